@@ -0,0 +1,293 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+)
+
+var (
+	errAdminSuspension  = errors.New("admins cannot be suspended")
+	errAdminReset       = errors.New("admins' passwords cannot be force reset")
+	errAlreadySuspended = errors.New("user is already suspended")
+	errNotSuspended     = errors.New("user is not suspended")
+)
+
+// adminUserResponse shows admins the account state of a user, along with its profile
+type adminUserResponse struct {
+	ID int64 `json:"id"`
+	userResponse
+	IsTotpEnabled bool      `json:"is_totp_enabled"`
+	IsSuspended   bool      `json:"is_suspended"`
+	SuspendedAt   time.Time `json:"suspended_at"`
+}
+
+func newAdminUserResponse(user db.User) adminUserResponse {
+	return adminUserResponse{
+		ID:            user.ID,
+		userResponse:  newUserResponse(user),
+		IsTotpEnabled: user.IsTotpEnabled,
+		IsSuspended:   user.SuspendedAt.Valid,
+		SuspendedAt:   user.SuspendedAt.Time,
+	}
+}
+
+type listAdminUserRequest struct {
+	pageRequest
+}
+
+// listAdminUser lists every user, including the suspended ones and those who blocked the admin
+func (server *Server) listAdminUser(ctx *gin.Context) {
+	var req listAdminUserRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var users []db.User
+	if p.Backward {
+		users, err = server.store.ListAllUsersBefore(ctx, db.ListAllUsersBeforeParams{
+			CursorID: p.Cursor.ID,
+			Limit:    p.limit(),
+		})
+	} else {
+		users, err = server.store.ListAllUsers(ctx, db.ListAllUsersParams{
+			CursorID: p.Cursor.nullID(),
+			Limit:    p.limit(),
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	users, next, prev := paginate(users, p, func(user db.User) pageCursor {
+		return pageCursor{ID: user.ID}
+	})
+
+	items := []adminUserResponse{}
+	for _, user := range users {
+		items = append(items, newAdminUserResponse(user))
+	}
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+type adminUserRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getAdminUser gets the user from the URI, writing the error response when it fails
+func (server *Server) getAdminUser(ctx *gin.Context) (db.User, bool) {
+	var req adminUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.User{}, false
+	}
+
+	user, err := server.store.GetUser(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return user, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return user, false
+	}
+	return user, true
+}
+
+// suspendUser stops the user from logging in, ending every session it has
+func (server *Server) suspendUser(ctx *gin.Context) {
+	user, ok := server.getAdminUser(ctx)
+	if !ok {
+		return
+	}
+
+	// Otherwise an admin could lock every other admin out
+	if user.Role == util.AdminRole {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAdminSuspension))
+		return
+	}
+
+	suspendedUser, err := server.store.SuspendUser(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusForbidden, errorResponse(errAlreadySuspended))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.endAllSessions(ctx, suspendedUser)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(suspendedUser))
+}
+
+// unsuspendUser lifts the suspension, so the user can log in again
+func (server *Server) unsuspendUser(ctx *gin.Context) {
+	user, ok := server.getAdminUser(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := server.store.UnsuspendUser(ctx, user.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusForbidden, errorResponse(errNotSuspended))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAdminUserResponse(user))
+}
+
+type forceResetPasswordResponse struct {
+	ResetEmailSent bool `json:"reset_email_sent"`
+}
+
+// forceResetPassword replaces the user's password with a random one, ending every session it has
+// The user is emailed a reset token when its email is verified, otherwise it must be given one some other way
+func (server *Server) forceResetPassword(ctx *gin.Context) {
+	user, ok := server.getAdminUser(ctx)
+	if !ok {
+		return
+	}
+
+	// Like suspensions, this would let an admin lock every other admin out
+	if user.Role == util.AdminRole {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAdminReset))
+		return
+	}
+
+	// Nobody knows the random password, so the old one stops working until the user resets it
+	password, err := util.RandomSecret(secretCodeSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err = server.store.ChangeUserPassword(ctx, db.ChangeUserPasswordParams{
+		ID:                user.ID,
+		HashPass:          hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.endAllSessions(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		err = server.sendPasswordReset(ctx, user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, forceResetPasswordResponse{ResetEmailSent: user.IsEmailVerified})
+}
+
+// deleteAdminMessage deletes an abusive message for everyone, regardless of its sender or how long ago it was sent
+func (server *Server) deleteAdminMessage(ctx *gin.Context) {
+	var uri messageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	message, err := server.store.GetMessage(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if message.DeletedAt.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(errors.New("message was already deleted")))
+		return
+	}
+
+	tombstone, err := server.store.DeleteMessageForEveryoneTx(ctx, message.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newMessageResponse(tombstone)
+
+	// Pushing the tombstone to the connected sessions of every member, including the sender
+	members, err := server.store.ListChatMembers(ctx, message.ChatID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	recipientIDs := []int64{}
+	for _, member := range members {
+		recipientIDs = append(recipientIDs, member.UserID)
+	}
+	server.hub.publish(eventMessageDeleted, rsp, recipientIDs...)
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type systemStatsResponse struct {
+	Users           int64 `json:"users"`
+	SuspendedUsers  int64 `json:"suspended_users"`
+	Chats           int64 `json:"chats"`
+	Messages        int64 `json:"messages"`
+	MessagesLastDay int64 `json:"messages_last_day"`
+	ActiveSessions  int64 `json:"active_sessions"`
+}
+
+func (server *Server) getSystemStats(ctx *gin.Context) {
+	stats, err := server.store.GetSystemStats(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, systemStatsResponse{
+		Users:           stats.Users,
+		SuspendedUsers:  stats.SuspendedUsers,
+		Chats:           stats.Chats,
+		Messages:        stats.Messages,
+		MessagesLastDay: stats.RecentMessages,
+		ActiveSessions:  stats.ActiveSessions,
+	})
+}
@@ -0,0 +1,635 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// serveAdminRequest serves a request authorized with a token granted the scopes of the role
+func serveAdminRequest(t *testing.T, store *mockdb.MockStore, role, method, url string) (*Server, *httptest.ResponseRecorder) {
+	stubTokenRevocation(store)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+
+	addRoleAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomUsername(), role, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	return server, recorder
+}
+
+func TestListAdminUserAPI(t *testing.T) {
+	users := []db.User{}
+	for i := 0; i < 6; i++ {
+		user, _ := randomUser(t)
+		user.ID = int64(i + 1)
+		users = append(users, user)
+	}
+	users[1].SuspendedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAllUsers(gomock.Any(), gomock.Eq(db.ListAllUsersParams{Limit: 6})).
+					Times(1).
+					Return(users, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []adminUserResponse `json:"items"`
+					NextCursor string              `json:"next_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 5)
+				require.Equal(t, users[0].ID, rsp.Items[0].ID)
+				require.Equal(t, users[0].Email.String, rsp.Items[0].Email)
+				require.False(t, rsp.Items[0].IsSuspended)
+				require.True(t, rsp.Items[1].IsSuspended)
+				require.Equal(t, pageCursor{ID: 5}.encode(), rsp.NextCursor)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.UserRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAllUsers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errMissingScope)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			_, recorder := serveAdminRequest(t, store, tc.role, http.MethodGet, "/admin/users")
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestSuspendUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+	suspendedUser := user
+	suspendedUser.SuspendedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		role          string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			role:   util.AdminRole,
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					SuspendUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(suspendedUser, nil)
+				// Every session of the suspended user is ended
+				store.EXPECT().
+					RevokeUserTokens(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp adminUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, user.ID, rsp.ID)
+				require.True(t, rsp.IsSuspended)
+			},
+		},
+		{
+			name:   "AdminUser",
+			role:   util.AdminRole,
+			userID: admin.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(admin.ID)).
+					Times(1).
+					Return(admin, nil)
+				store.EXPECT().
+					SuspendUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAdminSuspension)
+			},
+		},
+		{
+			name:   "AlreadySuspended",
+			role:   util.AdminRole,
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(suspendedUser, nil)
+				store.EXPECT().
+					SuspendUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errAlreadySuspended)
+			},
+		},
+		{
+			name:   "NotFound",
+			role:   util.AdminRole,
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "NotAdmin",
+			role:   util.UserRole,
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/admin/users/%d/suspend", tc.userID)
+			_, recorder := serveAdminRequest(t, store, tc.role, http.MethodPost, url)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUnsuspendUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	suspendedUser := user
+	suspendedUser.SuspendedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(suspendedUser, nil)
+				store.EXPECT().
+					UnsuspendUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp adminUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.False(t, rsp.IsSuspended)
+			},
+		},
+		{
+			name: "NotSuspended",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UnsuspendUser(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errNotSuspended)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/admin/users/%d/suspend", user.ID)
+			_, recorder := serveAdminRequest(t, store, util.AdminRole, http.MethodDelete, url)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestForceResetPasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+	user.IsEmailVerified = true
+	unverifiedUser := user
+	unverifiedUser.IsEmailVerified = false
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore, user db.User)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PasswordReset{UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp forceResetPasswordResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.ResetEmailSent)
+				require.Len(t, readMails(t, server), 1)
+			},
+		},
+		{
+			name: "UnverifiedEmail",
+			user: unverifiedUser,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				// Unverified emails aren't trusted with the reset token
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp forceResetPasswordResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.False(t, rsp.ResetEmailSent)
+				require.Empty(t, readMails(t, server))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(tc.user.ID)).
+				Times(1).
+				Return(tc.user, nil)
+			store.EXPECT().
+				ChangeUserPassword(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ any, arg db.ChangeUserPasswordParams) (db.User, error) {
+					// The old password no longer works
+					require.Equal(t, tc.user.ID, arg.ID)
+					require.Error(t, util.CheckPassword(password, arg.HashPass))
+
+					user := tc.user
+					user.HashPass = arg.HashPass
+					return user, nil
+				})
+			store.EXPECT().
+				RevokeUserTokens(gomock.Any(), gomock.Any()).
+				Times(1)
+			store.EXPECT().
+				BlockUserSessions(gomock.Any(), gomock.Eq(tc.user.Username)).
+				Times(1)
+			tc.buildStubs(store, tc.user)
+
+			url := fmt.Sprintf("/admin/users/%d/reset_password", tc.user.ID)
+			server, recorder := serveAdminRequest(t, store, util.AdminRole, http.MethodPost, url)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestForceResetAdminPasswordAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = util.AdminRole
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(admin.ID)).
+		Times(1).
+		Return(admin, nil)
+	store.EXPECT().
+		ChangeUserPassword(gomock.Any(), gomock.Any()).
+		Times(0)
+	store.EXPECT().
+		BlockUserSessions(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	url := fmt.Sprintf("/admin/users/%d/reset_password", admin.ID)
+	_, recorder := serveAdminRequest(t, store, util.AdminRole, http.MethodPost, url)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	requireBodyMatchError(t, recorder.Body, errAdminReset)
+}
+
+func TestDeleteAdminMessageAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	chat, message := randomMessage(user1, user2)
+	// Admins can delete messages no matter how long ago they were sent
+	message.SentAt = time.Now().Add(-24 * time.Hour)
+
+	deletedMessage := message
+	deletedMessage.Body = ""
+	deletedMessage.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(message, nil)
+				store.EXPECT().
+					DeleteMessageForEveryoneTx(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(deletedMessage, nil)
+				store.EXPECT().
+					ListChatMembers(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return([]db.ChatMember{{UserID: user1.ID}, {UserID: user2.ID}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp messageResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, message.ID, rsp.ID)
+				require.Empty(t, rsp.Body)
+			},
+		},
+		{
+			name: "AlreadyDeleted",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(deletedMessage, nil)
+				store.EXPECT().
+					DeleteMessageForEveryoneTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(db.Message{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.UserRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/admin/messages/%d", message.ID)
+			_, recorder := serveAdminRequest(t, store, tc.role, http.MethodDelete, url)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetSystemStatsAPI(t *testing.T) {
+	stats := db.GetSystemStatsRow{
+		Users:          10,
+		SuspendedUsers: 1,
+		Chats:          4,
+		Messages:       100,
+		RecentMessages: 20,
+		ActiveSessions: 5,
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSystemStats(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, since time.Time) (db.GetSystemStatsRow, error) {
+						require.WithinDuration(t, time.Now().Add(-24*time.Hour), since, time.Second)
+						return stats, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp systemStatsResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, stats.Users, rsp.Users)
+				require.Equal(t, stats.RecentMessages, rsp.MessagesLastDay)
+				require.Equal(t, stats.ActiveSessions, rsp.ActiveSessions)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: util.UserRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSystemStats(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSystemStats(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetSystemStatsRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			_, recorder := serveAdminRequest(t, store, tc.role, http.MethodGet, "/admin/stats")
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginSuspendedUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+	user.SuspendedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+	requireBodyMatchError(t, recorder.Body, errUserSuspended)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/storage"
+	"github.com/renatomh/api-simplechat/token"
+)
+
+const (
+	// Form field which holds the uploaded file
+	attachmentFormKey = "file"
+	// Room left for the multipart boundaries and headers on top of the file size limit
+	multipartOverhead = 1 << 20
+	// Number of bytes used to detect the content type of a file
+	sniffLength = 512
+)
+
+type attachmentResponse struct {
+	ID          int64     `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newAttachmentResponse(attachment db.Attachment) attachmentResponse {
+	return attachmentResponse{
+		ID:          attachment.ID,
+		FileName:    attachment.FileName,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
+
+// isAllowedContentType checks if files of a specific content type can be uploaded
+func (server *Server) isAllowedContentType(contentType string) bool {
+	for _, allowed := range server.config.AttachmentAllowedTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *Server) uploadAttachment(ctx *gin.Context) {
+	// Limiting the size of the request before reading the form
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, server.config.AttachmentMaxSize+multipartOverhead)
+
+	fileHeader, err := ctx.FormFile(attachmentFormKey)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(fmt.Errorf("file is too large")))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if fileHeader.Size > server.config.AttachmentMaxSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errorResponse(fmt.Errorf("file is too large")))
+		return
+	}
+	if fileHeader.Size == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("file is empty")))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer file.Close()
+
+	// The content type sent by the client can't be trusted, so it's detected from the file itself
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil || !server.isAllowedContentType(contentType) {
+		ctx.JSON(http.StatusUnsupportedMediaType, errorResponse(fmt.Errorf("file type %s is not allowed", contentType)))
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	key := uuid.New().String()
+	size, err := server.blobs.Put(ctx, key, file)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateAttachmentParams{
+		UploaderID:  user.ID,
+		StorageKey:  key,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        size,
+	}
+	attachment, err := server.store.CreateAttachment(ctx, arg)
+	if err != nil {
+		// The file is useless without its record, so it's removed right away
+		server.blobs.Delete(ctx, key)
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAttachmentResponse(attachment))
+}
+
+type attachmentRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getAttachment(ctx *gin.Context) {
+	var req attachmentRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	attachment, err := server.store.GetAttachment(ctx, req.ID)
+	if err != nil {
+		// If no item was found
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !attachment.MessageID.Valid {
+		// Attachments which weren't sent yet are only available to the uploader
+		if attachment.UploaderID != user.ID {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("attachment does not belong to you")))
+			return
+		}
+	} else {
+		// Otherwise, the user must take part on the chat where it was sent
+		message, ok := server.getChatMessage(ctx, attachment.MessageID.Int64, user.ID)
+		if !ok {
+			return
+		}
+		if message.DeletedAt.Valid {
+			ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("message was deleted")))
+			return
+		}
+	}
+
+	reader, err := server.blobs.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer reader.Close()
+
+	headers := map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+	}
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, headers)
+}
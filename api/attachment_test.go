@@ -0,0 +1,324 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// Contents of files detected as an allowed and a disallowed type
+var (
+	pngContent = append([]byte("\x89PNG\r\n\x1a\n"), []byte(util.RandomString(32))...)
+	pdfContent = append([]byte("%PDF-1.4\n"), []byte(util.RandomString(32))...)
+)
+
+// newMultipartBody creates a multipart body with a single file
+func newMultipartBody(t *testing.T, fileName string, content []byte) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile(attachmentFormKey, fileName)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return body, writer.FormDataContentType()
+}
+
+func TestUploadAttachmentAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		fileName      string
+		content       []byte
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			fileName: "picture.png",
+			content:  pngContent,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAttachment(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAttachmentParams) (db.Attachment, error) {
+						require.Equal(t, user.ID, arg.UploaderID)
+						require.Equal(t, "picture.png", arg.FileName)
+						require.Equal(t, "image/png", arg.ContentType)
+						require.Equal(t, int64(len(pngContent)), arg.Size)
+						return db.Attachment{
+							ID:          util.RandomInt(1, 1000),
+							UploaderID:  arg.UploaderID,
+							StorageKey:  arg.StorageKey,
+							FileName:    arg.FileName,
+							ContentType: arg.ContentType,
+							Size:        arg.Size,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp attachmentResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, "image/png", rsp.ContentType)
+				require.Equal(t, int64(len(pngContent)), rsp.Size)
+			},
+		},
+		{
+			name:     "UnsupportedType",
+			fileName: "document.png",
+			content:  pdfContent,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAttachment(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:     "TooLarge",
+			fileName: "large.txt",
+			content:  []byte(util.RandomString(2048)),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAttachment(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:     "EmptyFile",
+			fileName: "empty.txt",
+			content:  []byte{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAttachment(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			fileName: "notes.txt",
+			content:  []byte(util.RandomString(64)),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAttachment(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAttachmentParams) (db.Attachment, error) {
+						require.Equal(t, "text/plain", arg.ContentType)
+						return db.Attachment{}, sql.ErrConnDone
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+				// The stored file must be removed along with the failed upload
+				entries, err := os.ReadDir(server.config.BlobStoragePath)
+				require.NoError(t, err)
+				require.Empty(t, entries)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body, contentType := newMultipartBody(t, tc.fileName, tc.content)
+			request, err := http.NewRequest(http.MethodPost, "/attachments", body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", contentType)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, server, recorder)
+		})
+	}
+}
+
+func TestGetAttachmentAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	chat, message := randomMessage(otherUser, user)
+
+	// Attachments sent with the message and still waiting to be sent
+	sentAttachment := db.Attachment{
+		ID:          util.RandomInt(1, 1000),
+		UploaderID:  otherUser.ID,
+		MessageID:   sql.NullInt64{Int64: message.ID, Valid: true},
+		StorageKey:  util.RandomString(16),
+		FileName:    "picture.png",
+		ContentType: "image/png",
+		Size:        int64(len(pngContent)),
+	}
+	unsentAttachment := sentAttachment
+	unsentAttachment.MessageID = sql.NullInt64{}
+
+	testCases := []struct {
+		name          string
+		attachment    db.Attachment
+		buildStubs    func(store *mockdb.MockStore, attachment db.Attachment)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OK",
+			attachment: sentAttachment,
+			buildStubs: func(store *mockdb.MockStore, attachment db.Attachment) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(message, nil)
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return(chat, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Eq(db.GetChatMemberParams{ChatID: chat.ID, UserID: user.ID})).
+					Times(1).
+					Return(db.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: db.ChatRoleMember}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "image/png", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), "picture.png")
+
+				content, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+				require.Equal(t, pngContent, content)
+			},
+		},
+		{
+			name:       "NotChatMember",
+			attachment: sentAttachment,
+			buildStubs: func(store *mockdb.MockStore, attachment db.Attachment) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(message, nil)
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return(chat, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChatMember{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "UnsentFromOtherUser",
+			attachment: unsentAttachment,
+			buildStubs: func(store *mockdb.MockStore, attachment db.Attachment) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "NotFound",
+			attachment: db.Attachment{ID: sentAttachment.ID},
+			buildStubs: func(store *mockdb.MockStore, attachment db.Attachment) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+
+			getAttachment := store.EXPECT().
+				GetAttachment(gomock.Any(), gomock.Eq(tc.attachment.ID)).
+				Times(1)
+			if tc.attachment.StorageKey == "" {
+				getAttachment.Return(db.Attachment{}, sql.ErrNoRows)
+			} else {
+				getAttachment.Return(tc.attachment, nil)
+			}
+			tc.buildStubs(store, tc.attachment)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			_, err := server.blobs.Put(context.Background(), sentAttachment.StorageKey, bytes.NewReader(pngContent))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/attachments/%d", tc.attachment.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+)
+
+// The error doesn't tell which of the users blocked the other
+var errUserBlocked = errors.New("cannot interact with this user")
+
+type blockedUserResponse struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// checkNotBlocked checks that neither user blocked the other, writing the error response when it fails
+func (server *Server) checkNotBlocked(ctx *gin.Context, userID, otherUserID int64) bool {
+	blocked, err := server.store.IsBlockedBetween(ctx, db.IsBlockedBetweenParams{
+		UserID:      userID,
+		OtherUserID: otherUserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if blocked {
+		ctx.JSON(http.StatusForbidden, errorResponse(errUserBlocked))
+		return false
+	}
+	return true
+}
+
+type blockUserRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) blockUser(ctx *gin.Context) {
+	var req blockUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.ID == req.ID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("a user cannot block itself")))
+		return
+	}
+
+	// Checking if the user to be blocked exists
+	blockedUser, err := server.store.GetUser(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Blocking an already blocked user keeps the original block
+	block, err := server.store.BlockUser(ctx, db.BlockUserParams{
+		BlockerID: user.ID,
+		BlockedID: blockedUser.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, blockedUserResponse{
+		ID:        blockedUser.ID,
+		Username:  blockedUser.Username,
+		FullName:  blockedUser.FullName,
+		BlockedAt: block.CreatedAt,
+	})
+}
+
+func (server *Server) unblockUser(ctx *gin.Context) {
+	var req blockUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Only blocks made by the user can be removed by it
+	removed, err := server.store.UnblockUser(ctx, db.UnblockUserParams{
+		BlockerID: user.ID,
+		BlockedID: req.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if removed == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(fmt.Errorf("user %d is not blocked", req.ID)))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type listBlockedUserRequest struct {
+	pageRequest
+}
+
+func (server *Server) listBlockedUser(ctx *gin.Context) {
+	var req listBlockedUserRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var blockedUsers []db.ListBlockedUsersRow
+	if p.Backward {
+		var rows []db.ListBlockedUsersBeforeRow
+		rows, err = server.store.ListBlockedUsersBefore(ctx, db.ListBlockedUsersBeforeParams{
+			BlockerID: user.ID,
+			CursorID:  p.Cursor.ID,
+			Limit:     p.limit(),
+		})
+		for _, row := range rows {
+			blockedUsers = append(blockedUsers, db.ListBlockedUsersRow(row))
+		}
+	} else {
+		blockedUsers, err = server.store.ListBlockedUsers(ctx, db.ListBlockedUsersParams{
+			BlockerID: user.ID,
+			CursorID:  p.Cursor.nullID(),
+			Limit:     p.limit(),
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	blockedUsers, next, prev := paginate(blockedUsers, p, func(blockedUser db.ListBlockedUsersRow) pageCursor {
+		return pageCursor{ID: blockedUser.ID}
+	})
+
+	items := []blockedUserResponse{}
+	for _, blockedUser := range blockedUsers {
+		items = append(items, blockedUserResponse{
+			ID:        blockedUser.ID,
+			Username:  blockedUser.Username,
+			FullName:  blockedUser.FullName,
+			BlockedAt: blockedUser.BlockedAt,
+		})
+	}
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
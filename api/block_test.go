@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	blockedUser, _ := randomUser(t)
+	for blockedUser.ID == user.ID {
+		blockedUser, _ = randomUser(t)
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		userID        int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Block",
+			method: http.MethodPost,
+			userID: blockedUser.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(blockedUser.ID)).
+					Times(1).
+					Return(blockedUser, nil)
+				store.EXPECT().
+					BlockUser(gomock.Any(), gomock.Eq(db.BlockUserParams{BlockerID: user.ID, BlockedID: blockedUser.ID})).
+					Times(1).
+					Return(db.UserBlock{BlockerID: user.ID, BlockedID: blockedUser.ID, CreatedAt: time.Now()}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp blockedUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, blockedUser.ID, rsp.ID)
+				require.Equal(t, blockedUser.Username, rsp.Username)
+			},
+		},
+		{
+			name:   "BlockItself",
+			method: http.MethodPost,
+			userID: user.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "BlockNotFound",
+			method: http.MethodPost,
+			userID: blockedUser.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(blockedUser.ID)).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					BlockUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "Unblock",
+			method: http.MethodDelete,
+			userID: blockedUser.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UnblockUser(gomock.Any(), gomock.Eq(db.UnblockUserParams{BlockerID: user.ID, BlockedID: blockedUser.ID})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:   "UnblockNotBlocked",
+			method: http.MethodDelete,
+			userID: blockedUser.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UnblockUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/users/%d/block", tc.userID)
+			request, err := http.NewRequest(tc.method, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestBlockedUserInteractionAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	for otherUser.ID == user.ID {
+		otherUser, _ = randomUser(t)
+	}
+	chat, _ := randomMessage(user, otherUser)
+
+	testCases := []struct {
+		name       string
+		url        string
+		body       gin.H
+		buildStubs func(store *mockdb.MockStore)
+	}{
+		{
+			name: "CreateContact",
+			url:  "/contacts",
+			body: gin.H{"username": otherUser.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(otherUser.Username)).
+					Times(1).
+					Return(otherUser, nil)
+				store.EXPECT().
+					CreateContact(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+		{
+			name: "SendMessage",
+			url:  "/messages",
+			body: gin.H{"chat_id": chat.ID, "body": "Hello!"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return(chat, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: db.ChatRoleMember}, nil)
+				store.EXPECT().
+					SendMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				IsBlockedBetween(gomock.Any(), gomock.Eq(db.IsBlockedBetweenParams{UserID: user.ID, OtherUserID: otherUser.ID})).
+				Times(1).
+				Return(true, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusForbidden, recorder.Code)
+		})
+	}
+}
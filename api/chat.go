@@ -3,7 +3,9 @@ package api
 import (
 	"database/sql"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -12,7 +14,125 @@ import (
 )
 
 type createChatRequest struct {
-	ContactID int64 `json:"contact_id" binding:"required"`
+	// Used to start a direct chat with an accepted contact
+	ContactID int64 `json:"contact_id" binding:"required_without=Title,excluded_with=Title"`
+	// Used to create a group chat, with members drawn from the accepted contacts
+	Title     string  `json:"title" binding:"required_without=ContactID,excluded_with=ContactID,max=100"`
+	MemberIDs []int64 `json:"member_ids" binding:"excluded_with=ContactID,dive,min=1"`
+}
+
+type chatResponse struct {
+	ID      int64  `json:"id"`
+	Title   string `json:"title,omitempty"`
+	IsGroup bool   `json:"is_group"`
+	// The from/to order makes no difference here, empty for group chats
+	FromUserID            int64           `json:"from_user_id,omitempty"`
+	ToUserID              int64           `json:"to_user_id,omitempty"`
+	LastMessageReceivedAt sql.NullTime    `json:"last_message_received_at"`
+	CreatedAt             time.Time       `json:"created_at"`
+	Members               []db.ChatMember `json:"members,omitempty"`
+	// Only filled when listing the chats of the user
+	UnreadCount       int32               `json:"unread_count"`
+	LastReadMessageID int64               `json:"last_read_message_id,omitempty"`
+	LastMessage       *lastMessagePreview `json:"last_message,omitempty"`
+}
+
+// Maximum number of characters of the last message shown on the chat listing
+const lastMessagePreviewLength = 100
+
+type lastMessagePreview struct {
+	ID         int64     `json:"id"`
+	FromUserID int64     `json:"from_user_id"`
+	Body       string    `json:"body"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+func newChatResponse(chat db.Chat, members []db.ChatMember) chatResponse {
+	return chatResponse{
+		ID:                    chat.ID,
+		Title:                 chat.Title.String,
+		IsGroup:               chat.IsGroup,
+		FromUserID:            chat.FromUserID.Int64,
+		ToUserID:              chat.ToUserID.Int64,
+		LastMessageReceivedAt: chat.LastMessageReceivedAt,
+		CreatedAt:             chat.CreatedAt,
+		Members:               members,
+	}
+}
+
+func newChatListResponse(chat db.ListChatsRow) chatResponse {
+	rsp := chatResponse{
+		ID:                    chat.ID,
+		Title:                 chat.Title.String,
+		IsGroup:               chat.IsGroup,
+		FromUserID:            chat.FromUserID.Int64,
+		ToUserID:              chat.ToUserID.Int64,
+		LastMessageReceivedAt: chat.LastMessageReceivedAt,
+		CreatedAt:             chat.CreatedAt,
+		UnreadCount:           chat.UnreadCount,
+		LastReadMessageID:     chat.LastReadMessageID.Int64,
+	}
+
+	// Chats without messages have nothing to preview
+	if chat.LastMessageID.Valid {
+		body := []rune(chat.LastMessageBody.String)
+		if len(body) > lastMessagePreviewLength {
+			body = body[:lastMessagePreviewLength]
+		}
+		rsp.LastMessage = &lastMessagePreview{
+			ID:         chat.LastMessageID.Int64,
+			FromUserID: chat.LastMessageFromUserID.Int64,
+			Body:       string(body),
+			SentAt:     chat.LastMessageSentAt.Time,
+		}
+	}
+
+	return rsp
+}
+
+// isAcceptedContact checks if two users have an accepted contact between them
+func (server *Server) isAcceptedContact(ctx *gin.Context, userID, otherUserID int64) (bool, error) {
+	contacts, err := server.store.CheckExistingContact(ctx, db.CheckExistingContactParams{
+		FromUserID: userID,
+		ToUserID:   otherUserID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(contacts) > 0 && contacts[0].Status == "Accepted", nil
+}
+
+// getChatMembership gets a chat and the membership of the user on it, writing the error response when it fails
+func (server *Server) getChatMembership(ctx *gin.Context, chatID, userID int64) (db.Chat, db.ChatMember, bool) {
+	// Checking if chat exists
+	chat, err := server.store.GetChat(ctx, chatID)
+	if err != nil {
+		// If no item was found
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return chat, db.ChatMember{}, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return chat, db.ChatMember{}, false
+	}
+
+	// Checking if user takes part on the chat
+	member, err := server.store.GetChatMember(ctx, db.GetChatMemberParams{
+		ChatID: chat.ID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("you are not a member of this chat")))
+			return chat, member, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return chat, member, false
+	}
+
+	return chat, member, true
 }
 
 func (server *Server) createChat(ctx *gin.Context) {
@@ -28,6 +148,11 @@ func (server *Server) createChat(ctx *gin.Context) {
 	// Getting user which requested the connection
 	user, _ := server.store.GetUserByUsername(ctx, authPayload.Username)
 
+	if req.ContactID == 0 {
+		server.createGroupChat(ctx, user, req)
+		return
+	}
+
 	// Checking if contact exists
 	contact, err := server.store.GetContact(ctx, req.ContactID)
 	if err != nil {
@@ -53,24 +178,29 @@ func (server *Server) createChat(ctx *gin.Context) {
 		return
 	}
 
+	// Checking if any of the users blocked the other
+	if !server.checkNotBlocked(ctx, contact.FromUserID, contact.ToUserID) {
+		return
+	}
+
+	arg := db.CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
+	}
+
 	// Checking if chat already exists
 	existingChat, err := server.store.GetChatByUserIDs(
 		ctx,
 		db.GetChatByUserIDsParams{
-			FromUserID: contact.FromUserID,
-			ToUserID:   contact.ToUserID,
+			FromUserID: arg.FromUserID,
+			ToUserID:   arg.ToUserID,
 		})
 	if err == nil {
 		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("there's already an existing chat with this contact %d", existingChat.ID)))
 		return
 	}
 
-	arg := db.CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
-	}
-
-	chat, err := server.store.CreateChat(ctx, arg)
+	result, err := server.store.CreateDirectChatTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -78,17 +208,57 @@ func (server *Server) createChat(ctx *gin.Context) {
 				ctx.JSON(http.StatusForbidden, errorResponse(err))
 				return
 			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newChatResponse(result.Chat, result.Members))
+}
+
+// createGroupChat creates a group chat owned by the user, with members drawn from its accepted contacts
+func (server *Server) createGroupChat(ctx *gin.Context, user db.User, req createChatRequest) {
+	memberIDs := []int64{}
+	seen := map[int64]bool{user.ID: true}
+	for _, memberID := range req.MemberIDs {
+		// Ignoring duplicates and the owner itself
+		if seen[memberID] {
+			continue
+		}
+		seen[memberID] = true
+
+		// Checking if the member is an accepted contact of the user
+		accepted, err := server.isAcceptedContact(ctx, user.ID, memberID)
+		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 			return
 		}
+		if !accepted {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("user %d is not an accepted contact", memberID)))
+			return
+		}
+		if !server.checkNotBlocked(ctx, user.ID, memberID) {
+			return
+		}
+
+		memberIDs = append(memberIDs, memberID)
+	}
+
+	result, err := server.store.CreateGroupChatTx(ctx, db.CreateGroupChatTxParams{
+		Title:     req.Title,
+		OwnerID:   user.ID,
+		MemberIDs: memberIDs,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	ctx.JSON(http.StatusOK, chat)
+	ctx.JSON(http.StatusOK, newChatResponse(result.Chat, result.Members))
 }
 
 type listChatRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
 }
 
 func (server *Server) listChat(ctx *gin.Context) {
@@ -103,17 +273,522 @@ func (server *Server) listChat(ctx *gin.Context) {
 
 	// Querying the user item by the username
 	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Chats are listed from the most recently active ones
+	var chats []db.ListChatsRow
+	if p.Backward {
+		var rows []db.ListChatsBeforeRow
+		rows, err = server.store.ListChatsBefore(ctx, db.ListChatsBeforeParams{
+			UserID:                      user.ID,
+			CursorLastMessageReceivedAt: p.Cursor.nullTime(),
+			CursorID:                    p.Cursor.ID,
+			Limit:                       p.limit(),
+		})
+		for _, row := range rows {
+			chats = append(chats, db.ListChatsRow(row))
+		}
+	} else {
+		chats, err = server.store.ListChats(ctx, db.ListChatsParams{
+			UserID:                      user.ID,
+			CursorID:                    p.Cursor.nullID(),
+			CursorLastMessageReceivedAt: p.Cursor.nullTime(),
+			Limit:                       p.limit(),
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chats, next, prev := paginate(chats, p, func(chat db.ListChatsRow) pageCursor {
+		cursor := pageCursor{ID: chat.ID}
+		if chat.LastMessageReceivedAt.Valid {
+			cursor.Time = &chat.LastMessageReceivedAt.Time
+		}
+		return cursor
+	})
+
+	items := []chatResponse{}
+	for _, chat := range chats {
+		items = append(items, newChatListResponse(chat))
+	}
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+type chatRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type chatMemberRequest struct {
+	ID     int64 `uri:"id" binding:"required,min=1"`
+	UserID int64 `uri:"user_id" binding:"required,min=1"`
+}
+
+func (server *Server) listChatMember(ctx *gin.Context) {
+	var req chatRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Only members can see who else takes part on the chat
+	chat, _, ok := server.getChatMembership(ctx, req.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	members, err := server.store.ListChatMembers(ctx, chat.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+type addChatMemberRequest struct {
+	UserID int64 `json:"user_id" binding:"required,min=1"`
+}
+
+func (server *Server) addChatMember(ctx *gin.Context) {
+	var uri chatRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req addChatMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chat, member, ok := server.getChatMembership(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Direct chats always have the same two members
+	if !chat.IsGroup {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot add members to a direct chat")))
+		return
+	}
+
+	// Only owners and admins can manage the members
+	if member.Role == db.ChatRoleMember {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only the owner and admins can add members")))
+		return
+	}
+
+	// New members are drawn from the accepted contacts of who's adding them
+	accepted, err := server.isAcceptedContact(ctx, user.ID, req.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !accepted {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("user %d is not an accepted contact", req.UserID)))
+		return
+	}
+	if !server.checkNotBlocked(ctx, user.ID, req.UserID) {
+		return
+	}
+
+	newMember, err := server.store.AddChatMember(ctx, db.AddChatMemberParams{
+		ChatID: chat.ID,
+		UserID: req.UserID,
+		Role:   db.ChatRoleMember,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("user %d is already a member of this chat", req.UserID)))
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMember)
+}
+
+func (server *Server) removeChatMember(ctx *gin.Context) {
+	var req chatMemberRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chat, member, ok := server.getChatMembership(ctx, req.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Direct chats always have the same two members
+	if !chat.IsGroup {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot remove members from a direct chat")))
+		return
+	}
+
+	// Members leave the chat through the specific route
+	if req.UserID == user.ID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("use the leave route to leave the chat")))
+		return
+	}
+
+	target, err := server.store.GetChatMember(ctx, db.GetChatMemberParams{
+		ChatID: chat.ID,
+		UserID: req.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The owner can remove anyone, while admins can only remove regular members
+	if member.Role != db.ChatRoleOwner && (member.Role != db.ChatRoleAdmin || target.Role != db.ChatRoleMember) {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("not allowed to remove this member")))
+		return
+	}
+
+	err = server.store.RemoveChatMember(ctx, db.RemoveChatMemberParams{
+		ChatID: chat.ID,
+		UserID: target.UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type updateChatMemberRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin member"`
+}
+
+func (server *Server) updateChatMemberRole(ctx *gin.Context) {
+	var uri chatMemberRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateChatMemberRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chat, member, ok := server.getChatMembership(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Only the owner can promote and demote members
+	if !chat.IsGroup || member.Role != db.ChatRoleOwner {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only the owner can change member roles")))
+		return
+	}
+
+	// The ownership can only be handed over through the transfer route
+	if uri.UserID == user.ID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("use the transfer route to hand over the ownership")))
+		return
+	}
+
+	updatedMember, err := server.store.UpdateChatMemberRole(ctx, db.UpdateChatMemberRoleParams{
+		ChatID: chat.ID,
+		UserID: uri.UserID,
+		Role:   req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updatedMember)
+}
+
+func (server *Server) leaveChat(ctx *gin.Context) {
+	var req chatRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chat, member, ok := server.getChatMembership(ctx, req.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	if !chat.IsGroup {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot leave a direct chat")))
+		return
+	}
+
+	// The owner must hand over the chat before leaving it, unless there's no one else
+	if member.Role == db.ChatRoleOwner {
+		members, err := server.store.ListChatMembers(ctx, chat.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if len(members) > 1 {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("transfer the ownership before leaving the chat")))
+			return
+		}
+	}
+
+	err = server.store.RemoveChatMember(ctx, db.RemoveChatMemberParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+type transferChatOwnershipRequest struct {
+	UserID int64 `json:"user_id" binding:"required,min=1"`
+}
 
-	arg := db.ListChatsParams{
+func (server *Server) transferChatOwnership(ctx *gin.Context) {
+	var uri chatRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req transferChatOwnershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chat, member, ok := server.getChatMembership(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	if !chat.IsGroup || member.Role != db.ChatRoleOwner {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only the owner can transfer the ownership")))
+		return
+	}
+
+	if req.UserID == user.ID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("you already own this chat")))
+		return
+	}
+
+	// The new owner must already be a member of the chat
+	_, err = server.store.GetChatMember(ctx, db.GetChatMemberParams{
+		ChatID: chat.ID,
+		UserID: req.UserID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.TransferChatOwnershipTx(ctx, db.TransferChatOwnershipTxParams{
+		ChatID:     chat.ID,
 		FromUserID: user.ID,
-		Limit:      req.PageSize,
-		Offset:     (req.PageID - 1) * req.PageSize,
+		ToUserID:   req.UserID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
-	chats, err := server.store.ListChats(ctx, arg)
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type markChatReadRequest struct {
+	// Optional, defaults to the last message of the chat
+	MessageID int64 `json:"message_id" binding:"omitempty,min=1"`
+}
+
+type readReceiptResponse struct {
+	ChatID            int64        `json:"chat_id"`
+	UserID            int64        `json:"user_id"`
+	LastReadMessageID int64        `json:"last_read_message_id,omitempty"`
+	LastReadAt        sql.NullTime `json:"last_read_at"`
+	UnreadCount       int32        `json:"unread_count"`
+}
+
+func newReadReceiptResponse(member db.ChatMember) readReceiptResponse {
+	return readReceiptResponse{
+		ChatID:            member.ChatID,
+		UserID:            member.UserID,
+		LastReadMessageID: member.LastReadMessageID.Int64,
+		LastReadAt:        member.LastReadAt,
+		UnreadCount:       member.UnreadCount,
+	}
+}
+
+func (server *Server) markChatRead(ctx *gin.Context) {
+	var uri chatRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req markChatReadRequest
+	// The body is optional, so an empty one is accepted
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	chat, member, ok := server.getChatMembership(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Getting the message up to which the chat was read
+	var message db.Message
+	if req.MessageID == 0 {
+		message, err = server.store.GetLastChatMessage(ctx, chat.ID)
+	} else {
+		message, err = server.store.GetMessage(ctx, req.MessageID)
+	}
+	if err != nil {
+		// Chats without messages have nothing to be read
+		if err == sql.ErrNoRows && req.MessageID == 0 {
+			ctx.JSON(http.StatusOK, newReadReceiptResponse(member))
+			return
+		}
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if message.ChatID != chat.ID {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("message does not belong to this chat")))
+		return
+	}
+
+	updatedMember, err := server.store.MarkChatRead(ctx, db.MarkChatReadParams{
+		MessageID: message.ID,
+		ChatID:    chat.ID,
+		UserID:    user.ID,
+	})
 	if err != nil {
+		// The read cursor never moves back, so older messages are already read
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusOK, newReadReceiptResponse(member))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, chats)
+	rsp := newReadReceiptResponse(updatedMember)
+
+	// Notifying the other members, so they can render the messages as seen
+	members, err := server.store.ListChatMembers(ctx, chat.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	recipientIDs := []int64{}
+	for _, m := range members {
+		if m.UserID != user.ID {
+			recipientIDs = append(recipientIDs, m.UserID)
+		}
+	}
+	server.hub.publish(eventChatRead, rsp, recipientIDs...)
+
+	ctx.JSON(http.StatusOK, rsp)
 }
@@ -75,6 +75,11 @@ func (server *Server) createContact(ctx *gin.Context) {
 		return
 	}
 
+	// Blocked users can't request contacts, whichever side made the block
+	if !server.checkNotBlocked(ctx, fromUser.ID, toUser.ID) {
+		return
+	}
+
 	// Checking if there's already a connection between the users
 	existingContact, err := server.store.CheckExistingContact(
 		ctx,
@@ -83,8 +88,12 @@ func (server *Server) createContact(ctx *gin.Context) {
 			ToUserID:   toUser.ID,
 		},
 	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 	if len(existingContact) > 0 {
-		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("there's already a connection between the users")))
+		server.renewContactRequest(ctx, existingContact[0], fromUser, toUser)
 		return
 	}
 
@@ -101,75 +110,106 @@ func (server *Server) createContact(ctx *gin.Context) {
 				ctx.JSON(http.StatusForbidden, errorResponse(err))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
 		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
 	rsp := newContactResponse(contact, fromUser, toUser)
-	ctx.JSON(http.StatusOK, rsp)
-}
 
-type listContactRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	// Notifying the requested user about the new contact request
+	server.hub.publish(eventContactRequested, rsp, toUser.ID)
+
+	ctx.JSON(http.StatusOK, rsp)
 }
 
-func (server *Server) listContact(ctx *gin.Context) {
-	var req listContactRequest
-	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+// renewContactRequest requests a rejected contact again, as long as the rejected user waited for the cooldown
+func (server *Server) renewContactRequest(ctx *gin.Context, contact db.Contact, fromUser, toUser db.User) {
+	if contact.Status != "Rejected" {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("there's already a connection between the users")))
 		return
 	}
 
-	// Getting the user which made the request
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-
-	// Querying the user item by the username
-	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
-
-	arg := db.ListContactsParams{
-		FromUserID: user.ID,
-		Limit:      req.PageSize,
-		Offset:     (req.PageID - 1) * req.PageSize,
+	// The user who rejected the request may change its mind at any time
+	if contact.FromUserID == fromUser.ID {
+		retryAt := contact.RejectedAt.Time.Add(server.config.ContactRequestCooldown)
+		if time.Now().Before(retryAt) {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("the contact request was rejected, it can be sent again after %s", retryAt.Format(time.RFC3339))))
+			return
+		}
 	}
-	contacts, err := server.store.ListContacts(ctx, arg)
+
+	contact, err := server.store.RenewContactRequest(ctx, db.RenewContactRequestParams{
+		ID:         contact.ID,
+		FromUserID: fromUser.ID,
+		ToUserID:   toUser.ID,
+	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, contacts)
+	rsp := newContactResponse(contact, fromUser, toUser)
+
+	// Notifying the requested user about the new contact request
+	server.hub.publish(eventContactRequested, rsp, toUser.ID)
+
+	ctx.JSON(http.StatusOK, rsp)
 }
 
-func (server *Server) listPendingContact(ctx *gin.Context) {
-	var req listContactRequest
-	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return
-	}
+// Directions of a contact, from the point of view of the user listing it
+const (
+	contactDirectionIncoming = "incoming"
+	contactDirectionOutgoing = "outgoing"
+)
 
-	// Getting the user which made the request
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+type listContactRequest struct {
+	Status    string `form:"status" binding:"omitempty,oneof=Pending Accepted Rejected"`
+	Direction string `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	pageRequest
+}
 
-	// Querying the user item by the username
-	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+// counterpartResponse holds the public profile of the other side of a contact
+type counterpartResponse struct {
+	ID          int64     `json:"id"`
+	Username    string    `json:"username"`
+	FullName    string    `json:"full_name"`
+	AvatarUrl   string    `json:"avatar_url"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+type contactListResponse struct {
+	ID          int64        `json:"id"`
+	Status      string       `json:"status"`
+	RequestedAt time.Time    `json:"requested_at"`
+	AcceptedAt  sql.NullTime `json:"accepted_at"`
+	// Incoming for requests made by the counterpart, outgoing for the ones made by the user
+	Direction   string              `json:"direction"`
+	Counterpart counterpartResponse `json:"counterpart"`
+}
 
-	arg := db.ListPendingContactsParams{
-		FromUserID: user.ID,
-		Limit:      req.PageSize,
-		Offset:     (req.PageID - 1) * req.PageSize,
+func newContactListResponse(contact db.ListContactsRow) contactListResponse {
+	direction := contactDirectionIncoming
+	if contact.IsOutgoing {
+		direction = contactDirectionOutgoing
 	}
-	contacts, err := server.store.ListPendingContacts(ctx, arg)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+	return contactListResponse{
+		ID:          contact.ID,
+		Status:      contact.Status,
+		RequestedAt: contact.RequestedAt,
+		AcceptedAt:  contact.AcceptedAt,
+		Direction:   direction,
+		Counterpart: counterpartResponse{
+			ID:          contact.CounterpartID,
+			Username:    contact.CounterpartUsername,
+			FullName:    contact.CounterpartFullName,
+			AvatarUrl:   contact.CounterpartAvatarUrl.String,
+			LastLoginAt: contact.CounterpartLastLoginAt.Time,
+		},
 	}
-
-	ctx.JSON(http.StatusOK, contacts)
 }
 
-func (server *Server) listAcceptedContact(ctx *gin.Context) {
+func (server *Server) listContact(ctx *gin.Context) {
 	var req listContactRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
@@ -181,46 +221,61 @@ func (server *Server) listAcceptedContact(ctx *gin.Context) {
 
 	// Querying the user item by the username
 	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
-
-	arg := db.ListAcceptedContactsParams{
-		FromUserID: user.ID,
-		Limit:      req.PageSize,
-		Offset:     (req.PageID - 1) * req.PageSize,
-	}
-	contacts, err := server.store.ListAcceptedContacts(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, contacts)
-}
-
-func (server *Server) listRejectedContact(ctx *gin.Context) {
-	var req listContactRequest
-	if err := ctx.ShouldBindQuery(&req); err != nil {
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// Getting the user which made the request
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-
-	// Querying the user item by the username
-	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
-
-	arg := db.ListRejectedContactsParams{
-		FromUserID: user.ID,
-		Limit:      req.PageSize,
-		Offset:     (req.PageID - 1) * req.PageSize,
+	// Both filters are optional
+	status := sql.NullString{String: req.Status, Valid: req.Status != ""}
+	isOutgoing := sql.NullBool{Bool: req.Direction == contactDirectionOutgoing, Valid: req.Direction != ""}
+
+	var contacts []db.ListContactsRow
+	if p.Backward {
+		var rows []db.ListContactsBeforeRow
+		rows, err = server.store.ListContactsBefore(ctx, db.ListContactsBeforeParams{
+			UserID:     user.ID,
+			Status:     status,
+			IsOutgoing: isOutgoing,
+			CursorID:   p.Cursor.ID,
+			Limit:      p.limit(),
+		})
+		for _, row := range rows {
+			contacts = append(contacts, db.ListContactsRow(row))
+		}
+	} else {
+		contacts, err = server.store.ListContacts(ctx, db.ListContactsParams{
+			UserID:     user.ID,
+			Status:     status,
+			IsOutgoing: isOutgoing,
+			CursorID:   p.Cursor.nullID(),
+			Limit:      p.limit(),
+		})
 	}
-	contacts, err := server.store.ListRejectedContacts(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, contacts)
+	contacts, next, prev := paginate(contacts, p, func(contact db.ListContactsRow) pageCursor {
+		return pageCursor{ID: contact.ID}
+	})
+
+	items := []contactListResponse{}
+	for _, contact := range contacts {
+		items = append(items, newContactListResponse(contact))
+	}
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
 }
 
 type acceptContactRequest struct {
@@ -266,12 +321,20 @@ func (server *Server) acceptContact(ctx *gin.Context) {
 		return
 	}
 
+	// Requests can't be accepted after any of the users blocked the other
+	if !server.checkNotBlocked(ctx, contact.FromUserID, contact.ToUserID) {
+		return
+	}
+
 	acceptedContact, err := server.store.AcceptContact(ctx, req.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	// Notifying the user who made the request that it was accepted
+	server.hub.publish(eventContactAccepted, acceptedContact, acceptedContact.FromUserID)
+
 	ctx.JSON(http.StatusOK, acceptedContact)
 }
 
@@ -324,5 +387,118 @@ func (server *Server) rejectContact(ctx *gin.Context) {
 		return
 	}
 
+	// Notifying the user who made the request that it was rejected
+	server.hub.publish(eventContactRejected, rejectedContact, rejectedContact.FromUserID)
+
 	ctx.JSON(http.StatusOK, rejectedContact)
 }
+
+type contactRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// removeContact removes an accepted contact, for either side of it
+// The direct chat and its history are kept, but no messages can be sent on it until the users connect again
+func (server *Server) removeContact(ctx *gin.Context) {
+	var req contactRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	contact, err := server.store.GetContact(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Checking if the contact refers to the user trying to remove it
+	var otherUserID int64
+	switch user.ID {
+	case contact.FromUserID:
+		otherUserID = contact.ToUserID
+	case contact.ToUserID:
+		otherUserID = contact.FromUserID
+	default:
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot remove contact not in your list")))
+		return
+	}
+
+	// Pending requests are cancelled instead, while rejected ones are kept for the cooldown
+	if contact.Status != "Accepted" {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only accepted contacts can be removed")))
+		return
+	}
+
+	err = server.store.DeleteContact(ctx, contact.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Notifying the other user that the contact was removed
+	server.hub.publish(eventContactRemoved, contact, otherUserID)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// cancelContact withdraws a pending contact request, for the user who made it
+func (server *Server) cancelContact(ctx *gin.Context) {
+	var req contactRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	contact, err := server.store.GetContact(ctx, req.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Checking if the contact was requested by the user trying to cancel it
+	if user.ID != contact.FromUserID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only the user who made the contact request can cancel it")))
+		return
+	}
+
+	if contact.Status != "Pending" {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only pending contact requests can be cancelled")))
+		return
+	}
+
+	err = server.store.DeleteContact(ctx, contact.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Notifying the requested user that the request was withdrawn
+	server.hub.publish(eventContactCancelled, contact, contact.ToUserID)
+
+	ctx.Status(http.StatusNoContent)
+}
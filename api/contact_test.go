@@ -0,0 +1,471 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomContact creates a contact requested by a user to another one
+func randomContact(fromUser, toUser db.User, status string) db.Contact {
+	return db.Contact{
+		ID:          util.RandomInt(1, 1000),
+		FromUserID:  fromUser.ID,
+		ToUserID:    toUser.ID,
+		Status:      status,
+		RequestedAt: time.Now(),
+	}
+}
+
+func TestCreateContactAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	for otherUser.ID == user.ID {
+		otherUser, _ = randomUser(t)
+	}
+
+	// Contacts rejected right now and a while ago
+	rejectedContact := randomContact(user, otherUser, "Rejected")
+	rejectedContact.RejectedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	oldRejectedContact := rejectedContact
+	oldRejectedContact.RejectedAt.Time = time.Now().Add(-time.Hour)
+	// A contact rejected by the user itself
+	ownRejectedContact := randomContact(otherUser, user, "Rejected")
+	ownRejectedContact.RejectedAt = rejectedContact.RejectedAt
+
+	testCases := []struct {
+		name           string
+		existing       []db.Contact
+		expectsRenewal bool
+		expectedStatus int
+	}{
+		{
+			name:           "New",
+			existing:       []db.Contact{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "AlreadyAccepted",
+			existing:       []db.Contact{randomContact(otherUser, user, "Accepted")},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "RejectedWithinCooldown",
+			existing:       []db.Contact{rejectedContact},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "RejectedAfterCooldown",
+			existing:       []db.Contact{oldRejectedContact},
+			expectsRenewal: true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RejectedByTheUser",
+			existing:       []db.Contact{ownRejectedContact},
+			expectsRenewal: true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(otherUser.Username)).
+				Times(1).
+				Return(otherUser, nil)
+			store.EXPECT().
+				IsBlockedBetween(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(false, nil)
+			store.EXPECT().
+				CheckExistingContact(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(tc.existing, nil)
+
+			// The existing contact is requested again, from the user to the other one
+			renewals := 0
+			if tc.expectsRenewal {
+				renewals = 1
+			}
+			store.EXPECT().
+				RenewContactRequest(gomock.Any(), gomock.Any()).
+				Times(renewals).
+				DoAndReturn(func(_ context.Context, arg db.RenewContactRequestParams) (db.Contact, error) {
+					require.Equal(t, tc.existing[0].ID, arg.ID)
+					require.Equal(t, user.ID, arg.FromUserID)
+					require.Equal(t, otherUser.ID, arg.ToUserID)
+					return randomContact(user, otherUser, "Pending"), nil
+				})
+			creations := 0
+			if len(tc.existing) == 0 {
+				creations = 1
+			}
+			store.EXPECT().
+				CreateContact(gomock.Any(), gomock.Any()).
+				Times(creations).
+				Return(randomContact(user, otherUser, "Pending"), nil)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"username": otherUser.Username})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/contacts", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestRemoveContactAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	for otherUser.ID == user.ID {
+		otherUser, _ = randomUser(t)
+	}
+	strangerUser, _ := randomUser(t)
+	for strangerUser.ID == user.ID {
+		strangerUser, _ = randomUser(t)
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		contact        db.Contact
+		expectedStatus int
+	}{
+		{
+			name:           "RemoveAccepted",
+			method:         http.MethodDelete,
+			contact:        randomContact(otherUser, user, "Accepted"),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "RemovePending",
+			method:         http.MethodDelete,
+			contact:        randomContact(user, otherUser, "Pending"),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "RemoveSomeoneElses",
+			method:         http.MethodDelete,
+			contact:        randomContact(otherUser, strangerUser, "Accepted"),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "CancelPending",
+			method:         http.MethodPost,
+			path:           "/cancel",
+			contact:        randomContact(user, otherUser, "Pending"),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "CancelReceived",
+			method:         http.MethodPost,
+			path:           "/cancel",
+			contact:        randomContact(otherUser, user, "Pending"),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "CancelAccepted",
+			method:         http.MethodPost,
+			path:           "/cancel",
+			contact:        randomContact(user, otherUser, "Accepted"),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				GetContact(gomock.Any(), gomock.Eq(tc.contact.ID)).
+				Times(1).
+				Return(tc.contact, nil)
+
+			deletions := 0
+			if tc.expectedStatus == http.StatusNoContent {
+				deletions = 1
+			}
+			store.EXPECT().
+				DeleteContact(gomock.Any(), gomock.Eq(tc.contact.ID)).
+				Times(deletions).
+				Return(nil)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/contacts/%d%s", tc.contact.ID, tc.path)
+			request, err := http.NewRequest(tc.method, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestAcceptContactAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	for otherUser.ID == user.ID {
+		otherUser, _ = randomUser(t)
+	}
+	contact := randomContact(otherUser, user, "Pending")
+	blockedArg := db.IsBlockedBetweenParams{UserID: otherUser.ID, OtherUserID: user.ID}
+
+	testCases := []struct {
+		name          string
+		contact       db.Contact
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			contact: contact,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					IsBlockedBetween(gomock.Any(), gomock.Eq(blockedArg)).
+					Times(1).
+					Return(false, nil)
+
+				acceptedContact := contact
+				acceptedContact.Status = "Accepted"
+				store.EXPECT().
+					AcceptContact(gomock.Any(), gomock.Eq(contact.ID)).
+					Times(1).
+					Return(acceptedContact, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.Contact
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, contact.ID, rsp.ID)
+				require.Equal(t, "Accepted", rsp.Status)
+			},
+		},
+		{
+			name:    "Blocked",
+			contact: contact,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					IsBlockedBetween(gomock.Any(), gomock.Eq(blockedArg)).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					AcceptContact(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errUserBlocked)
+			},
+		},
+		{
+			name:    "NotRequestedUser",
+			contact: randomContact(user, otherUser, "Pending"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptContact(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:    "AlreadyAccepted",
+			contact: randomContact(otherUser, user, "Accepted"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptContact(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetContact(gomock.Any(), gomock.Eq(tc.contact.ID)).
+				Times(1).
+				Return(tc.contact, nil)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/contacts/%d/accept", tc.contact.ID)
+			request, err := http.NewRequest(http.MethodPut, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListContactAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+
+	contact := db.ListContactsRow{
+		ID:                  util.RandomInt(1, 1000),
+		Status:              "Pending",
+		RequestedAt:         time.Now(),
+		IsOutgoing:          false,
+		CounterpartID:       otherUser.ID,
+		CounterpartUsername: otherUser.Username,
+		CounterpartFullName: otherUser.FullName,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Filtered",
+			query: "?status=Pending&direction=incoming",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListContactsParams{
+					UserID:     user.ID,
+					Status:     sql.NullString{String: "Pending", Valid: true},
+					IsOutgoing: sql.NullBool{Bool: false, Valid: true},
+					Limit:      6,
+				}
+				store.EXPECT().
+					ListContacts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.ListContactsRow{contact}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items []contactListResponse `json:"items"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 1)
+				require.Equal(t, contactDirectionIncoming, rsp.Items[0].Direction)
+				require.Equal(t, otherUser.ID, rsp.Items[0].Counterpart.ID)
+				require.Equal(t, otherUser.Username, rsp.Items[0].Counterpart.Username)
+			},
+		},
+		{
+			name:  "Unfiltered",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListContactsParams{
+					UserID: user.ID,
+					Limit:  6,
+				}
+				store.EXPECT().
+					ListContacts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.ListContactsRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidDirection",
+			query: "?direction=sideways",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListContacts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/contacts"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Time allowed to write a message to the peer
+	writeWait = 10 * time.Second
+	// Time allowed to read the next pong message from the peer
+	pongWait = 60 * time.Second
+	// Send pings to peer with this period (must be less than pongWait)
+	pingPeriod = (pongWait * 9) / 10
+	// Maximum message size allowed from peer
+	maxIncomingMessageSize = 512
+	// Number of events buffered for each client before it's considered too slow
+	clientSendBufferSize = 32
+	// Reason sent on the close frame when the handshake token expires
+	closeReasonTokenExpired = "token has expired"
+)
+
+// Types of the events pushed to the connected clients
+const (
+	eventMessageCreated   = "message.created"
+	eventMessageUpdated   = "message.updated"
+	eventMessageDeleted   = "message.deleted"
+	eventContactRequested = "contact.requested"
+	eventContactAccepted  = "contact.accepted"
+	eventContactRejected  = "contact.rejected"
+	eventContactRemoved   = "contact.removed"
+	eventContactCancelled = "contact.cancelled"
+	eventChatRead         = "chat.read"
+)
+
+// hubEvent is the envelope for every event sent through the websocket
+type hubEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// wsClient is a single websocket connection (session) of a user
+type wsClient struct {
+	hub       *hub
+	conn      *websocket.Conn
+	userID    int64
+	tokenID   uuid.UUID
+	expiredAt time.Time
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// hub keeps track of the connected clients of each user and delivers events to them
+type hub struct {
+	mu      sync.RWMutex
+	clients map[int64]map[*wsClient]struct{}
+}
+
+// newHub creates a new empty hub
+func newHub() *hub {
+	return &hub{
+		clients: make(map[int64]map[*wsClient]struct{}),
+	}
+}
+
+// register adds a client to the sessions of its user
+func (h *hub) register(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sessions, ok := h.clients[client.userID]
+	if !ok {
+		sessions = make(map[*wsClient]struct{})
+		h.clients[client.userID] = sessions
+	}
+	sessions[client] = struct{}{}
+}
+
+// unregister removes a client from the sessions of its user
+func (h *hub) unregister(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sessions, ok := h.clients[client.userID]
+	if !ok {
+		return
+	}
+	delete(sessions, client)
+	if len(sessions) == 0 {
+		delete(h.clients, client.userID)
+	}
+}
+
+// sessionCount returns the number of connected clients for a user
+func (h *hub) sessionCount(userID int64) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.clients[userID])
+}
+
+// publish sends an event to every connected session of the provided users
+func (h *hub) publish(eventType string, data interface{}, userIDs ...int64) {
+	msg, err := json.Marshal(hubEvent{Type: eventType, Data: data})
+	if err != nil {
+		log.Println("cannot marshal hub event:", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for client := range h.clients[userID] {
+			select {
+			case client.send <- msg:
+			default:
+				// The client is not keeping up, so we drop its connection
+				go client.close()
+			}
+		}
+	}
+}
+
+// disconnect closes the sessions of a user, optionally only the ones opened with a specific token
+func (h *hub) disconnect(userID int64, tokenID uuid.UUID) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients[userID] {
+		if tokenID == uuid.Nil || client.tokenID == tokenID {
+			go client.close()
+		}
+	}
+}
+
+// close unregisters the client and closes its connection, only once
+func (client *wsClient) close() {
+	client.closeOnce.Do(func() {
+		client.hub.unregister(client)
+		close(client.done)
+		client.conn.Close()
+	})
+}
+
+// readPump reads from the connection, only to handle pongs and detect closed connections
+func (client *wsClient) readPump() {
+	defer client.close()
+
+	client.conn.SetReadLimit(maxIncomingMessageSize)
+	client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	client.conn.SetPongHandler(func(string) error {
+		return client.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		// Clients are not expected to send data, so incoming messages are discarded
+		if _, _, err := client.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writePump delivers events and heartbeats to the connection until it's closed or the token expires
+func (client *wsClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	expiry := time.NewTimer(time.Until(client.expiredAt))
+	defer func() {
+		ticker.Stop()
+		expiry.Stop()
+		client.close()
+	}()
+
+	for {
+		select {
+		case <-client.done:
+			return
+		case msg := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-expiry.C:
+			// The access token used on the handshake is no longer valid, so the session must end
+			closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, closeReasonTokenExpired)
+			client.conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(writeWait))
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/limiter"
+	"github.com/renatomh/api-simplechat/util"
+)
+
+// loginLimiter backs off failed logins both by account and by client IP
+// Accounts are protected against distributed guessing, while clients are stopped from trying many accounts
+type loginLimiter struct {
+	accounts limiter.Limiter
+	clients  limiter.Limiter
+}
+
+// newLoginLimiter creates the login limiter with the backend chosen on the config
+func newLoginLimiter(config util.Config, store db.Store) (*loginLimiter, error) {
+	accountPolicy := limiter.Policy{
+		MaxAttempts: config.LoginMaxAttempts,
+		BaseDelay:   config.LoginBackoffDelay,
+		MaxDelay:    config.LoginLockoutDuration,
+	}
+	clientPolicy := accountPolicy
+	clientPolicy.MaxAttempts = config.LoginMaxAttemptsPerIP
+
+	newLimiter := func(policy limiter.Policy) (limiter.Limiter, error) {
+		switch config.LoginLimiter {
+		case "memory":
+			return limiter.NewMemoryLimiter(policy)
+		case "postgres":
+			return limiter.NewPostgresLimiter(store, policy)
+		default:
+			return nil, fmt.Errorf("unknown login limiter %q", config.LoginLimiter)
+		}
+	}
+
+	accounts, err := newLimiter(accountPolicy)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := newLimiter(clientPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &loginLimiter{
+		accounts: accounts,
+		clients:  clients,
+	}, nil
+}
+
+// Both limiters may share the same backend, so their keys must not collide
+func accountLimiterKey(username string) string {
+	return "account:" + username
+}
+
+func clientLimiterKey(clientIP string) string {
+	return "client:" + clientIP
+}
+
+// retryAfter returns how long the client must wait before trying to log into the account again
+func (l *loginLimiter) retryAfter(ctx context.Context, username, clientIP string) (time.Duration, error) {
+	accountWait, err := l.accounts.RetryAfter(ctx, accountLimiterKey(username))
+	if err != nil {
+		return 0, err
+	}
+	clientWait, err := l.clients.RetryAfter(ctx, clientLimiterKey(clientIP))
+	if err != nil {
+		return 0, err
+	}
+	return maxDuration(accountWait, clientWait), nil
+}
+
+// fail records a failed login, returning how long the client must wait before the next one
+func (l *loginLimiter) fail(ctx context.Context, username, clientIP string) (time.Duration, error) {
+	accountWait, err := l.accounts.Fail(ctx, accountLimiterKey(username))
+	if err != nil {
+		return 0, err
+	}
+	clientWait, err := l.clients.Fail(ctx, clientLimiterKey(clientIP))
+	if err != nil {
+		return 0, err
+	}
+	return maxDuration(accountWait, clientWait), nil
+}
+
+// succeed forgets the failures of the account
+// The client's failures are kept, otherwise logging into an own account would allow guessing others
+func (l *loginLimiter) succeed(ctx context.Context, username string) error {
+	return l.accounts.Reset(ctx, accountLimiterKey(username))
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// setRetryAfter tells the client how many seconds to wait before trying again
+func setRetryAfter(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+}
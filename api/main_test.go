@@ -1,23 +1,63 @@
 package api
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
 	"github.com/renatomh/api-simplechat/util"
 	"github.com/stretchr/testify/require"
 )
 
+// testTokenKeySeed derives the key signing the tokens of the test servers
+var testTokenKeySeed = bytes.Repeat([]byte{42}, ed25519.SeedSize)
+
+// testTokenMaker creates a maker with the same key as the one of the test servers
+func testTokenMaker(t *testing.T) token.Maker {
+	tokenMaker, err := token.NewPasetoPublicMaker(ed25519.NewKeyFromSeed(testTokenKeySeed))
+	require.NoError(t, err)
+	return tokenMaker
+}
+
 func newTestServer(t *testing.T, store db.Store) *Server {
+	return newTestServerWithMaker(t, store, testTokenMaker(t))
+}
+
+func newTestServerWithMaker(t *testing.T, store db.Store, tokenMaker token.Maker) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		AccessTokenDuration:       time.Minute,
+		RefreshTokenDuration:      time.Hour,
+		MessageEditWindow:         time.Minute,
+		MessageDeleteWindow:       time.Minute,
+		ContactRequestCooldown:    time.Minute,
+		DefaultPageSize:           5,
+		MaxPageSize:               10,
+		LoginLimiter:              "memory",
+		LoginMaxAttempts:          3,
+		LoginMaxAttemptsPerIP:     5,
+		LoginBackoffDelay:         time.Minute,
+		LoginLockoutDuration:      time.Hour,
+		TOTPIssuer:                "Simple Chat",
+		MFATokenDuration:          time.Minute,
+		AppBaseURL:                "http://localhost:8080",
+		MailerOutputPath:          t.TempDir(),
+		MailSender:                "Simple Chat <no-reply@example.com>",
+		EmailVerificationDuration: time.Hour,
+		PasswordResetDuration:     time.Minute,
+		BlobStoragePath:           t.TempDir(),
+		AttachmentMaxSize:         1024,
+		AttachmentAllowedTypes: []string{
+			"image/png",
+			"text/plain",
+		},
 	}
 
-	server, err := NewServer(config, store)
+	server, err := NewServer(config, store, tokenMaker)
 	require.NoError(t, err)
 
 	return server
@@ -2,8 +2,11 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
@@ -11,9 +14,43 @@ import (
 	"github.com/renatomh/api-simplechat/token"
 )
 
+var errEmptyMessage = errors.New("message must have a body or attachments")
+
 type createMessageRequest struct {
-	ChatID int64  `json:"chat_id" binding:"required"`
-	Body   string `json:"body" binding:"required"`
+	ChatID int64 `json:"chat_id" binding:"required"`
+	// Messages may have no body, as long as they have attachments
+	Body string `json:"body" binding:"required_without=AttachmentIDs"`
+	// Up to 10 attachments previously uploaded by the user
+	AttachmentIDs []int64 `json:"attachment_ids" binding:"max=10,unique,dive,min=1"`
+}
+
+type messageResponse struct {
+	ID         int64 `json:"id"`
+	ChatID     int64 `json:"chat_id"`
+	FromUserID int64 `json:"from_user_id"`
+	// Empty for messages sent to group chats
+	ToUserID int64        `json:"to_user_id,omitempty"`
+	Body     string       `json:"body"`
+	SentAt   time.Time    `json:"sent_at"`
+	EditedAt sql.NullTime `json:"edited_at"`
+	// Messages deleted for everyone are kept as tombstones, with an empty body
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	// Other members which already read the message, only filled when listing messages
+	ReadBy      []int64              `json:"read_by,omitempty"`
+	Attachments []attachmentResponse `json:"attachments,omitempty"`
+}
+
+func newMessageResponse(message db.Message) messageResponse {
+	return messageResponse{
+		ID:         message.ID,
+		ChatID:     message.ChatID,
+		FromUserID: message.FromUserID,
+		ToUserID:   message.ToUserID.Int64,
+		Body:       message.Body,
+		SentAt:     message.SentAt,
+		EditedAt:   message.EditedAt,
+		DeletedAt:  message.DeletedAt,
+	}
 }
 
 func (server *Server) createMessage(ctx *gin.Context) {
@@ -22,6 +59,11 @@ func (server *Server) createMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	// The binding lets an empty list of attachments through, as well as blank bodies
+	if strings.TrimSpace(req.Body) == "" && len(req.AttachmentIDs) == 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errEmptyMessage))
+		return
+	}
 
 	// Getting the user which made the request
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
@@ -29,59 +71,81 @@ func (server *Server) createMessage(ctx *gin.Context) {
 	// Getting user which requested the connection
 	user, _ := server.store.GetUserByUsername(ctx, authPayload.Username)
 
-	// Checking if chat exists
-	chat, err := server.store.GetChat(ctx, req.ChatID)
-	if err != nil {
-		// If no item was found
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
-	}
-
 	// Checking if user is trying to send a message in a chat where it does not take part
-	if (user.ID != chat.FromUserID) && (user.ID != chat.ToUserID) {
-		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot send a message to a chat that is not yours")))
+	chat, _, ok := server.getChatMembership(ctx, req.ChatID, user.ID)
+	if !ok {
 		return
 	}
 
-	// Defining user who will receive the message
-	var toUserId int64
-	if user.ID == chat.FromUserID {
-		toUserId = chat.ToUserID
-	} else {
-		toUserId = chat.FromUserID
+	// Defining user who will receive the message, only for direct chats
+	var toUserID sql.NullInt64
+	if !chat.IsGroup {
+		if user.ID == chat.FromUserID.Int64 {
+			toUserID = chat.ToUserID
+		} else {
+			toUserID = chat.FromUserID
+		}
+
+		// Messages can't be sent to direct chats where any of the users blocked the other
+		if !server.checkNotBlocked(ctx, user.ID, toUserID.Int64) {
+			return
+		}
+
+		// Neither after the contact was removed, even though its history is kept
+		accepted, err := server.isAcceptedContact(ctx, user.ID, toUserID.Int64)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if !accepted {
+			ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot send messages to a user not in your contacts")))
+			return
+		}
 	}
-	arg := db.CreateMessageParams{
-		ChatID:     chat.ID,
-		FromUserID: user.ID,
-		ToUserID:   toUserId,
-		Body:       req.Body,
+	arg := db.SendMessageTxParams{
+		ChatID:        chat.ID,
+		FromUserID:    user.ID,
+		ToUserID:      toUserID,
+		Body:          req.Body,
+		AttachmentIDs: req.AttachmentIDs,
 	}
 
-	message, err := server.store.CreateMessage(ctx, arg)
+	// The chat's last activity and the unread counters are updated along with the message
+	result, err := server.store.SendMessageTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrInvalidAttachments) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
 			case "unique_violation":
 				ctx.JSON(http.StatusForbidden, errorResponse(err))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
 		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newMessageResponse(result.Message)
+	for _, attachment := range result.Attachments {
+		rsp.Attachments = append(rsp.Attachments, newAttachmentResponse(attachment))
 	}
 
-	ctx.JSON(http.StatusOK, message)
+	// Pushing the new message to the connected sessions of the other members
+	recipientIDs := []int64{}
+	for _, member := range result.Recipients {
+		recipientIDs = append(recipientIDs, member.UserID)
+	}
+	server.hub.publish(eventMessageCreated, rsp, recipientIDs...)
+
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type listMessageRequest struct {
-	ChatID   int64 `form:"chat_id" binding:"required,min=1"`
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	ChatID int64 `form:"chat_id" binding:"required,min=1"`
+	pageRequest
 }
 
 func (server *Server) listMessage(ctx *gin.Context) {
@@ -96,36 +160,318 @@ func (server *Server) listMessage(ctx *gin.Context) {
 
 	// Querying the user item by the username
 	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
 
-	// Checking if chat exists
-	chat, err := server.store.GetChat(ctx, req.ChatID)
+	// Checking if user is trying to read messages from a chat where it does not take part
+	chat, _, ok := server.getChatMembership(ctx, req.ChatID, user.ID)
+	if !ok {
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// Messages are sorted by the time they were sent, so their cursors must have one
+	if p.Cursor != nil && p.Cursor.Time == nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+		return
+	}
+
+	// Messages are listed from the newest ones, leaving out the ones deleted only for the user
+	var messages []db.Message
+	if p.Backward {
+		messages, err = server.store.ListMessagesBefore(ctx, db.ListMessagesBeforeParams{
+			ChatID:       chat.ID,
+			UserID:       user.ID,
+			CursorSentAt: *p.Cursor.Time,
+			CursorID:     p.Cursor.ID,
+			Limit:        p.limit(),
+		})
+	} else {
+		messages, err = server.store.ListMessages(ctx, db.ListMessagesParams{
+			ChatID:       chat.ID,
+			UserID:       user.ID,
+			CursorID:     p.Cursor.nullID(),
+			CursorSentAt: p.Cursor.nullTime(),
+			Limit:        p.limit(),
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	messages, next, prev := paginate(messages, p, func(message db.Message) pageCursor {
+		return pageCursor{Time: &message.SentAt, ID: message.ID}
+	})
+
+	// The read cursors of the members tell which messages each one has already seen
+	members, err := server.store.ListChatMembers(ctx, chat.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Getting the attachments of every listed message at once
+	messageIDs := []int64{}
+	for _, message := range messages {
+		messageIDs = append(messageIDs, message.ID)
+	}
+	attachments, err := server.store.ListMessagesAttachments(ctx, messageIDs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	messageAttachments := map[int64][]attachmentResponse{}
+	for _, attachment := range attachments {
+		messageID := attachment.MessageID.Int64
+		messageAttachments[messageID] = append(messageAttachments[messageID], newAttachmentResponse(attachment))
+	}
+
+	items := []messageResponse{}
+	for _, message := range messages {
+		messageRsp := newMessageResponse(message)
+		for _, member := range members {
+			if member.UserID != message.FromUserID && member.LastReadMessageID.Int64 >= message.ID {
+				messageRsp.ReadBy = append(messageRsp.ReadBy, member.UserID)
+			}
+		}
+		// Attachments of deleted messages are no longer available
+		if !message.DeletedAt.Valid {
+			messageRsp.Attachments = messageAttachments[message.ID]
+		}
+		items = append(items, messageRsp)
+	}
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+type messageRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getChatMessage gets a message from a chat the user takes part on, writing the error response when it fails
+func (server *Server) getChatMessage(ctx *gin.Context, messageID, userID int64) (db.Message, bool) {
+	message, err := server.store.GetMessage(ctx, messageID)
 	if err != nil {
 		// If no item was found
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
+			return message, false
 		}
 
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return message, false
+	}
+
+	// Only members of the chat can access its messages
+	_, _, ok := server.getChatMembership(ctx, message.ChatID, userID)
+	return message, ok
+}
+
+type updateMessageRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+func (server *Server) updateMessage(ctx *gin.Context) {
+	var uri messageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// Checking if user is trying to read messages from a chat where it does not take part
-	if (user.ID != chat.FromUserID) && (user.ID != chat.ToUserID) {
-		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("cannot read messages from a chat that is not yours")))
+	var req updateMessageRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	message, ok := server.getChatMessage(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Checking if user is trying to edit someone else's message
+	if message.FromUserID != user.ID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only the sender can edit the message")))
+		return
+	}
+
+	// Tombstones can't be brought back by editing them
+	if message.DeletedAt.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("message was deleted")))
+		return
+	}
+
+	// Messages can only be edited for a while after being sent
+	if time.Since(message.SentAt) > server.config.MessageEditWindow {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("message can no longer be edited")))
+		return
+	}
+
+	// The previous body is kept as a revision of the message
+	result, err := server.store.EditMessageTx(ctx, db.UpdateMessageParams{
+		ID:   message.ID,
+		Body: req.Body,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newMessageResponse(result.Message)
+
+	// Pushing the edited message to the connected sessions of the other members
+	members, err := server.store.ListChatMembers(ctx, message.ChatID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	recipientIDs := []int64{}
+	for _, member := range members {
+		if member.UserID != user.ID {
+			recipientIDs = append(recipientIDs, member.UserID)
+		}
+	}
+	server.hub.publish(eventMessageUpdated, rsp, recipientIDs...)
 
-	arg := db.ListMessagesParams{
-		ChatID: chat.ID,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+func (server *Server) listMessageRevision(ctx *gin.Context) {
+	var uri messageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
-	messages, err := server.store.ListMessages(ctx, arg)
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, messages)
+	message, ok := server.getChatMessage(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	revisions, err := server.store.ListMessageRevisions(ctx, message.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, revisions)
+}
+
+// Scopes of a message deletion
+const (
+	deleteScopeMe       = "me"
+	deleteScopeEveryone = "everyone"
+)
+
+type deleteMessageRequest struct {
+	Scope string `form:"scope" binding:"required,oneof=me everyone"`
+}
+
+func (server *Server) deleteMessage(ctx *gin.Context) {
+	var uri messageRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req deleteMessageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	message, ok := server.getChatMessage(ctx, uri.ID, user.ID)
+	if !ok {
+		return
+	}
+
+	// Deleting for the user only hides the message from its listings
+	if req.Scope == deleteScopeMe {
+		err = server.store.HideMessage(ctx, db.HideMessageParams{
+			MessageID: message.ID,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	// Checking if user is trying to delete someone else's message for everyone
+	if message.FromUserID != user.ID {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("only the sender can delete the message for everyone")))
+		return
+	}
+
+	if message.DeletedAt.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("message was already deleted")))
+		return
+	}
+
+	// Messages can only be deleted for everyone for a while after being sent
+	if time.Since(message.SentAt) > server.config.MessageDeleteWindow {
+		ctx.JSON(http.StatusForbidden, errorResponse(fmt.Errorf("message can no longer be deleted for everyone")))
+		return
+	}
+
+	tombstone, err := server.store.DeleteMessageForEveryoneTx(ctx, message.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := newMessageResponse(tombstone)
+
+	// Pushing the tombstone to the connected sessions of the other members
+	members, err := server.store.ListChatMembers(ctx, message.ChatID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	recipientIDs := []int64{}
+	for _, member := range members {
+		if member.UserID != user.ID {
+			recipientIDs = append(recipientIDs, member.UserID)
+		}
+	}
+	server.hub.publish(eventMessageDeleted, rsp, recipientIDs...)
+
+	ctx.JSON(http.StatusOK, rsp)
 }
@@ -0,0 +1,675 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomMessage creates a message sent by the user on a direct chat
+func randomMessage(fromUser db.User, toUser db.User) (db.Chat, db.Message) {
+	chat := db.Chat{
+		ID:         util.RandomInt(1, 1000),
+		FromUserID: sql.NullInt64{Int64: fromUser.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: toUser.ID, Valid: true},
+	}
+	message := db.Message{
+		ID:         util.RandomInt(1, 1000),
+		ChatID:     chat.ID,
+		FromUserID: fromUser.ID,
+		ToUserID:   sql.NullInt64{Int64: toUser.ID, Valid: true},
+		Body:       util.RandomString(12),
+		SentAt:     time.Now(),
+	}
+	return chat, message
+}
+
+func TestCreateMessageAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	chat, message := randomMessage(user, otherUser)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"chat_id": chat.ID, "body": message.Body},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return(chat, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: db.ChatRoleMember}, nil)
+				store.EXPECT().
+					IsBlockedBetween(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(false, nil)
+				store.EXPECT().
+					CheckExistingContact(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Contact{{FromUserID: user.ID, ToUserID: otherUser.ID, Status: "Accepted"}}, nil)
+
+				arg := db.SendMessageTxParams{
+					ChatID:     chat.ID,
+					FromUserID: user.ID,
+					ToUserID:   chat.ToUserID,
+					Body:       message.Body,
+				}
+				store.EXPECT().
+					SendMessageTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.SendMessageTxResult{Message: message, Chat: chat}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp messageResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, message.ID, rsp.ID)
+				require.Equal(t, message.Body, rsp.Body)
+			},
+		},
+		{
+			name: "NoBodyNorAttachments",
+			body: gin.H{"chat_id": chat.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SendMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EmptyAttachments",
+			body: gin.H{"chat_id": chat.ID, "body": "", "attachment_ids": []int64{}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SendMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errEmptyMessage)
+			},
+		},
+		{
+			name: "BlankBody",
+			body: gin.H{"chat_id": chat.ID, "body": "   "},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SendMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errEmptyMessage)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/messages", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListMessageAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	chat, message := randomMessage(user, otherUser)
+
+	sentAt := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := pageCursor{Time: &sentAt, ID: 42}
+
+	// Stubs for the data added to the listed messages
+	stubMessageDetails := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			ListChatMembers(gomock.Any(), gomock.Eq(chat.ID)).
+			Times(1).
+			Return([]db.ChatMember{{UserID: user.ID}, {UserID: otherUser.ID}}, nil)
+		store.EXPECT().
+			ListMessagesAttachments(gomock.Any(), gomock.Eq([]int64{message.ID})).
+			Times(1).
+			Return([]db.Attachment{}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "After",
+			query: url.Values{"after": {cursor.encode()}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListMessagesParams{
+					ChatID:       chat.ID,
+					UserID:       user.ID,
+					CursorID:     sql.NullInt64{Int64: cursor.ID, Valid: true},
+					CursorSentAt: sql.NullTime{Time: sentAt, Valid: true},
+					Limit:        6,
+				}
+				store.EXPECT().
+					ListMessages(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Message{message}, nil)
+				stubMessageDetails(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []messageResponse `json:"items"`
+					PrevCursor string            `json:"prev_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 1)
+				require.Equal(t, message.ID, rsp.Items[0].ID)
+				require.Equal(t, pageCursor{Time: &message.SentAt, ID: message.ID}.encode(), rsp.PrevCursor)
+			},
+		},
+		{
+			name:  "Before",
+			query: url.Values{"before": {cursor.encode()}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListMessagesBeforeParams{
+					ChatID:       chat.ID,
+					UserID:       user.ID,
+					CursorSentAt: sentAt,
+					CursorID:     cursor.ID,
+					Limit:        6,
+				}
+				store.EXPECT().
+					ListMessagesBefore(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Message{message}, nil)
+				stubMessageDetails(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "AfterCursorWithoutTime",
+			query: url.Values{"after": {pageCursor{ID: 42}.encode()}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListMessages(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCursor)
+			},
+		},
+		{
+			name:  "BeforeCursorWithoutTime",
+			query: url.Values{"before": {pageCursor{ID: 42}.encode()}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListMessagesBefore(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCursor)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+				Times(1).
+				Return(chat, nil)
+			store.EXPECT().
+				GetChatMember(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: db.ChatRoleMember}, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			tc.query.Set("chat_id", fmt.Sprint(chat.ID))
+			request, err := http.NewRequest(http.MethodGet, "/messages?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateMessageAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	chat, message := randomMessage(user, otherUser)
+	body := util.RandomString(12)
+
+	// A message sent before the edit window
+	_, oldMessage := randomMessage(user, otherUser)
+	oldMessage.ChatID = chat.ID
+	oldMessage.SentAt = time.Now().Add(-time.Hour)
+
+	// A message sent by the other user
+	_, otherMessage := randomMessage(otherUser, user)
+	otherMessage.ChatID = chat.ID
+
+	// Stubs for a chat where both users are members
+	stubChat := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+			Times(1).
+			Return(chat, nil)
+		store.EXPECT().
+			GetChatMember(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: db.ChatRoleMember}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		messageID     int64
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			messageID: message.ID,
+			body:      gin.H{"body": body},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(message, nil)
+				stubChat(store)
+
+				editedMessage := message
+				editedMessage.Body = body
+				editedMessage.EditedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					EditMessageTx(gomock.Any(), gomock.Eq(db.UpdateMessageParams{ID: message.ID, Body: body})).
+					Times(1).
+					Return(db.EditMessageTxResult{
+						Message:  editedMessage,
+						Revision: db.MessageRevision{MessageID: message.ID, Body: message.Body},
+					}, nil)
+				store.EXPECT().
+					ListChatMembers(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return([]db.ChatMember{{UserID: user.ID}, {UserID: otherUser.ID}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp messageResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, message.ID, rsp.ID)
+				require.Equal(t, body, rsp.Body)
+				require.True(t, rsp.EditedAt.Valid)
+			},
+		},
+		{
+			name:      "NotSender",
+			messageID: otherMessage.ID,
+			body:      gin.H{"body": body},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(otherMessage.ID)).
+					Times(1).
+					Return(otherMessage, nil)
+				stubChat(store)
+				store.EXPECT().
+					EditMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "EditWindowExpired",
+			messageID: oldMessage.ID,
+			body:      gin.H{"body": body},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(oldMessage.ID)).
+					Times(1).
+					Return(oldMessage, nil)
+				stubChat(store)
+				store.EXPECT().
+					EditMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NotChatMember",
+			messageID: message.ID,
+			body:      gin.H{"body": body},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(message, nil)
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return(chat, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChatMember{}, sql.ErrNoRows)
+				store.EXPECT().
+					EditMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "MessageNotFound",
+			messageID: message.ID,
+			body:      gin.H{"body": body},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(db.Message{}, sql.ErrNoRows)
+				store.EXPECT().
+					EditMessageTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "EmptyBody",
+			messageID: message.ID,
+			body:      gin.H{"body": ""},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/messages/%d", tc.messageID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteMessageAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	chat, message := randomMessage(user, otherUser)
+
+	// A message sent before the deletion window
+	_, oldMessage := randomMessage(user, otherUser)
+	oldMessage.ChatID = chat.ID
+	oldMessage.SentAt = time.Now().Add(-time.Hour)
+
+	// A message sent by the other user
+	_, otherMessage := randomMessage(otherUser, user)
+	otherMessage.ChatID = chat.ID
+
+	// Stubs for a message on a chat where both users are members
+	stubMessage := func(store *mockdb.MockStore, message db.Message) {
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+			Times(1).
+			Return(user, nil)
+		store.EXPECT().
+			GetMessage(gomock.Any(), gomock.Eq(message.ID)).
+			Times(1).
+			Return(message, nil)
+		store.EXPECT().
+			GetChat(gomock.Any(), gomock.Eq(chat.ID)).
+			Times(1).
+			Return(chat, nil)
+		store.EXPECT().
+			GetChatMember(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.ChatMember{ChatID: chat.ID, UserID: user.ID, Role: db.ChatRoleMember}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		messageID     int64
+		scope         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "DeleteForMe",
+			messageID: otherMessage.ID,
+			scope:     deleteScopeMe,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubMessage(store, otherMessage)
+				store.EXPECT().
+					HideMessage(gomock.Any(), gomock.Eq(db.HideMessageParams{MessageID: otherMessage.ID, UserID: user.ID})).
+					Times(1)
+				store.EXPECT().
+					DeleteMessageForEveryoneTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:      "DeleteForEveryone",
+			messageID: message.ID,
+			scope:     deleteScopeEveryone,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubMessage(store, message)
+
+				tombstone := message
+				tombstone.Body = ""
+				tombstone.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					DeleteMessageForEveryoneTx(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(tombstone, nil)
+				store.EXPECT().
+					ListChatMembers(gomock.Any(), gomock.Eq(chat.ID)).
+					Times(1).
+					Return([]db.ChatMember{{UserID: user.ID}, {UserID: otherUser.ID}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp messageResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, message.ID, rsp.ID)
+				require.Empty(t, rsp.Body)
+				require.True(t, rsp.DeletedAt.Valid)
+			},
+		},
+		{
+			name:      "DeleteForEveryoneNotSender",
+			messageID: otherMessage.ID,
+			scope:     deleteScopeEveryone,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubMessage(store, otherMessage)
+				store.EXPECT().
+					DeleteMessageForEveryoneTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "DeleteForEveryoneWindowExpired",
+			messageID: oldMessage.ID,
+			scope:     deleteScopeEveryone,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubMessage(store, oldMessage)
+				store.EXPECT().
+					DeleteMessageForEveryoneTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidScope",
+			messageID: message.ID,
+			scope:     "nobody",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMessage(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/messages/%d?scope=%s", tc.messageID, tc.scope)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
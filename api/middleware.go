@@ -16,8 +16,10 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
+var errMissingScope = errors.New("token is not allowed to access this resource")
+
 // AuthMiddleware creates a gin middleware for authorization
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, revocations *revocationList) gin.HandlerFunc {
 	abort := func(ctx *gin.Context, err error) {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		ctx.Abort()
@@ -50,7 +52,40 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		// Refresh tokens must only be used to renew access tokens
+		if payload.Type != token.TokenTypeAccessToken {
+			abort(ctx, errors.New("token is not an access token"))
+			return
+		}
+
+		// Checking if the token was revoked by logging out or changing the password
+		revoked, err := revocations.isRevoked(ctx, payload)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			ctx.Abort()
+			return
+		}
+		if revoked {
+			abort(ctx, errors.New("token has been revoked"))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// scopeMiddleware creates a gin middleware allowing only the tokens granted every one of the scopes
+// It must run after the auth middleware, which sets the token payload
+func scopeMiddleware(scopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !payload.HasScopes(scopes...) {
+			ctx.JSON(http.StatusForbidden, errorResponse(errMissingScope))
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
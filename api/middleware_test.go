@@ -1,6 +1,9 @@
 package api
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,7 +11,11 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
 	"github.com/renatomh/api-simplechat/token"
+	"github.com/renatomh/api-simplechat/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -20,17 +27,41 @@ func addAuthorization(
 	username string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(username, duration)
+	addRoleAuthorization(t, request, tokenMaker, authorizationType, username, util.UserRole, duration)
+}
+
+// addRoleAuthorization authorizes the request with a token granted the scopes of the role
+func addRoleAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	role string,
+	duration time.Duration,
+) {
+	accessToken, payload, err := tokenMaker.CreateToken(username, role, token.TokenTypeAccessToken, duration)
 	require.NoError(t, err)
+	require.NotEmpty(t, payload)
 
-	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
+	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, accessToken)
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
 }
 
+// stubTokenRevocation makes the auth middleware accept every token as not revoked
+func stubTokenRevocation(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetTokenRevocation(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.GetTokenRevocationRow{}, nil)
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	testCases := []struct {
-		name          string
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		name      string
+		setupAuth func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		// Optional, by default every token is accepted as not revoked
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
@@ -68,6 +99,77 @@ func TestAuthMiddleware(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken("user", util.UserRole, token.TokenTypeRefreshToken, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevokedToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTokenRevocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetTokenRevocationRow{IsRevoked: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "IssuedBeforePasswordChange",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTokenRevocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetTokenRevocationRow{ValidAfter: time.Now().Add(time.Second)}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "DeletedUser",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTokenRevocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetTokenRevocationRow{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RevocationInternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTokenRevocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetTokenRevocationRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 		{
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
@@ -83,11 +185,21 @@ func TestAuthMiddleware(t *testing.T) {
 		tc := testCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			} else {
+				stubTokenRevocation(store)
+			}
+
+			server := newTestServer(t, store)
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.revocations),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -103,3 +215,121 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddlewareVerifierOnly(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// Another service issues the tokens, while the server only holds the public key
+	issuer, err := token.NewJWTEdDSAMaker(privateKey)
+	require.NoError(t, err)
+	verifier, err := token.NewJWTEdDSAVerifier(publicKey)
+	require.NoError(t, err)
+	otherMaker, err := token.NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		tokenMaker   token.Maker
+		expectedCode int
+	}{
+		{
+			name:         "IssuedToken",
+			tokenMaker:   issuer,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "OtherMakerToken",
+			tokenMaker:   otherMaker,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			stubTokenRevocation(store)
+
+			server := newTestServerWithMaker(t, store, verifier)
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, tc.tokenMaker, authorizationTypeBearer, "user", time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
+
+func TestScopeMiddleware(t *testing.T) {
+	testCases := []struct {
+		name         string
+		role         string
+		scopes       []string
+		expectedCode int
+	}{
+		{
+			name:         "Granted",
+			role:         util.AdminRole,
+			scopes:       []string{token.ScopeAdminUsers, token.ScopeAdminStats},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "NotGranted",
+			role:         util.UserRole,
+			scopes:       []string{token.ScopeAdminUsers},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "NoScopes",
+			role:         util.UserRole,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations),
+				scopeMiddleware(tc.scopes...),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addRoleAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid page cursor")
+
+// Page sizes used when the ones configured for the server are missing or invalid
+const (
+	fallbackDefaultPageSize int32 = 20
+	fallbackMaxPageSize     int32 = 100
+)
+
+// pageRequest holds the pagination parameters shared by every list endpoint
+// A page is requested right after or right before a cursor returned on a previous page, or from the start if none is given
+type pageRequest struct {
+	Before   string `form:"before" binding:"excluded_with=After"`
+	After    string `form:"after"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1"`
+}
+
+// listResponse wraps a page of items, along with the cursors to their neighbouring pages
+type listResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+}
+
+// pageCursor points to an item of a listing by its sort key, with the ID breaking ties
+type pageCursor struct {
+	Time *time.Time `json:"t,omitempty"`
+	// Search results are sorted by their rank instead
+	Rank *float32 `json:"r,omitempty"`
+	ID   int64    `json:"id"`
+}
+
+// encode makes the cursor opaque to clients
+func (cursor pageCursor) encode() string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(encoded string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	cursor := &pageCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID < 1 {
+		return nil, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// nullID returns the cursor ID for the queries, which is empty when listing from the start
+func (cursor *pageCursor) nullID() sql.NullInt64 {
+	if cursor == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: cursor.ID, Valid: true}
+}
+
+// nullTime returns the cursor time for the queries, which is empty for items sorted without one
+func (cursor *pageCursor) nullTime() sql.NullTime {
+	if cursor == nil || cursor.Time == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *cursor.Time, Valid: true}
+}
+
+// nullRank returns the cursor rank for the search queries, which is empty when listing from the start
+func (cursor *pageCursor) nullRank() sql.NullFloat64 {
+	if cursor == nil || cursor.Rank == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(*cursor.Rank), Valid: true}
+}
+
+// page holds the validated pagination parameters of a request
+type page struct {
+	Size     int32
+	Cursor   *pageCursor
+	Backward bool
+}
+
+// limit is the amount of rows to be queried, with an extra one telling if there are more pages
+func (p page) limit() int32 {
+	return p.Size + 1
+}
+
+// newPage validates the pagination parameters, limiting the page size to the one allowed by the server
+func (server *Server) newPage(req pageRequest) (page, error) {
+	maxSize := server.config.MaxPageSize
+	if maxSize <= 0 {
+		maxSize = fallbackMaxPageSize
+	}
+	defaultSize := server.config.DefaultPageSize
+	if defaultSize <= 0 {
+		defaultSize = fallbackDefaultPageSize
+	}
+	if defaultSize > maxSize {
+		defaultSize = maxSize
+	}
+
+	p := page{Size: req.PageSize}
+	if p.Size == 0 {
+		p.Size = defaultSize
+	}
+	if p.Size > maxSize {
+		return p, fmt.Errorf("page size must be at most %d", maxSize)
+	}
+
+	encoded := req.After
+	if req.Before != "" {
+		encoded = req.Before
+		p.Backward = true
+	}
+	if encoded != "" {
+		cursor, err := decodePageCursor(encoded)
+		if err != nil {
+			return p, err
+		}
+		p.Cursor = cursor
+	}
+
+	return p, nil
+}
+
+// paginate trims the extra row queried for the page, returning the rows in listing order along with the cursors to the next and previous pages
+// Backward pages are queried in reverse order, so the rows closest to the cursor come first
+func paginate[T any](rows []T, p page, cursorOf func(T) pageCursor) ([]T, string, string) {
+	hasMore := len(rows) > int(p.Size)
+	if hasMore {
+		rows = rows[:p.Size]
+	}
+	if p.Backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	// There's always a page on the side the cursor came from
+	hasNext, hasPrev := hasMore, p.Cursor != nil
+	if p.Backward {
+		hasNext, hasPrev = p.Cursor != nil, hasMore
+	}
+
+	var next, prev string
+	if hasNext {
+		next = cursorOf(rows[len(rows)-1]).encode()
+	}
+	if hasPrev {
+		prev = cursorOf(rows[0]).encode()
+	}
+	return rows, next, prev
+}
@@ -0,0 +1,296 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageCursor(t *testing.T) {
+	sentAt := time.Now().UTC()
+	cursor := pageCursor{Time: &sentAt, ID: 42}
+
+	decoded, err := decodePageCursor(cursor.encode())
+	require.NoError(t, err)
+	require.Equal(t, cursor.ID, decoded.ID)
+	require.True(t, sentAt.Equal(*decoded.Time))
+
+	for _, encoded := range []string{"not base64!", "bm90IGpzb24", pageCursor{}.encode()} {
+		_, err := decodePageCursor(encoded)
+		require.ErrorIs(t, err, errInvalidCursor)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	testCases := []struct {
+		name            string
+		defaultPageSize int32
+		maxPageSize     int32
+		pageSize        int32
+		expectedSize    int32
+		expectedErr     bool
+	}{
+		{
+			name:            "Configured",
+			defaultPageSize: 5,
+			maxPageSize:     10,
+			expectedSize:    5,
+		},
+		{
+			name:            "ConfiguredTooLarge",
+			defaultPageSize: 5,
+			maxPageSize:     10,
+			pageSize:        11,
+			expectedErr:     true,
+		},
+		{
+			name:         "NotConfigured",
+			expectedSize: fallbackDefaultPageSize,
+		},
+		{
+			name:         "NotConfiguredRequested",
+			pageSize:     fallbackMaxPageSize,
+			expectedSize: fallbackMaxPageSize,
+		},
+		{
+			name:        "NotConfiguredTooLarge",
+			pageSize:    fallbackMaxPageSize + 1,
+			expectedErr: true,
+		},
+		{
+			name:            "DefaultAboveMax",
+			defaultPageSize: 50,
+			maxPageSize:     10,
+			expectedSize:    10,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := &Server{config: util.Config{
+				DefaultPageSize: tc.defaultPageSize,
+				MaxPageSize:     tc.maxPageSize,
+			}}
+
+			p, err := server.newPage(pageRequest{PageSize: tc.pageSize})
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedSize, p.Size)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	cursorOf := func(id int64) pageCursor { return pageCursor{ID: id} }
+	cursor := &pageCursor{ID: 10}
+
+	testCases := []struct {
+		name     string
+		rows     []int64
+		page     page
+		expected []int64
+		next     bool
+		prev     bool
+	}{
+		{
+			name:     "FirstPage",
+			rows:     []int64{1, 2, 3},
+			page:     page{Size: 2},
+			expected: []int64{1, 2},
+			next:     true,
+		},
+		{
+			name:     "LastPage",
+			rows:     []int64{11, 12},
+			page:     page{Size: 2, Cursor: cursor},
+			expected: []int64{11, 12},
+			prev:     true,
+		},
+		{
+			name:     "Backward",
+			rows:     []int64{9, 8, 7},
+			page:     page{Size: 2, Cursor: cursor, Backward: true},
+			expected: []int64{8, 9},
+			next:     true,
+			prev:     true,
+		},
+		{
+			name:     "BackwardToStart",
+			rows:     []int64{9},
+			page:     page{Size: 2, Cursor: cursor, Backward: true},
+			expected: []int64{9},
+			next:     true,
+		},
+		{
+			name:     "Empty",
+			rows:     []int64{},
+			page:     page{Size: 2, Cursor: cursor},
+			expected: []int64{},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rows, next, prev := paginate(tc.rows, tc.page, cursorOf)
+			require.Equal(t, tc.expected, rows)
+
+			if tc.next {
+				require.Equal(t, cursorOf(rows[len(rows)-1]).encode(), next)
+			} else {
+				require.Empty(t, next)
+			}
+			if tc.prev {
+				require.Equal(t, cursorOf(rows[0]).encode(), prev)
+			} else {
+				require.Empty(t, prev)
+			}
+		})
+	}
+}
+
+func TestListUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	n := 6
+	users := []db.ListUsersRow{}
+	for i := 0; i < n; i++ {
+		listedUser, _ := randomUser(t)
+		users = append(users, db.ListUsersRow{
+			ID:       int64(i + 1),
+			FullName: listedUser.FullName,
+			Username: listedUser.Username,
+		})
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "FirstPage",
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(db.ListUsersParams{UserID: user.ID, Limit: 6})).
+					Times(1).
+					Return(users, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []publicUserResponse `json:"items"`
+					NextCursor string               `json:"next_cursor"`
+					PrevCursor string               `json:"prev_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 5)
+				require.Equal(t, pageCursor{ID: 5}.encode(), rsp.NextCursor)
+				require.Empty(t, rsp.PrevCursor)
+			},
+		},
+		{
+			name:  "Before",
+			query: url.Values{"before": {pageCursor{ID: 3}.encode()}, "page_size": {"2"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				rows := []db.ListUsersBeforeRow{db.ListUsersBeforeRow(users[1]), db.ListUsersBeforeRow(users[0])}
+				store.EXPECT().
+					ListUsersBefore(gomock.Any(), gomock.Eq(db.ListUsersBeforeParams{UserID: user.ID, CursorID: 3, Limit: 3})).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []publicUserResponse `json:"items"`
+					NextCursor string               `json:"next_cursor"`
+					PrevCursor string               `json:"prev_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 2)
+				require.Equal(t, users[0].Username, rsp.Items[0].Username)
+				require.Equal(t, pageCursor{ID: 2}.encode(), rsp.NextCursor)
+				require.Empty(t, rsp.PrevCursor)
+			},
+		},
+		{
+			name:  "BeforeAndAfter",
+			query: url.Values{"before": {pageCursor{ID: 3}.encode()}, "after": {pageCursor{ID: 1}.encode()}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListUsersBefore(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: url.Values{"after": {"invalid"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "PageSizeTooLarge",
+			query: url.Values{"page_size": {"11"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/mail"
+	"github.com/renatomh/api-simplechat/util"
+)
+
+var errInvalidResetToken = errors.New("reset token is invalid, expired or already used")
+
+// How long the work done after answering a request can take
+const backgroundTimeout = time.Minute
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPassword emails a reset token to the user with the given email
+// The response is the same whether the email exists or not, so it can't be used to find out users' emails
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// The reset is only made after answering, so not even the response time tells which emails belong to users
+	server.background.Add(1)
+	go func() {
+		defer server.background.Done()
+		server.resetForgottenPassword(req.Email)
+	}()
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// resetForgottenPassword sends a password reset to the user with the given email, if there's one
+// Failures can only be logged, since the request was already answered
+func (server *Server) resetForgottenPassword(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), backgroundTimeout)
+	defer cancel()
+
+	user, err := server.store.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("cannot get user to reset its password:", err)
+		}
+		return
+	}
+
+	// Only verified emails are trusted to receive the token, since anyone could have typed them
+	if !user.IsEmailVerified {
+		return
+	}
+	err = server.sendPasswordReset(ctx, user)
+	if err != nil {
+		log.Printf("cannot send password reset to user %d: %v", user.ID, err)
+	}
+}
+
+// sendPasswordReset creates a password reset for the user, emailing the token which is only stored hashed
+func (server *Server) sendPasswordReset(ctx context.Context, user db.User) error {
+	resetToken, err := util.RandomSecret(secretCodeSize)
+	if err != nil {
+		return err
+	}
+
+	reset, err := server.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		UserID:    user.ID,
+		TokenHash: util.HashSecret(resetToken),
+		ExpiresAt: time.Now().Add(server.config.PasswordResetDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return server.mailer.Send(ctx, mail.Message{
+		To:      user.Email.String,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\r\n\r\nUse the token below to reset your password before %s:\r\n\r\n%s\r\n\r\n"+
+				"If you didn't ask for it, you can ignore this email and keep your current password.\r\n",
+			user.FullName,
+			reset.ExpiresAt.Format(time.RFC1123),
+			resetToken,
+		),
+	})
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// resetPassword sets a new password with the emailed token, ending every session started with the old one
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:         util.HashSecret(req.Token),
+		HashPass:          hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetToken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.endAllSessions(ctx, result.User)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,299 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/mail"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// failingMailer fails to send every email
+type failingMailer struct{}
+
+func (mailer failingMailer) Send(ctx context.Context, msg mail.Message) error {
+	return errors.New("mail server is unavailable")
+}
+
+func TestForgotPasswordAPIMailerFailure(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByEmail(gomock.Any(), gomock.Eq(user.Email.String)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		CreatePasswordReset(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.PasswordReset{UserID: user.ID, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+
+	server := newTestServer(t, store)
+	server.mailer = failingMailer{}
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"email": user.Email.String})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// The response is the same as for unknown emails
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	server.background.Wait()
+}
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+	unverifiedUser := user
+	unverifiedUser.IsEmailVerified = false
+
+	var sentTokenHash string
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email.String},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email.String)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePasswordResetParams) (db.PasswordReset, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiresAt, time.Second)
+						sentTokenHash = arg.TokenHash
+						return db.PasswordReset{UserID: user.ID, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Len(t, mails, 1)
+				require.Contains(t, mails[0], "To: "+user.Email.String)
+
+				// The emailed token is the one whose hash was stored
+				match := regexp.MustCompile(`\r\n\r\n([\w-]{43})\r\n`).FindStringSubmatch(mails[0])
+				require.Len(t, match, 2)
+				require.Equal(t, sentTokenHash, util.HashSecret(match[1]))
+			},
+		},
+		{
+			name: "UnverifiedEmail",
+			body: gin.H{"email": user.Email.String},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email.String)).
+					Times(1).
+					Return(unverifiedUser, nil)
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, mails)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{"email": "unknown@example.com"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				// Unknown emails can't be told apart from known ones
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, mails)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "invalid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"email": user.Email.String},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				// The lookup only happens after answering
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, mails)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/password/forgot", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			server.background.Wait()
+			tc.checkResponse(t, recorder, readMails(t, server))
+		})
+	}
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	resetToken, err := util.RandomSecret(secretCodeSize)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": resetToken, "new_password": "new-secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, util.HashSecret(resetToken), arg.TokenHash)
+						require.NoError(t, util.CheckPassword("new-secret", arg.HashPass))
+						return db.ResetPasswordTxResult{User: user}, nil
+					})
+				// Every session started with the old password is ended
+				store.EXPECT().
+					RevokeUserTokens(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{"token": "invalid", "new_password": "new-secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, sql.ErrNoRows)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidResetToken)
+			},
+		},
+		{
+			name: "ShortNewPassword",
+			body: gin.H{"token": resetToken, "new_password": "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"token": resetToken, "new_password": "new-secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/renatomh/api-simplechat/token"
+)
+
+var errRateLimited = errors.New("too many requests, try again later")
+
+// tokenBucket holds the requests a single client can still make, refilled continuously over time
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// rateLimiter keeps the token buckets of every client, each route group having buckets of its own
+type rateLimiter struct {
+	mu         sync.Mutex
+	buckets    map[string]*tokenBucket
+	lastPruned time.Time
+}
+
+// newRateLimiter creates a new rate limiter without any buckets
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets:    make(map[string]*tokenBucket),
+		lastPruned: time.Now(),
+	}
+}
+
+// rateLimitResult tells how the bucket stands after trying to take a token from it
+type rateLimitResult struct {
+	allowed   bool
+	remaining int
+	// Time until the next token, which is only set when none were left
+	retryAfter time.Duration
+	// Time until the bucket is full again
+	reset time.Duration
+}
+
+// take tries to take a token from the key's bucket, which holds up to the limit and is fully refilled over the period
+func (limiter *rateLimiter) take(key string, limit int, period time.Duration, now time.Time) rateLimitResult {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.pruneLocked(period, now)
+
+	capacity := float64(limit)
+	rate := capacity / period.Seconds()
+
+	bucket, ok := limiter.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, updatedAt: now}
+		limiter.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*rate)
+	bucket.updatedAt = now
+
+	result := rateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.allowed = true
+	} else {
+		result.retryAfter = time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	result.remaining = int(bucket.tokens)
+	result.reset = time.Duration((capacity - bucket.tokens) / rate * float64(time.Second))
+	return result
+}
+
+// pruneLocked drops the buckets which were already refilled, it must be called with the lock held
+func (limiter *rateLimiter) pruneLocked(period time.Duration, now time.Time) {
+	if now.Sub(limiter.lastPruned) < period {
+		return
+	}
+	limiter.lastPruned = now
+
+	// Every bucket is full after a whole period without requests, so it's the same as a new one
+	for key, bucket := range limiter.buckets {
+		if now.Sub(bucket.updatedAt) >= period {
+			delete(limiter.buckets, key)
+		}
+	}
+}
+
+// rateLimitMiddleware creates a gin middleware allowing up to limit requests per period to each client
+// Authenticated requests are limited by username and public ones by client IP, so it must come after the auth middleware
+// Limits are named, so routes sharing the same name share the same buckets, and a limit of 0 disables it
+func rateLimitMiddleware(limiter *rateLimiter, name string, limit int, period time.Duration) gin.HandlerFunc {
+	if limit <= 0 || period <= 0 {
+		return func(ctx *gin.Context) {
+			ctx.Next()
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		key := name + ":ip:" + ctx.ClientIP()
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			key = name + ":user:" + payload.(*token.Payload).Username
+		}
+
+		result := limiter.take(key, limit, period, time.Now())
+		ctx.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		ctx.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.reset.Seconds()))))
+
+		if !result.allowed {
+			setRetryAfter(ctx, result.retryAfter)
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(errRateLimited))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
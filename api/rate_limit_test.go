@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterTake(t *testing.T) {
+	limiter := newRateLimiter()
+	now := time.Now()
+
+	// The bucket starts full, allowing a burst of the whole limit
+	for i := 1; i >= 0; i-- {
+		result := limiter.take("key", 2, time.Minute, now)
+		require.True(t, result.allowed)
+		require.Equal(t, i, result.remaining)
+	}
+
+	result := limiter.take("key", 2, time.Minute, now)
+	require.False(t, result.allowed)
+	require.Equal(t, 30*time.Second, result.retryAfter)
+	require.Equal(t, time.Minute, result.reset)
+
+	// Other keys have buckets of their own
+	require.True(t, limiter.take("other", 2, time.Minute, now).allowed)
+
+	// Tokens are refilled over the period
+	result = limiter.take("key", 2, time.Minute, now.Add(30*time.Second))
+	require.True(t, result.allowed)
+	require.Equal(t, 0, result.remaining)
+
+	// Buckets refilled over a whole period are pruned
+	limiter.take("key", 2, time.Minute, now.Add(3*time.Minute))
+	require.Len(t, limiter.buckets, 1)
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenRevocation(store)
+
+	server := newTestServer(t, store)
+	limitedPath := "/limited"
+	server.router.GET(
+		limitedPath,
+		func(ctx *gin.Context) {
+			// Only requests with an authorization header are authenticated
+			if ctx.GetHeader(authorizationHeaderKey) != "" {
+				authMiddleware(server.tokenMaker, server.revocations)(ctx)
+			}
+		},
+		rateLimitMiddleware(server.rateLimiter, "limited", 2, time.Minute),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	send := func(username string, clientIP string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, limitedPath, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = clientIP + ":1234"
+		if username != "" {
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, time.Minute)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i := 1; i >= 0; i-- {
+		recorder := send("user", "10.0.0.1")
+		require.Equal(t, http.StatusOK, recorder.Code)
+		require.Equal(t, "2", recorder.Header().Get("X-RateLimit-Limit"))
+		require.Equal(t, strconv.Itoa(i), recorder.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	// Authenticated requests are limited by user, wherever they come from
+	recorder := send("user", "10.0.0.2")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+	require.Equal(t, "60", recorder.Header().Get("X-RateLimit-Reset"))
+	requireBodyMatchError(t, recorder.Body, errRateLimited)
+
+	require.Equal(t, http.StatusOK, send("other", "10.0.0.2").Code)
+
+	// Public requests are limited by client IP
+	require.Equal(t, http.StatusOK, send("", "10.0.0.1").Code)
+	require.Equal(t, http.StatusOK, send("", "10.0.0.1").Code)
+	require.Equal(t, http.StatusTooManyRequests, send("", "10.0.0.1").Code)
+	require.Equal(t, http.StatusOK, send("", "10.0.0.3").Code)
+}
+
+func TestRateLimitMiddlewareForwardedFor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	limitedPath := "/limited"
+	server.router.GET(
+		limitedPath,
+		rateLimitMiddleware(server.rateLimiter, "limited", 2, time.Minute),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	// No proxy is trusted, so forged headers don't change the client IP of the bucket
+	for i, forwardedFor := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		request, err := http.NewRequest(http.MethodGet, limitedPath, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		if i < 2 {
+			require.Equal(t, http.StatusOK, recorder.Code)
+		} else {
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		}
+	}
+}
+
+func TestRateLimitMiddlewareDisabled(t *testing.T) {
+	limiter := newRateLimiter()
+	handler := rateLimitMiddleware(limiter, "disabled", 0, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request, _ = http.NewRequest(http.MethodGet, "/", nil)
+	handler(ctx)
+
+	require.False(t, ctx.IsAborted())
+	require.Empty(t, recorder.Header().Get("X-RateLimit-Limit"))
+	require.Empty(t, limiter.buckets)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+)
+
+// How long a "not revoked" decision can be reused before checking the database again
+// Revocations made by this instance take effect immediately, others within this interval
+const revocationCacheTTL = 30 * time.Second
+
+// revocationEntry is the cached revocation decision for a single token
+type revocationEntry struct {
+	revoked   bool
+	checkedAt time.Time
+	expiredAt time.Time
+}
+
+// revocationList tells whether tokens were revoked, caching the decisions stored on Postgres
+type revocationList struct {
+	store      db.Store
+	mu         sync.Mutex
+	tokens     map[uuid.UUID]revocationEntry
+	cutoffs    map[string]time.Time
+	lastPruned time.Time
+}
+
+// newRevocationList creates a new revocation list backed by the store
+func newRevocationList(store db.Store) *revocationList {
+	return &revocationList{
+		store:      store,
+		tokens:     make(map[uuid.UUID]revocationEntry),
+		cutoffs:    make(map[string]time.Time),
+		lastPruned: time.Now(),
+	}
+}
+
+// isRevoked checks if the token was revoked, either by itself or together with every token of its user
+func (list *revocationList) isRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	list.mu.Lock()
+	list.pruneLocked()
+	entry, cached := list.tokens[payload.ID]
+	cutoff, hasCutoff := list.cutoffs[payload.Username]
+	list.mu.Unlock()
+
+	// Revocations made by this instance don't need to wait for the cache to expire
+	if hasCutoff && payload.IssuedAt.Before(cutoff) {
+		return true, nil
+	}
+	if cached && (entry.revoked || time.Since(entry.checkedAt) < revocationCacheTTL) {
+		return entry.revoked, nil
+	}
+
+	revocation, err := list.store.GetTokenRevocation(ctx, db.GetTokenRevocationParams{
+		TokenID:  payload.ID,
+		Username: payload.Username,
+	})
+	var revoked bool
+	switch {
+	case err == sql.ErrNoRows:
+		// The user was deleted, so none of its tokens are valid anymore
+		revoked = true
+	case err != nil:
+		return false, err
+	default:
+		// Tokens issued before the last password change or "logout all" are no longer valid
+		revoked = revocation.IsRevoked || payload.IssuedAt.Before(revocation.ValidAfter)
+	}
+
+	list.mu.Lock()
+	list.tokens[payload.ID] = revocationEntry{
+		revoked:   revoked,
+		checkedAt: time.Now(),
+		expiredAt: payload.ExpiredAt,
+	}
+	list.mu.Unlock()
+
+	return revoked, nil
+}
+
+// revoke invalidates a single token
+func (list *revocationList) revoke(ctx context.Context, payload *token.Payload) error {
+	err := list.store.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Expired tokens are rejected anyway, so there's no need to keep them on the list
+	err = list.store.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	list.tokens[payload.ID] = revocationEntry{
+		revoked:   true,
+		checkedAt: time.Now(),
+		expiredAt: payload.ExpiredAt,
+	}
+	list.mu.Unlock()
+
+	return nil
+}
+
+// revokeAll invalidates every token issued to the user until now
+func (list *revocationList) revokeAll(ctx context.Context, username string) error {
+	cutoff := time.Now()
+	err := list.store.RevokeUserTokens(ctx, db.RevokeUserTokensParams{
+		Username:        username,
+		TokensRevokedAt: cutoff,
+	})
+	if err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	list.cutoffs[username] = cutoff
+	list.mu.Unlock()
+
+	return nil
+}
+
+// pruneLocked drops entries which are no longer useful, it must be called with the lock held
+func (list *revocationList) pruneLocked() {
+	now := time.Now()
+	if now.Sub(list.lastPruned) < revocationCacheTTL {
+		return
+	}
+	list.lastPruned = now
+
+	for id, entry := range list.tokens {
+		// Expired tokens are rejected anyway, and valid decisions are refreshed after the TTL
+		if now.After(entry.expiredAt) || (!entry.revoked && now.Sub(entry.checkedAt) >= revocationCacheTTL) {
+			delete(list.tokens, id)
+		}
+	}
+	for username, cutoff := range list.cutoffs {
+		// After the TTL, every cached decision for the user was already refreshed from the database
+		if now.Sub(cutoff) >= revocationCacheTTL {
+			delete(list.cutoffs, username)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+)
+
+// buildSearchQuery converts the search terms into a Postgres tsquery, where:
+//   - "quoted words" must appear next to each other, as a phrase
+//   - words ending with * match any word starting with them
+//   - every other word must appear anywhere on the message
+func buildSearchQuery(terms string) (string, error) {
+	queryTerms := []string{}
+
+	// Segments with odd indexes were between quotes, unless the last quote was left open
+	segments := strings.Split(terms, `"`)
+	for i, segment := range segments {
+		words := searchWords(segment)
+		if len(words) == 0 {
+			continue
+		}
+
+		isPhrase := i%2 == 1 && i < len(segments)-1
+		if isPhrase && len(words) > 1 {
+			queryTerms = append(queryTerms, "("+strings.Join(words, " <-> ")+")")
+			continue
+		}
+		queryTerms = append(queryTerms, words...)
+	}
+
+	if len(queryTerms) == 0 {
+		return "", fmt.Errorf("search query must contain at least one word")
+	}
+	return strings.Join(queryTerms, " & "), nil
+}
+
+// searchWords splits a text into tsquery words, keeping only letters and digits so no operator can be injected
+func searchWords(text string) []string {
+	words := []string{}
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '*'
+	})
+	for _, field := range fields {
+		parts := strings.FieldsFunc(field, func(r rune) bool { return r == '*' })
+		for i, part := range parts {
+			// Only a trailing asterisk turns the word into a prefix
+			if i == len(parts)-1 && strings.HasSuffix(field, "*") {
+				part += ":*"
+			}
+			words = append(words, part)
+		}
+	}
+	return words
+}
+
+type searchMessageRequest struct {
+	Query  string    `form:"q" binding:"required,max=200"`
+	ChatID int64     `form:"chat_id" binding:"omitempty,min=1"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	pageRequest
+}
+
+func (server *Server) searchMessage(ctx *gin.Context) {
+	var req searchMessageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(fmt.Errorf("from must be before to")))
+		return
+	}
+
+	query, err := buildSearchQuery(req.Query)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// Search results are sorted by their rank, so their cursors must have one
+	if p.Cursor != nil && p.Cursor.Rank == nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidCursor))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Checking if user is trying to search a chat where it does not take part
+	if req.ChatID != 0 {
+		if _, _, ok := server.getChatMembership(ctx, req.ChatID, user.ID); !ok {
+			return
+		}
+	}
+
+	// Only messages from chats the user takes part on are searched, from the most relevant ones
+	// Snippets are HTML, with the body escaped and only the matches wrapped in <mark> tags
+	chatID := sql.NullInt64{Int64: req.ChatID, Valid: req.ChatID != 0}
+	sentFrom := sql.NullTime{Time: req.From, Valid: !req.From.IsZero()}
+	sentTo := sql.NullTime{Time: req.To, Valid: !req.To.IsZero()}
+	messages := []db.SearchMessagesRow{}
+	if p.Backward {
+		var rows []db.SearchMessagesBeforeRow
+		rows, err = server.store.SearchMessagesBefore(ctx, db.SearchMessagesBeforeParams{
+			Query:      query,
+			UserID:     user.ID,
+			ChatID:     chatID,
+			SentFrom:   sentFrom,
+			SentTo:     sentTo,
+			CursorRank: *p.Cursor.Rank,
+			CursorID:   p.Cursor.ID,
+			Limit:      p.limit(),
+		})
+		for _, row := range rows {
+			messages = append(messages, db.SearchMessagesRow(row))
+		}
+	} else {
+		messages, err = server.store.SearchMessages(ctx, db.SearchMessagesParams{
+			Query:      query,
+			UserID:     user.ID,
+			ChatID:     chatID,
+			SentFrom:   sentFrom,
+			SentTo:     sentTo,
+			CursorID:   p.Cursor.nullID(),
+			CursorRank: p.Cursor.nullRank(),
+			Limit:      p.limit(),
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	messages, next, prev := paginate(messages, p, func(message db.SearchMessagesRow) pageCursor {
+		return pageCursor{Rank: &message.Rank, ID: message.ID}
+	})
+
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      messages,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
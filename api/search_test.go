@@ -0,0 +1,297 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSearchQuery(t *testing.T) {
+	testCases := []struct {
+		terms string
+		query string
+	}{
+		{terms: "hello", query: "hello"},
+		{terms: "hello world", query: "hello & world"},
+		{terms: `"hello world"`, query: "(hello <-> world)"},
+		{terms: `say "hello there world" now`, query: "say & (hello <-> there <-> world) & now"},
+		{terms: "hel*", query: "hel:*"},
+		{terms: `"hello wor*"`, query: "(hello <-> wor:*)"},
+		{terms: `"hello"`, query: "hello"},
+		{terms: `"unbalanced quote`, query: "unbalanced & quote"},
+		{terms: "a*b*", query: "a & b:*"},
+		{terms: "olá, mundo!", query: "olá & mundo"},
+		{terms: "x & y | !z <-> w:*", query: "x & y & z & w"},
+	}
+
+	for _, tc := range testCases {
+		query, err := buildSearchQuery(tc.terms)
+		require.NoError(t, err, tc.terms)
+		require.Equal(t, tc.query, query, tc.terms)
+	}
+
+	for _, terms := range []string{"", `""`, "& | !", "***"} {
+		_, err := buildSearchQuery(terms)
+		require.Error(t, err, terms)
+	}
+}
+
+func TestSearchMessageAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	chatID := util.RandomInt(1, 1000)
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	rows := []db.SearchMessagesRow{
+		{
+			ID:      util.RandomInt(1, 1000),
+			ChatID:  chatID,
+			Rank:    0.5,
+			Snippet: "<mark>hello</mark> there",
+		},
+	}
+
+	rank := float32(0.25)
+	cursor := pageCursor{Rank: &rank, ID: 42}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"q":         {`"hello there" wor*`},
+				"page_size": {"5"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				arg := db.SearchMessagesParams{
+					Query:  "(hello <-> there) & wor:*",
+					UserID: user.ID,
+					Limit:  6,
+				}
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []db.SearchMessagesRow `json:"items"`
+					NextCursor string                 `json:"next_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, rows, rsp.Items)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name: "ChatAndDateRange",
+			query: url.Values{
+				"q":       {"hello"},
+				"chat_id": {fmt.Sprint(chatID)},
+				"from":    {from.Format(time.RFC3339)},
+				"to":      {to.Format(time.RFC3339)},
+				"after":   {cursor.encode()},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chatID)).
+					Times(1).
+					Return(db.Chat{ID: chatID}, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Eq(db.GetChatMemberParams{ChatID: chatID, UserID: user.ID})).
+					Times(1).
+					Return(db.ChatMember{ChatID: chatID, UserID: user.ID}, nil)
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.SearchMessagesParams) ([]db.SearchMessagesRow, error) {
+						require.Equal(t, sql.NullInt64{Int64: chatID, Valid: true}, arg.ChatID)
+						require.True(t, arg.SentFrom.Valid)
+						require.True(t, from.Equal(arg.SentFrom.Time))
+						require.True(t, arg.SentTo.Valid)
+						require.True(t, to.Equal(arg.SentTo.Time))
+						require.Equal(t, sql.NullInt64{Int64: cursor.ID, Valid: true}, arg.CursorID)
+						require.Equal(t, sql.NullFloat64{Float64: float64(rank), Valid: true}, arg.CursorRank)
+						return rows, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Backward",
+			query: url.Values{
+				"q":      {"hello"},
+				"before": {cursor.encode()},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				arg := db.SearchMessagesBeforeParams{
+					Query:      "hello",
+					UserID:     user.ID,
+					CursorRank: rank,
+					CursorID:   cursor.ID,
+					Limit:      6,
+				}
+				store.EXPECT().
+					SearchMessagesBefore(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.SearchMessagesBeforeRow{db.SearchMessagesBeforeRow(rows[0])}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []db.SearchMessagesRow `json:"items"`
+					NextCursor string                 `json:"next_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, rows, rsp.Items)
+				require.Equal(t, pageCursor{Rank: &rows[0].Rank, ID: rows[0].ID}.encode(), rsp.NextCursor)
+			},
+		},
+		{
+			name: "CursorWithoutRank",
+			query: url.Values{
+				"q":     {"hello"},
+				"after": {pageCursor{ID: 42}.encode()},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCursor)
+			},
+		},
+		{
+			name: "PageSizeTooLarge",
+			query: url.Values{
+				"q":         {"hello"},
+				"page_size": {"11"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotChatMember",
+			query: url.Values{
+				"q":       {"hello"},
+				"chat_id": {fmt.Sprint(chatID)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetChat(gomock.Any(), gomock.Eq(chatID)).
+					Times(1).
+					Return(db.Chat{ID: chatID}, nil)
+				store.EXPECT().
+					GetChatMember(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChatMember{}, sql.ErrNoRows)
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidDateRange",
+			query: url.Values{
+				"q":    {"hello"},
+				"from": {to.Format(time.RFC3339)},
+				"to":   {from.Format(time.RFC3339)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoWords",
+			query: url.Values{
+				"q": {"&|!"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchMessages(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/messages/search?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
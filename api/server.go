@@ -2,66 +2,167 @@ package api
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/mail"
+	"github.com/renatomh/api-simplechat/storage"
 	"github.com/renatomh/api-simplechat/token"
 	"github.com/renatomh/api-simplechat/util"
 )
 
 // Server serves HTTP requests for the application
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config       util.Config
+	store        db.Store
+	tokenMaker   token.Maker
+	blobs        storage.BlobStore
+	mailer       mail.Mailer
+	revocations  *revocationList
+	loginLimiter *loginLimiter
+	rateLimiter  *rateLimiter
+	router       *gin.Engine
+	hub          *hub
+	// Work still running after its request was answered
+	background sync.WaitGroup
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+// NewServer creates a new HTTP server, creating and verifying tokens with the given maker
+func NewServer(config util.Config, store db.Store, tokenMaker token.Maker) (*Server, error) {
+	blobs, err := storage.NewLocalBlobStore(config.BlobStoragePath)
 	if err != nil {
-		return nil, fmt.Errorf("cannot create token maker: %w", err)
+		return nil, fmt.Errorf("cannot create blob store: %w", err)
+	}
+
+	mailer, err := mail.NewFileMailer(config.MailerOutputPath, config.MailSender)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer: %w", err)
+	}
+
+	loginLimiter, err := newLoginLimiter(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create login limiter: %w", err)
 	}
 
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:       config,
+		store:        store,
+		tokenMaker:   tokenMaker,
+		blobs:        blobs,
+		mailer:       mailer,
+		revocations:  newRevocationList(store),
+		loginLimiter: loginLimiter,
+		rateLimiter:  newRateLimiter(),
+		hub:          newHub(),
 	}
 
-	server.setupRouter()
+	err = server.setupRouter()
+	if err != nil {
+		return nil, fmt.Errorf("cannot setup router: %w", err)
+	}
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	router := gin.Default()
 
+	// Otherwise any client could pick its own IP with the X-Forwarded-For header, getting around the limits by IP
+	err := router.SetTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	// Public routes share a single limit for each client IP
+	publicRateLimit := server.rateLimit("public", server.config.RateLimitPublic)
+
 	// Adding routes to the router
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	router.POST("/users", publicRateLimit, server.createUser)
+	router.POST("/users/login", publicRateLimit, server.loginUser)
+	router.POST("/users/login/mfa", publicRateLimit, server.loginMFA)
+	router.POST("/tokens/renew_access", publicRateLimit, server.renewAccessToken)
+	router.GET("/users/verify_email", publicRateLimit, server.verifyEmail)
+	router.POST("/users/password/forgot", publicRateLimit, server.forgotPassword)
+	router.POST("/users/password/reset", publicRateLimit, server.resetPassword)
 
-	// Defining group of routes which require authentication
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	// The websocket handshake authenticates on its own, since browsers can't set headers on it
+	router.GET("/ws", publicRateLimit, server.serveWebSocket)
 
+	// Defining group of routes which require authentication
+	authRoutes := router.Group("/").Use(
+		authMiddleware(server.tokenMaker, server.revocations),
+		server.rateLimit("default", server.config.RateLimitDefault),
+	)
+
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.POST("/users/logout_all", server.logoutAllUser)
+	authRoutes.GET("/users/me", server.getCurrentUser)
+	authRoutes.PATCH("/users/me", server.updateUser)
+	authRoutes.PUT("/users/me/password", server.changeUserPassword)
+	authRoutes.GET("/users/me/logins", server.listLoginEvent)
+	authRoutes.POST("/users/me/verify_email", server.resendVerifyEmail)
+	authRoutes.POST("/users/me/totp", server.enrollTOTP)
+	authRoutes.POST("/users/me/totp/confirm", server.confirmTOTP)
+	authRoutes.DELETE("/users/me/totp", server.disableTOTP)
 	authRoutes.GET("/users/:id", server.getUser)
 	authRoutes.GET("/users", server.listUser)
+	authRoutes.GET("/users/blocked", server.listBlockedUser)
+	authRoutes.POST("/users/:id/block", server.blockUser)
+	authRoutes.DELETE("/users/:id/block", server.unblockUser)
 
-	authRoutes.POST("/contacts", server.createContact)
+	authRoutes.POST("/contacts", server.rateLimit("contacts", server.config.RateLimitContacts), server.createContact)
 	authRoutes.GET("/contacts", server.listContact)
-	authRoutes.GET("/contacts/pending", server.listPendingContact)
-	authRoutes.GET("/contacts/accepted", server.listAcceptedContact)
-	authRoutes.GET("/contacts/rejected", server.listRejectedContact)
 
 	authRoutes.PUT("/contacts/:id/accept", server.acceptContact)
 	authRoutes.PUT("/contacts/:id/reject", server.rejectContact)
+	authRoutes.POST("/contacts/:id/cancel", server.cancelContact)
+	authRoutes.DELETE("/contacts/:id", server.removeContact)
 
 	authRoutes.POST("/chats", server.createChat)
 	authRoutes.GET("/chats", server.listChat)
-
-	authRoutes.POST("/messages", server.createMessage)
+	authRoutes.GET("/chats/:id/members", server.listChatMember)
+	authRoutes.POST("/chats/:id/members", server.addChatMember)
+	authRoutes.DELETE("/chats/:id/members/:user_id", server.removeChatMember)
+	authRoutes.PUT("/chats/:id/members/:user_id/role", server.updateChatMemberRole)
+	authRoutes.POST("/chats/:id/leave", server.leaveChat)
+	authRoutes.POST("/chats/:id/transfer", server.transferChatOwnership)
+	authRoutes.POST("/chats/:id/read", server.markChatRead)
+
+	authRoutes.POST("/messages", server.rateLimit("messages", server.config.RateLimitMessages), server.createMessage)
 	authRoutes.GET("/messages", server.listMessage)
+	authRoutes.GET("/messages/search", server.searchMessage)
+	authRoutes.PATCH("/messages/:id", server.updateMessage)
+	authRoutes.DELETE("/messages/:id", server.deleteMessage)
+	authRoutes.GET("/messages/:id/revisions", server.listMessageRevision)
+
+	authRoutes.POST("/attachments", server.uploadAttachment)
+	authRoutes.GET("/attachments/:id", server.getAttachment)
+
+	// Admin routes are grouped by the scope they require, on top of authentication
+	adminRoutes := router.Group("/admin",
+		authMiddleware(server.tokenMaker, server.revocations),
+		server.rateLimit("default", server.config.RateLimitDefault),
+	)
+
+	adminUserRoutes := adminRoutes.Group("/users", scopeMiddleware(token.ScopeAdminUsers))
+	adminUserRoutes.GET("", server.listAdminUser)
+	adminUserRoutes.POST("/:id/suspend", server.suspendUser)
+	adminUserRoutes.DELETE("/:id/suspend", server.unsuspendUser)
+	adminUserRoutes.POST("/:id/reset_password", server.forceResetPassword)
+
+	adminMessageRoutes := adminRoutes.Group("/messages", scopeMiddleware(token.ScopeAdminMessages))
+	adminMessageRoutes.DELETE("/:id", server.deleteAdminMessage)
+
+	adminStatsRoutes := adminRoutes.Group("/stats", scopeMiddleware(token.ScopeAdminStats))
+	adminStatsRoutes.GET("", server.getSystemStats)
 
 	server.router = router
+	return nil
+}
+
+// rateLimit creates a named rate limit middleware, allowing up to the given requests per period of the config
+func (server *Server) rateLimit(name string, limit int) gin.HandlerFunc {
+	return rateLimitMiddleware(server.rateLimiter, name, limit, server.config.RateLimitPeriod)
 }
 
 // Start runs the HTTP server on a specific address
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	suspendedUser := user
+	suspendedUser.SuspendedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	stubSession := func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+		store.EXPECT().
+			GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+			Times(1).
+			Return(db.Session{
+				ID:           payload.ID,
+				Username:     user.Username,
+				RefreshToken: refreshToken,
+				ExpiresAt:    payload.ExpiredAt,
+			}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		tokenType     token.TokenType
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				stubSession(store, refreshToken, payload)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), rsp.AccessTokenExpiresAt, time.Second)
+			},
+		},
+		{
+			name:      "DemotedUser",
+			role:      util.AdminRole,
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				stubSession(store, refreshToken, payload)
+				// The user was an admin when logging in, but no longer is
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				payload, err := testTokenMaker(t).VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, util.UserRole, payload.Role)
+				require.False(t, payload.HasScopes(token.ScopeAdminUsers))
+			},
+		},
+		{
+			name:      "SuspendedUser",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				stubSession(store, refreshToken, payload)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(suspendedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errUserSuspended)
+			},
+		},
+		{
+			name:      "AccessToken",
+			tokenType: token.TokenTypeAccessToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "SessionNotFound",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "BlockedSession",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						Username:     user.Username,
+						RefreshToken: refreshToken,
+						IsBlocked:    true,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "IncorrectSessionUser",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						Username:     "someone.else",
+						RefreshToken: refreshToken,
+						ExpiresAt:    payload.ExpiredAt,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "ExpiredSession",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(payload.ID)).
+					Times(1).
+					Return(db.Session{
+						ID:           payload.ID,
+						Username:     user.Username,
+						RefreshToken: refreshToken,
+						ExpiresAt:    time.Now().Add(-time.Minute),
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			tokenType: token.TokenTypeRefreshToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			role := tc.role
+			if role == "" {
+				role = util.UserRole
+			}
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, role, tc.tokenType, time.Hour)
+			require.NoError(t, err)
+			tc.buildStubs(store, refreshToken, payload)
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			url := "/tokens/renew_access"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+	"github.com/renatomh/api-simplechat/util"
+)
+
+// Amount of recovery codes given when enabling two-factor authentication, and their random bytes
+const (
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 8
+)
+
+var (
+	errInvalidMFACode       = errors.New("authentication code is invalid or already used")
+	errTOTPAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	errTOTPNotEnabled       = errors.New("two-factor authentication is not enabled")
+	errTOTPNotEnrolled      = errors.New("two-factor authentication must be enrolled first")
+	errInvalidMFALoginToken = errors.New("token is not a pending two-factor login token")
+)
+
+// checkSecondFactor checks a TOTP code or, failing that, a recovery code of the user
+// Both are used up when valid, so they can't be replayed
+func (server *Server) checkSecondFactor(ctx *gin.Context, user db.User, code string) (bool, error) {
+	counter, ok := util.ValidateTOTP(code, user.TotpSecret.String, time.Now())
+	if ok {
+		_, err := server.store.UseUserTOTPCounter(ctx, db.UseUserTOTPCounterParams{
+			ID:      user.ID,
+			Counter: counter,
+		})
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	_, err := server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: util.HashSecret(code),
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+type mfaRequiredResponse struct {
+	MFARequired       bool      `json:"mfa_required"`
+	MFAToken          string    `json:"mfa_token"`
+	MFATokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+// requireMFA responds to a correct password with a short-lived token, to be exchanged for the session along with a code
+func (server *Server) requireMFA(ctx *gin.Context, user db.User) {
+	mfaToken, mfaPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeMFAPendingToken,
+		server.config.MFATokenDuration,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mfaRequiredResponse{
+		MFARequired:       true,
+		MFAToken:          mfaToken,
+		MFATokenExpiresAt: mfaPayload.ExpiredAt,
+	})
+}
+
+type loginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// loginMFA completes a two-factor login, starting the session once the code is valid
+func (server *Server) loginMFA(ctx *gin.Context) {
+	var req loginMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(req.MFAToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if payload.Type != token.TokenTypeMFAPendingToken {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFALoginToken))
+		return
+	}
+
+	// Codes are backed off along with passwords, otherwise they could be guessed once the password is known
+	if !server.allowLogin(ctx, payload.Username) {
+		return
+	}
+
+	// Like any other token, it's no longer valid once the password changed
+	revoked, err := server.revocations.isRevoked(ctx, payload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if revoked {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("token has been revoked")))
+		return
+	}
+
+	user, err := server.store.GetUserByUsername(ctx, payload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Two-factor authentication may have been disabled since the password was checked
+	if !user.IsTotpEnabled {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errTOTPNotEnabled))
+		return
+	}
+
+	valid, err := server.checkSecondFactor(ctx, user, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !valid {
+		_, err = server.store.RecordLoginTx(ctx, server.newLoginEvent(ctx, user, false))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.failLogin(ctx, user.Username, errInvalidMFACode)
+		return
+	}
+
+	server.startSession(ctx, user)
+}
+
+type enrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURL string `json:"otpauth_url"`
+}
+
+// enrollTOTP generates a new TOTP secret for the user, which is only enabled once confirmed with a first code
+func (server *Server) enrollTOTP(ctx *gin.Context) {
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsTotpEnabled {
+		ctx.JSON(http.StatusForbidden, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+
+	secret, otpauthURL, err := util.GenerateTOTP(server.config.TOTPIssuer, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Enrolling again replaces a secret which wasn't confirmed yet
+	_, err = server.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusForbidden, errorResponse(errTOTPAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollTOTPResponse{
+		Secret:     secret,
+		OtpauthURL: otpauthURL,
+	})
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTOTP enables two-factor authentication with a first code, responding with the recovery codes
+// The recovery codes are only stored hashed, so this is the only time the user gets to see them
+func (server *Server) confirmTOTP(ctx *gin.Context) {
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsTotpEnabled {
+		ctx.JSON(http.StatusForbidden, errorResponse(errTOTPAlreadyEnabled))
+		return
+	}
+	if !user.TotpSecret.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(errTOTPNotEnrolled))
+		return
+	}
+
+	// Recovery codes can't confirm the secret, since they don't prove the authenticator was set up
+	counter, ok := util.ValidateTOTP(req.Code, user.TotpSecret.String, time.Now())
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+		return
+	}
+	_, err = server.store.UseUserTOTPCounter(ctx, db.UseUserTOTPCounterParams{
+		ID:      user.ID,
+		Counter: counter,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMFACode))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	codeHashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = util.RandomSecret(recoveryCodeSize)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		codeHashes[i] = util.HashSecret(codes[i])
+	}
+
+	_, err = server.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		UserID:             user.ID,
+		RecoveryCodeHashes: codeHashes,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+type disableTOTPRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// disableTOTP turns two-factor authentication off, which requires both factors so a stolen session isn't enough
+func (server *Server) disableTOTP(ctx *gin.Context) {
+	var req disableTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.IsTotpEnabled {
+		ctx.JSON(http.StatusForbidden, errorResponse(errTOTPNotEnabled))
+		return
+	}
+
+	if !server.checkCurrentPassword(ctx, user, req.Password) {
+		return
+	}
+
+	valid, err := server.checkSecondFactor(ctx, user, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !valid {
+		server.failLogin(ctx, user.Username, errInvalidMFACode)
+		return
+	}
+
+	_, err = server.store.DisableTOTPTx(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
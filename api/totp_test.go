@@ -0,0 +1,571 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/pquerna/otp/totp"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomTOTPUser creates a random user with two-factor authentication enabled
+func randomTOTPUser(t *testing.T) (db.User, string) {
+	user, password := randomUser(t)
+
+	secret, _, err := util.GenerateTOTP("Simple Chat", user.Username)
+	require.NoError(t, err)
+	user.TotpSecret = sql.NullString{String: secret, Valid: true}
+	user.IsTotpEnabled = true
+
+	return user, password
+}
+
+func TestLoginUserAPIRequiresMFA(t *testing.T) {
+	user, password := randomTOTPUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	// The session only starts once the code is sent
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(0)
+	store.EXPECT().
+		RecordLoginTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp mfaRequiredResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.True(t, rsp.MFARequired)
+
+	payload, err := server.tokenMaker.VerifyToken(rsp.MFAToken)
+	require.NoError(t, err)
+	require.Equal(t, token.TokenTypeMFAPendingToken, payload.Type)
+	require.Equal(t, user.Username, payload.Username)
+	require.WithinDuration(t, time.Now().Add(time.Minute), rsp.MFATokenExpiresAt, time.Second)
+}
+
+func TestLoginMFAAPI(t *testing.T) {
+	user, _ := randomTOTPUser(t)
+	disabledUser := user
+	disabledUser.IsTotpEnabled = false
+
+	code, err := totp.GenerateCode(user.TotpSecret.String, time.Now())
+	require.NoError(t, err)
+	recoveryCode, err := util.RandomSecret(recoveryCodeSize)
+	require.NoError(t, err)
+
+	expectSession := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			CreateSession(gomock.Any(), gomock.Any()).
+			Times(1)
+		store.EXPECT().
+			RecordLoginTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.RecordLoginTxResult, error) {
+				require.True(t, arg.Succeeded)
+				return db.RecordLoginTxResult{User: user}, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		tokenType     token.TokenType
+		duration      time.Duration
+		code          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "TOTPCode",
+			tokenType: token.TokenTypeMFAPendingToken,
+			duration:  time.Minute,
+			code:      code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UseUserTOTPCounter(gomock.Any(), gomock.Eq(db.UseUserTOTPCounterParams{ID: user.ID, Counter: time.Now().Unix() / 30})).
+					Times(1).
+					Return(user, nil)
+				expectSession(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+			},
+		},
+		{
+			name:      "RecoveryCode",
+			tokenType: token.TokenTypeMFAPendingToken,
+			duration:  time.Minute,
+			code:      recoveryCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{UserID: user.ID, CodeHash: util.HashSecret(recoveryCode)})).
+					Times(1).
+					Return(db.RecoveryCode{}, nil)
+				expectSession(store)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "ReplayedCode",
+			tokenType: token.TokenTypeMFAPendingToken,
+			duration:  time.Minute,
+			code:      code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					UseUserTOTPCounter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					RecordLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.RecordLoginTxResult, error) {
+						require.False(t, arg.Succeeded)
+						return db.RecordLoginTxResult{}, nil
+					})
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidMFACode)
+			},
+		},
+		{
+			name:      "TOTPDisabled",
+			tokenType: token.TokenTypeMFAPendingToken,
+			duration:  time.Minute,
+			code:      code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(disabledUser, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccessToken",
+			tokenType: token.TokenTypeAccessToken,
+			duration:  time.Minute,
+			code:      code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidMFALoginToken)
+			},
+		},
+		{
+			name:      "ExpiredToken",
+			tokenType: token.TokenTypeMFAPendingToken,
+			duration:  -time.Minute,
+			code:      code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			mfaToken, _, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, tc.tokenType, tc.duration)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(gin.H{"mfa_token": mfaToken, "code": tc.code})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	enabledUser, _ := randomTOTPUser(t)
+	enabledUser.Username = user.Username
+
+	var storedSecret string
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.SetUserTOTPSecretParams) (db.User, error) {
+						require.Equal(t, user.ID, arg.ID)
+						storedSecret = arg.TotpSecret
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, storedSecret, rsp.Secret)
+				require.Contains(t, rsp.OtpauthURL, "otpauth://totp/")
+				require.Contains(t, rsp.OtpauthURL, "secret="+rsp.Secret)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			user: enabledUser,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(tc.user.Username)).
+				AnyTimes().
+				Return(tc.user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	enabledUser, _ := randomTOTPUser(t)
+	pendingUser := enabledUser
+	pendingUser.IsTotpEnabled = false
+	notEnrolledUser := pendingUser
+	notEnrolledUser.TotpSecret = sql.NullString{}
+
+	code, err := totp.GenerateCode(pendingUser.TotpSecret.String, time.Now())
+	require.NoError(t, err)
+
+	var storedHashes []string
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		code          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: pendingUser,
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UseUserTOTPCounter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(pendingUser, nil)
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.EnableTOTPTxParams) (db.User, error) {
+						require.Equal(t, pendingUser.ID, arg.UserID)
+						storedHashes = arg.RecoveryCodeHashes
+						return enabledUser, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp recoveryCodesResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, recoveryCodeCount)
+
+				// Only the hashes of the recovery codes are stored
+				for i, code := range rsp.RecoveryCodes {
+					require.Equal(t, util.HashSecret(code), storedHashes[i])
+				}
+			},
+		},
+		{
+			name: "InvalidCode",
+			user: pendingUser,
+			code: "000000x",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			user: notEnrolledUser,
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errTOTPNotEnrolled)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			user: enabledUser,
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errTOTPAlreadyEnabled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(tc.user.Username)).
+				AnyTimes().
+				Return(tc.user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"code": tc.code})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDisableTOTPAPI(t *testing.T) {
+	user, password := randomTOTPUser(t)
+	disabledUser := user
+	disabledUser.IsTotpEnabled = false
+
+	code, err := totp.GenerateCode(user.TotpSecret.String, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user,
+			body: gin.H{"password": password, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UseUserTOTPCounter(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					DisableTOTPTx(gomock.Any(), gomock.Eq(user.ID)).
+					Times(1).
+					Return(disabledUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "WrongPassword",
+			user: user,
+			body: gin.H{"password": "wrong-password", "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DisableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			user: user,
+			body: gin.H{"password": password, "code": "invalid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().
+					DisableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidMFACode)
+			},
+		},
+		{
+			name: "NotEnabled",
+			user: disabledUser,
+			body: gin.H{"password": password, "code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DisableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(tc.user.Username)).
+				AnyTimes().
+				Return(tc.user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodDelete, "/users/me/totp", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -2,12 +2,16 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/lib/pq"
 	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
 	"github.com/renatomh/api-simplechat/util"
 )
 
@@ -22,6 +26,8 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	AvatarUrl         string    `json:"avatar_url"`
@@ -33,6 +39,8 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email.String,
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 		AvatarUrl:         user.AvatarUrl.String,
@@ -53,17 +61,27 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.CreateUserParams{
-		FullName: req.FullName,
-		Username: req.Username,
-		Email: sql.NullString{
-			String: req.Email,
-			Valid:  req.Email != "",
+	code, codeHash, err := newVerifyCode()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			FullName: req.FullName,
+			Username: req.Username,
+			Email: sql.NullString{
+				String: req.Email,
+				Valid:  req.Email != "",
+			},
+			HashPass: hashedPassword,
 		},
-		HashPass: hashedPassword,
+		VerifyCodeHash:  codeHash,
+		VerifyExpiresAt: time.Now().Add(server.config.EmailVerificationDuration),
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	result, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -71,13 +89,21 @@ func (server *Server) createUser(ctx *gin.Context) {
 				ctx.JSON(http.StatusForbidden, errorResponse(err))
 				return
 			}
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The email is only sent once the user is created, failures are logged since the user can ask for it again
+	if result.User.Email.Valid {
+		err = server.sendVerifyEmail(ctx, result.User, result.EmailVerification, code)
+		if err != nil {
+			log.Printf("cannot send verification email to user %d: %v", result.User.ID, err)
 		}
 	}
 
 	// We won't return the hashed password to the user
-	rsp := newUserResponse(user)
+	rsp := newUserResponse(result.User)
 	ctx.JSON(http.StatusOK, rsp)
 }
 
@@ -126,8 +152,7 @@ func (server *Server) getUser(ctx *gin.Context) {
 }
 
 type listUserRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
 }
 
 func listUserResponse(users []db.ListUsersRow) []publicUserResponse {
@@ -151,29 +176,78 @@ func (server *Server) listUser(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.ListUsersParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Users who blocked the one listing are left out
+	var users []db.ListUsersRow
+	if p.Backward {
+		var rows []db.ListUsersBeforeRow
+		rows, err = server.store.ListUsersBefore(ctx, db.ListUsersBeforeParams{
+			UserID:   user.ID,
+			CursorID: p.Cursor.ID,
+			Limit:    p.limit(),
+		})
+		for _, row := range rows {
+			users = append(users, db.ListUsersRow(row))
+		}
+	} else {
+		users, err = server.store.ListUsers(ctx, db.ListUsersParams{
+			UserID:   user.ID,
+			CursorID: p.Cursor.nullID(),
+			Limit:    p.limit(),
+		})
 	}
-	users, err := server.store.ListUsers(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	users, next, prev := paginate(users, p, func(listedUser db.ListUsersRow) pageCursor {
+		return pageCursor{ID: listedUser.ID}
+	})
+
 	// We won't return user's sensitive data
-	rsp := listUserResponse(users)
-	ctx.JSON(http.StatusOK, rsp)
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      listUserResponse(users),
+		NextCursor: next,
+		PrevCursor: prev,
+	})
 }
 
+// Unknown users and wrong passwords get the same error, so usernames can't be found out by logging in
+var (
+	errInvalidCredentials   = errors.New("incorrect username or password")
+	errTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+	errUserSuspended        = errors.New("user is suspended")
+)
+
+// Hash of a random password, compared against for unknown users so they take as long as wrong passwords
+const dummyHashPass = "$2a$10$rxppPVevXn/WMiKFLObR2ep0lR2VR3ajhiHPLkakUY.m/0CsXJ13."
+
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required,min=6"`
 }
 
 type loginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 func (server *Server) loginUser(ctx *gin.Context) {
@@ -183,10 +257,16 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	// Locked out clients are turned away before checking any password
+	if !server.allowLogin(ctx, req.Username) {
+		return
+	}
+
 	user, err := server.store.GetUserByUsername(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			util.CheckPassword(req.Password, dummyHashPass)
+			server.failLogin(ctx, req.Username, errInvalidCredentials)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -195,12 +275,43 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashPass)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		// Failed attempts are recorded as well, so the user can find out about them
+		_, err = server.store.RecordLoginTx(ctx, server.newLoginEvent(ctx, user, false))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		server.failLogin(ctx, req.Username, errInvalidCredentials)
+		return
+	}
+
+	// Users with two-factor authentication must still send a code before the session is started
+	if user.IsTotpEnabled {
+		server.requireMFA(ctx, user)
+		return
+	}
+
+	server.startSession(ctx, user)
+}
+
+// startSession logs the user in once it's authenticated, responding with its new tokens
+func (server *Server) startSession(ctx *gin.Context, user db.User) {
+	// Suspensions are only told once authenticated, so they aren't revealed to anyone guessing passwords
+	if user.SuspendedAt.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(errUserSuspended))
+		return
+	}
+
+	err := server.loginLimiter.succeed(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	accessToken, err := server.tokenMaker.CreateToken(
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
+		token.TokenTypeAccessToken,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -208,9 +319,378 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeRefreshToken,
+		server.config.RefreshTokenDuration,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The session is identified by the refresh token ID, so it can be checked when renewing the access token
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The user's last login is updated along with the login history
+	login, err := server.store.RecordLoginTx(ctx, server.newLoginEvent(ctx, user, true))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	user = login.User
+
 	rsp := loginUserResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+// allowLogin checks if the client may try to log into the account, writing the error response when it's locked out
+func (server *Server) allowLogin(ctx *gin.Context, username string) bool {
+	wait, err := server.loginLimiter.retryAfter(ctx, username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+		return false
+	}
+	return true
+}
+
+// failLogin backs off the failed login, telling the client when it can try again once it's locked out
+func (server *Server) failLogin(ctx *gin.Context, username string, loginErr error) {
+	wait, err := server.loginLimiter.fail(ctx, username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if wait > 0 {
+		setRetryAfter(ctx, wait)
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(errTooManyLoginAttempts))
+		return
+	}
+	ctx.JSON(http.StatusUnauthorized, errorResponse(loginErr))
+}
+
+// checkCurrentPassword makes sure the user sent its password before a sensitive change, writing the error response when it didn't
+// Failures are backed off like logins, so a stolen access token can't be used to guess the password
+func (server *Server) checkCurrentPassword(ctx *gin.Context, user db.User, password string) bool {
+	if !server.allowLogin(ctx, user.Username) {
+		return false
+	}
+	err := util.CheckPassword(password, user.HashPass)
+	if err != nil {
+		server.failLogin(ctx, user.Username, err)
+		return false
+	}
+	return true
+}
+
+// newLoginEvent describes a login attempt of the user from the request's client
+func (server *Server) newLoginEvent(ctx *gin.Context, user db.User, succeeded bool) db.CreateLoginEventParams {
+	return db.CreateLoginEventParams{
+		UserID:    user.ID,
+		Succeeded: succeeded,
+		ClientIp:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
+
+type loginEventResponse struct {
+	ID        int64     `json:"id"`
+	Succeeded bool      `json:"succeeded"`
+	ClientIp  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listLoginEventRequest struct {
+	pageRequest
+}
+
+// listLoginEvent lists the login attempts made on the user's account, from the most recent ones
+func (server *Server) listLoginEvent(ctx *gin.Context) {
+	var req listLoginEventRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	p, err := server.newPage(req.pageRequest)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var events []db.LoginEvent
+	if p.Backward {
+		events, err = server.store.ListLoginEventsBefore(ctx, db.ListLoginEventsBeforeParams{
+			UserID:   user.ID,
+			CursorID: p.Cursor.ID,
+			Limit:    p.limit(),
+		})
+	} else {
+		events, err = server.store.ListLoginEvents(ctx, db.ListLoginEventsParams{
+			UserID:   user.ID,
+			CursorID: p.Cursor.nullID(),
+			Limit:    p.limit(),
+		})
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	events, next, prev := paginate(events, p, func(event db.LoginEvent) pageCursor {
+		return pageCursor{ID: event.ID}
+	})
+
+	items := []loginEventResponse{}
+	for _, event := range events {
+		items = append(items, loginEventResponse{
+			ID:        event.ID,
+			Succeeded: event.Succeeded,
+			ClientIp:  event.ClientIp,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	ctx.JSON(http.StatusOK, listResponse{
+		Items:      items,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+type logoutUserRequest struct {
+	// The session of the refresh token issued along with the access token, which must also be blocked
+	SessionID string `json:"session_id" binding:"required,uuid"`
+}
+
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Blocking the session, so its refresh token can't renew access tokens anymore
+	err = server.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       uuid.MustParse(req.SessionID),
+		Username: user.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Revoking the access token used on the request
+	err = server.revocations.revoke(ctx, authPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Closing the real-time connections opened with the revoked token
+	server.hub.disconnect(user.ID, authPayload.ID)
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (server *Server) logoutAllUser(ctx *gin.Context) {
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.endAllSessions(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// endAllSessions logs the user out everywhere
+func (server *Server) endAllSessions(ctx *gin.Context, user db.User) error {
+	// Revoking every token issued to the user until now
+	err := server.revocations.revokeAll(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+
+	// Blocking every session, so no refresh token can renew access tokens anymore
+	err = server.store.BlockUserSessions(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+
+	// Closing every real-time connection of the user
+	server.hub.disconnect(user.ID, uuid.Nil)
+	return nil
+}
+
+func (server *Server) getCurrentUser(ctx *gin.Context) {
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Unlike other users, the authenticated one can see all of its own data
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// Only the provided fields are updated
+type updateUserRequest struct {
+	FullName  *string `json:"full_name" binding:"omitempty,min=1,max=100"`
+	Email     *string `json:"email" binding:"omitempty,email"`
+	AvatarUrl *string `json:"avatar_url" binding:"omitempty,url"`
+}
+
+func (server *Server) updateUser(ctx *gin.Context) {
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The username can't be changed, since tokens and sessions refer to it
+	arg := db.UpdateUserParams{
+		ID:        user.ID,
+		FullName:  user.FullName,
+		Username:  user.Username,
+		Email:     user.Email,
+		AvatarUrl: user.AvatarUrl,
+	}
+	if req.FullName != nil {
+		arg.FullName = *req.FullName
+	}
+	if req.Email != nil {
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+	}
+	if req.AvatarUrl != nil {
+		arg.AvatarUrl = sql.NullString{String: *req.AvatarUrl, Valid: true}
+	}
+
+	updatedUser, err := server.store.UpdateUser(ctx, arg)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(updatedUser))
+}
+
+type changeUserPasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+func (server *Server) changeUserPassword(ctx *gin.Context) {
+	var req changeUserPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// A stolen access token alone isn't enough to take over the account
+	if !server.checkCurrentPassword(ctx, user, req.CurrentPassword) {
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// The change time comes from the same clock as the tokens' issue times, which it's compared against
+	user, err = server.store.ChangeUserPassword(ctx, db.ChangeUserPasswordParams{
+		ID:                user.ID,
+		HashPass:          hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Every session started with the old password is ended, so the user must log in again
+	err = server.endAllSessions(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -2,12 +2,16 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -15,6 +19,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	mockdb "github.com/renatomh/api-simplechat/db/mock"
 	db "github.com/renatomh/api-simplechat/db/sqlc"
 	"github.com/renatomh/api-simplechat/token"
@@ -23,15 +29,15 @@ import (
 )
 
 // Struct to hold fields for custom matcher
-type eqCreateUserParamsMatcher struct {
+type eqCreateUserTxParamsMatcher struct {
 	arg      db.CreateUserParams
 	password string
 }
 
 // Matches checks if provided args match, and returns the result
-func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
+func (e eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
 	// Checking if args are present in the interface
-	arg, ok := x.(db.CreateUserParams)
+	arg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
@@ -42,28 +48,67 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
+	// Every user gets a verification code, which is only used when it has an email
+	if arg.VerifyCodeHash == "" {
+		return false
+	}
+
 	e.arg.HashPass = arg.HashPass
-	return reflect.DeepEqual(e.arg, arg)
+	return reflect.DeepEqual(e.arg, arg.CreateUserParams)
 }
 
 // String defines the matcher message
-func (e eqCreateUserParamsMatcher) String() string {
+func (e eqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
 // Matcher for the provided and hashed password
-func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
-	return eqCreateUserParamsMatcher{arg, password}
+func EqCreateUserTxParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserTxParamsMatcher{arg, password}
+}
+
+// createUserTx mimics the user creation transaction, creating the verification for users with an email
+func createUserTx(user db.User) func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	return func(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+		result := db.CreateUserTxResult{User: user}
+		if user.Email.Valid {
+			result.EmailVerification = db.EmailVerification{
+				ID:        1,
+				UserID:    user.ID,
+				Email:     user.Email.String,
+				CodeHash:  arg.VerifyCodeHash,
+				ExpiresAt: arg.VerifyExpiresAt,
+			}
+		}
+		return result, nil
+	}
+}
+
+// readMails reads the emails written by the test server's mailer
+func readMails(t *testing.T, server *Server) []string {
+	files, err := filepath.Glob(filepath.Join(server.config.MailerOutputPath, "*.eml"))
+	require.NoError(t, err)
+
+	mails := []string{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		mails = append(mails, string(content))
+	}
+	return mails
 }
 
 func TestCreateUserAPI(t *testing.T) {
 	user, password := randomUser(t)
+	userWithoutEmail := user
+	userWithoutEmail.Email = sql.NullString{}
 
 	testCases := []struct {
 		name          string
 		body          gin.H
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recoder *httptest.ResponseRecorder)
+		checkMails    func(mails []string)
 	}{
 		{
 			name: "OK",
@@ -80,14 +125,85 @@ func TestCreateUserAPI(t *testing.T) {
 					Email:    user.Email,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
 					Times(1).
-					Return(user, nil)
+					DoAndReturn(createUserTx(user))
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchUser(t, recorder.Body, user)
 			},
+			checkMails: func(mails []string) {
+				require.Len(t, mails, 1)
+				require.Contains(t, mails[0], "To: "+user.Email.String)
+				require.Contains(t, mails[0], "http://localhost:8080/users/verify_email?code=")
+			},
+		},
+		{
+			name: "NoEmail",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserParams{
+					Username: user.Username,
+					FullName: user.FullName,
+				}
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
+					Times(1).
+					DoAndReturn(createUserTx(userWithoutEmail))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, userWithoutEmail)
+			},
+			checkMails: func(mails []string) {
+				require.Empty(t, mails)
+			},
+		},
+		{
+			name: "DuplicateUsername",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email.String,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateUserTxResult{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+			checkMails: func(mails []string) {
+				require.Empty(t, mails)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+			checkMails: func(mails []string) {
+				require.Empty(t, mails)
+			},
 		},
 	}
 
@@ -123,10 +239,43 @@ func TestCreateUserAPI(t *testing.T) {
 
 			// Checking the response
 			tc.checkResponse(recorder)
+			tc.checkMails(readMails(t, server))
 		})
 	}
 }
 
+func TestCreateUserAPIMailerFailure(t *testing.T) {
+	user, password := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateUserTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(createUserTx(user))
+
+	server := newTestServer(t, store)
+	server.mailer = failingMailer{}
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"username":  user.Username,
+		"password":  password,
+		"full_name": user.FullName,
+		"email":     user.Email.String,
+	})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// The user is created anyway, since the email can be sent again
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchUser(t, recorder.Body, user)
+}
+
 func TestGetUserAPI(t *testing.T) {
 	// Creating a random user
 	user, _ := randomUser(t)
@@ -257,6 +406,7 @@ func TestGetUserAPI(t *testing.T) {
 
 			// Building stubs for the test case
 			tc.buildStubs(store)
+			stubTokenRevocation(store)
 
 			// Starting test server and sending request
 			server := newTestServer(t, store)
@@ -298,9 +448,27 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+				loggedUser := user
+				loggedUser.LastLoginAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					RecordLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.RecordLoginTxResult, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.True(t, arg.Succeeded)
+						return db.RecordLoginTxResult{User: loggedUser}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.False(t, rsp.User.LastLoginAt.IsZero())
 			},
 		},
 		{
@@ -314,9 +482,14 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUserByUsername(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().
+					RecordLoginTx(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				// Unknown users can't be told apart from wrong passwords
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
 			},
 		},
 		{
@@ -330,9 +503,21 @@ func TestLoginUserAPI(t *testing.T) {
 					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RecordLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateLoginEventParams) (db.RecordLoginTxResult, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.False(t, arg.Succeeded)
+						return db.RecordLoginTxResult{}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
 			},
 		},
 		{
@@ -380,6 +565,326 @@ func TestLoginUserAPI(t *testing.T) {
 	}
 }
 
+func TestLoginUserAPIBackoff(t *testing.T) {
+	user, password := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		AnyTimes().
+		Return(user, nil)
+	store.EXPECT().
+		RecordLoginTx(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		Return(db.RecordLoginTxResult{User: user}, nil)
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		AnyTimes()
+
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Not(user.Username)).
+		AnyTimes().
+		Return(db.User{}, sql.ErrNoRows)
+
+	server := newTestServer(t, store)
+	loginAs := func(username, password, clientIP string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{"username": username, "password": password})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+		require.NoError(t, err)
+		request.RemoteAddr = clientIP + ":1234"
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	login := func(password, clientIP string) *httptest.ResponseRecorder {
+		return loginAs(user.Username, password, clientIP)
+	}
+
+	// Successful logins forget the account failures
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusUnauthorized, login("incorrect", "10.0.0.1").Code)
+	}
+	require.Equal(t, http.StatusOK, login(password, "10.0.0.9").Code)
+
+	// The test server allows 3 failures per account before backing off for a minute
+	for i := 0; i < 2; i++ {
+		recorder := login("incorrect", "10.0.0.1")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		require.Empty(t, recorder.Header().Get("Retry-After"))
+	}
+	// The failure locking the account out is already turned away, like the attempts after it
+	recorder := login("incorrect", "10.0.0.2")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+	requireBodyMatchError(t, recorder.Body, errTooManyLoginAttempts)
+
+	// While locked out, even the right password is turned away, from any client
+	recorder = login(password, "10.0.0.3")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+	requireBodyMatchError(t, recorder.Body, errTooManyLoginAttempts)
+
+	// Clients are backed off as well after failing on 5 accounts, even unknown ones
+	for i := 0; i < 4; i++ {
+		recorder = loginAs(util.RandomUsername(), "incorrect", "10.0.0.4")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+	for i := 0; i < 2; i++ {
+		recorder = loginAs(util.RandomUsername(), "incorrect", "10.0.0.4")
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	}
+}
+
+func TestPasswordCheckBackoff(t *testing.T) {
+	user, password := randomTOTPUser(t)
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   func(password string) gin.H
+	}{
+		{
+			name:   "ChangePassword",
+			method: http.MethodPut,
+			path:   "/users/me/password",
+			body: func(password string) gin.H {
+				return gin.H{"current_password": password, "new_password": "new-secret"}
+			},
+		},
+		{
+			name:   "DisableTOTP",
+			method: http.MethodDelete,
+			path:   "/users/me/totp",
+			body: func(password string) gin.H {
+				return gin.H{"password": password, "code": "123456"}
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			store.EXPECT().
+				ChangeUserPassword(gomock.Any(), gomock.Any()).
+				Times(0)
+			store.EXPECT().
+				DisableTOTPTx(gomock.Any(), gomock.Any()).
+				Times(0)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			send := func(password string) *httptest.ResponseRecorder {
+				data, err := json.Marshal(tc.body(password))
+				require.NoError(t, err)
+				request, err := http.NewRequest(tc.method, tc.path, bytes.NewReader(data))
+				require.NoError(t, err)
+
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				recorder := httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+				return recorder
+			}
+
+			// A stolen access token can't be used to guess the password any faster than logging in
+			for i := 0; i < 2; i++ {
+				require.Equal(t, http.StatusUnauthorized, send("incorrect").Code)
+			}
+			recorder := send("incorrect")
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+			recorder = send(password)
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			requireBodyMatchError(t, recorder.Body, errTooManyLoginAttempts)
+		})
+	}
+}
+
+func TestLogoutUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	sessionID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		path          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Logout",
+			path: "/users/logout",
+			body: gin.H{"session_id": sessionID.String()},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(db.BlockSessionParams{ID: sessionID, Username: user.Username})).
+					Times(1)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					DeleteExpiredRevokedTokens(gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "MissingSessionID",
+			path: "/users/logout",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "BlockSessionError",
+			path: "/users/logout",
+			body: gin.H{"session_id": sessionID.String()},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidSessionID",
+			path: "/users/logout",
+			body: gin.H{"session_id": "invalid"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "LogoutAll",
+			path: "/users/logout_all",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RevokeUserTokens(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			path: "/users/logout_all",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RevokeUserTokens(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			// The revocation is checked only once, afterwards the cached decision is used
+			store.EXPECT().
+				GetTokenRevocation(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.GetTokenRevocationRow{}, nil)
+
+			server := newTestServer(t, store)
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+
+			// After logging out, the same token must be rejected right away
+			if recorder.Code == http.StatusNoContent {
+				recorder = httptest.NewRecorder()
+				retry, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewReader(data))
+				require.NoError(t, err)
+
+				retry.Header = request.Header
+				server.router.ServeHTTP(recorder, retry)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			}
+		})
+	}
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
 	// Retrieving a random user from the local functions
 	username := util.RandomUsername()
@@ -400,6 +905,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 			Valid:  true,
 		},
 		HashPass: hashpass,
+		Role:     util.UserRole,
 	}
 
 	// Returning both user and password (as defined in the function signature)
@@ -425,3 +931,343 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.AvatarUrl.String, gotUser.AvatarUrl)
 	require.Equal(t, user.LastLoginAt.Time, gotUser.LastLoginAt)
 }
+
+// requireBodyMatchError checks if the response body carries the expected error
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, expected error) {
+	var rsp struct {
+		Error string `json:"error"`
+	}
+	err := json.Unmarshal(body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Equal(t, expected.Error(), rsp.Error)
+}
+
+func TestUpdateUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	newFullName := "New Name"
+	newEmail := util.RandomUsername() + "@example.com"
+
+	testCases := []struct {
+		name          string
+		method        string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "GetCurrentUser",
+			method: http.MethodGet,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, rsp.Username)
+				require.Equal(t, user.Email.String, rsp.Email)
+			},
+		},
+		{
+			name:   "PartialUpdate",
+			method: http.MethodPatch,
+			body:   gin.H{"full_name": newFullName},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateUserParams{
+					ID:        user.ID,
+					FullName:  newFullName,
+					Username:  user.Username,
+					Email:     user.Email,
+					AvatarUrl: user.AvatarUrl,
+				}
+				updatedUser := user
+				updatedUser.FullName = newFullName
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updatedUser, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, newFullName, rsp.FullName)
+				require.Equal(t, user.Email.String, rsp.Email)
+			},
+		},
+		{
+			name:   "EmailInUse",
+			method: http.MethodPatch,
+			body:   gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidEmail",
+			method: http.MethodPatch,
+			body:   gin.H{"email": "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidAvatarUrl",
+			method: http.MethodPatch,
+			body:   gin.H{"avatar_url": "not a url"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(tc.method, "/users/me", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestChangeUserPasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"current_password": password, "new_password": "new-secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ChangeUserPasswordParams) (db.User, error) {
+						require.Equal(t, user.ID, arg.ID)
+						require.NoError(t, util.CheckPassword("new-secret", arg.HashPass))
+						require.WithinDuration(t, time.Now(), arg.PasswordChangedAt, time.Second)
+						return user, nil
+					})
+				store.EXPECT().
+					RevokeUserTokens(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "WrongCurrentPassword",
+			body: gin.H{"current_password": "wrong-password", "new_password": "new-secret"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeUserPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ShortNewPassword",
+			body: gin.H{"current_password": password, "new_password": "123"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeUserPassword(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPut, "/users/me/password", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListLoginEventAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	events := []db.LoginEvent{}
+	for i := 3; i > 0; i-- {
+		events = append(events, db.LoginEvent{
+			ID:        int64(i),
+			UserID:    user.ID,
+			Succeeded: i != 2,
+			ClientIp:  "127.0.0.1",
+			UserAgent: util.RandomString(10),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"page_size": {"2"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLoginEvents(gomock.Any(), gomock.Eq(db.ListLoginEventsParams{UserID: user.ID, Limit: 3})).
+					Times(1).
+					Return(events, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items      []loginEventResponse `json:"items"`
+					NextCursor string               `json:"next_cursor"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 2)
+				require.True(t, rsp.Items[0].Succeeded)
+				require.False(t, rsp.Items[1].Succeeded)
+				require.Equal(t, events[0].UserAgent, rsp.Items[0].UserAgent)
+				require.Equal(t, pageCursor{ID: 2}.encode(), rsp.NextCursor)
+			},
+		},
+		{
+			name:  "Before",
+			query: url.Values{"before": {pageCursor{ID: 1}.encode()}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLoginEventsBefore(gomock.Any(), gomock.Eq(db.ListLoginEventsBeforeParams{UserID: user.ID, CursorID: 1, Limit: 6})).
+					Times(1).
+					Return([]db.LoginEvent{events[1], events[0]}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Items []loginEventResponse `json:"items"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.Items, 2)
+				require.Equal(t, events[0].ID, rsp.Items[0].ID)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListLoginEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginEvent{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/me/logins?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
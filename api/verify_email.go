@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/mail"
+	"github.com/renatomh/api-simplechat/token"
+	"github.com/renatomh/api-simplechat/util"
+)
+
+// Amount of random bytes on the secret codes sent by email
+const secretCodeSize = 32
+
+var errInvalidVerifyCode = errors.New("verification code is invalid, expired or already used")
+
+// newVerifyCode generates the secret code for an email verification, returning it along with its hash
+func newVerifyCode() (string, string, error) {
+	code, err := util.RandomSecret(secretCodeSize)
+	if err != nil {
+		return "", "", err
+	}
+	return code, util.HashSecret(code), nil
+}
+
+// sendVerifyEmail sends the link for the user to verify its email, which is the only place the secret code goes to
+func (server *Server) sendVerifyEmail(ctx context.Context, user db.User, verification db.EmailVerification, code string) error {
+	link := fmt.Sprintf(
+		"%s/users/verify_email?%s",
+		server.config.AppBaseURL,
+		url.Values{"id": {fmt.Sprint(verification.ID)}, "code": {code}}.Encode(),
+	)
+
+	return server.mailer.Send(ctx, mail.Message{
+		To:      verification.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\r\n\r\nPlease verify your email by opening the link below before %s:\r\n\r\n%s\r\n",
+			user.FullName,
+			verification.ExpiresAt.Format(time.RFC1123),
+			link,
+		),
+	})
+}
+
+type verifyEmailRequest struct {
+	ID   int64  `form:"id" binding:"required,min=1"`
+	Code string `form:"code" binding:"required"`
+}
+
+// verifyEmail uses up the code sent by email, so the link works without the user being logged in
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.UseEmailVerificationParams{
+		ID:       req.ID,
+		CodeHash: util.HashSecret(req.Code),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyCode))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+// resendVerifyEmail sends a new verification for the user's current email, such as after changing it
+func (server *Server) resendVerifyEmail(ctx *gin.Context) {
+	// Getting the user which made the request
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := server.store.GetUserByUsername(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.Email.Valid {
+		ctx.JSON(http.StatusForbidden, errorResponse(errors.New("user has no email to verify")))
+		return
+	}
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusForbidden, errorResponse(errors.New("email is already verified")))
+		return
+	}
+
+	code, codeHash, err := newVerifyCode()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	verification, err := server.store.CreateEmailVerification(ctx, db.CreateEmailVerificationParams{
+		UserID:    user.ID,
+		Email:     user.Email.String,
+		CodeHash:  codeHash,
+		ExpiresAt: time.Now().Add(server.config.EmailVerificationDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.sendVerifyEmail(ctx, user, verification, code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	verifiedUser := user
+	verifiedUser.IsEmailVerified = true
+	code, codeHash, err := newVerifyCode()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"id": {"1"}, "code": {code}},
+			buildStubs: func(store *mockdb.MockStore) {
+				// Only the hash of the code is looked up
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(db.UseEmailVerificationParams{ID: 1, CodeHash: codeHash})).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: verifiedUser}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, rsp.Username)
+				require.True(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name:  "InvalidCode",
+			query: url.Values{"id": {"1"}, "code": {"invalid"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidVerifyCode)
+			},
+		},
+		{
+			name:  "MissingCode",
+			query: url.Values{"id": {"1"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"id": {"1"}, "code": {code}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// The link sent by email is opened without logging in
+			request, err := http.NewRequest(http.MethodGet, "/users/verify_email?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	verifiedUser := user
+	verifiedUser.IsEmailVerified = true
+	userWithoutEmail := user
+	userWithoutEmail.Email = sql.NullString{}
+
+	var sentCodeHash string
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string)
+	}{
+		{
+			name: "OK",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+						require.Equal(t, user.ID, arg.UserID)
+						require.Equal(t, user.Email.String, arg.Email)
+						require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Second)
+						sentCodeHash = arg.CodeHash
+						return db.EmailVerification{ID: 7, UserID: user.ID, Email: arg.Email, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+				require.Len(t, mails, 1)
+
+				// The code on the link is the one whose hash was stored
+				match := regexp.MustCompile(`verify_email\?code=([\w-]+)&id=7`).FindStringSubmatch(mails[0])
+				require.Len(t, match, 2)
+				require.Equal(t, sentCodeHash, util.HashSecret(match[1]))
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			user: verifiedUser,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t, mails)
+			},
+		},
+		{
+			name: "NoEmail",
+			user: userWithoutEmail,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateEmailVerification(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, mails []string) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Empty(t, mails)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUserByUsername(gomock.Any(), gomock.Eq(tc.user.Username)).
+				AnyTimes().
+				Return(tc.user, nil)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/verify_email", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, readMails(t, server))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/renatomh/api-simplechat/token"
+)
+
+// Query parameter used by clients which can't set headers on the handshake (e.g. browsers)
+const accessTokenQueryKey = "access_token"
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Clients are authenticated by the access token, not by cookies, so any origin is allowed
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsAccessToken extracts the access token from the authorization header or the query string
+func wsAccessToken(ctx *gin.Context) (string, error) {
+	authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+	if len(authorizationHeader) == 0 {
+		accessToken := ctx.Query(accessTokenQueryKey)
+		if len(accessToken) == 0 {
+			return "", errors.New("access token is not provided")
+		}
+		return accessToken, nil
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		return "", errors.New("invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return "", fmt.Errorf("unsupported authorization type %s", authorizationType)
+	}
+
+	return fields[1], nil
+}
+
+// serveWebSocket upgrades the connection and registers it on the hub to receive real-time events
+func (server *Server) serveWebSocket(ctx *gin.Context) {
+	accessToken, err := wsAccessToken(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// Refresh tokens must only be used to renew access tokens
+	if payload.Type != token.TokenTypeAccessToken {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("token is not an access token")))
+		return
+	}
+
+	// Checking if the token was revoked by logging out or changing the password
+	revoked, err := server.revocations.isRevoked(ctx, payload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if revoked {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("token has been revoked")))
+		return
+	}
+
+	// Getting the user which made the request
+	user, err := server.store.GetUserByUsername(ctx, payload.Username)
+	if err != nil {
+		// The token might belong to a user which no longer exists
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// From here on, errors are reported by the upgrader itself
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+
+	client := &wsClient{
+		hub:       server.hub,
+		conn:      conn,
+		userID:    user.ID,
+		tokenID:   payload.ID,
+		expiredAt: payload.ExpiredAt,
+		send:      make(chan []byte, clientSendBufferSize),
+		done:      make(chan struct{}),
+	}
+	server.hub.register(client)
+
+	go client.writePump()
+	go client.readPump()
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWebSocket connects to the websocket endpoint of a test HTTP server
+func dialWebSocket(httpServer *httptest.Server, query string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws" + query
+	return websocket.DefaultDialer.Dial(url, header)
+}
+
+// bearerHeader creates an authorization header for the provided access token
+func bearerHeader(accessToken string) http.Header {
+	header := http.Header{}
+	header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+	return header
+}
+
+// waitForSessions waits until the hub has the expected number of sessions for the user
+func waitForSessions(t *testing.T, server *Server, userID int64, n int) {
+	require.Eventually(t, func() bool {
+		return server.hub.sessionCount(userID) == n
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebSocketDeliversEvents(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenRevocation(store)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(2).
+		Return(user, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, token.TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// Opening two sessions for the same user
+	conns := []*websocket.Conn{}
+	for i := 0; i < 2; i++ {
+		conn, _, err := dialWebSocket(httpServer, "", bearerHeader(accessToken))
+		require.NoError(t, err)
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	waitForSessions(t, server, user.ID, 2)
+
+	message := db.Message{
+		ID:         util.RandomInt(1, 1000),
+		ChatID:     util.RandomInt(1, 1000),
+		FromUserID: util.RandomInt(1, 1000),
+		ToUserID:   sql.NullInt64{Int64: user.ID, Valid: true},
+		Body:       util.RandomString(12),
+	}
+	server.hub.publish(eventMessageCreated, message, user.ID)
+
+	// Every session of the user must receive the event
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		var event struct {
+			Type string     `json:"type"`
+			Data db.Message `json:"data"`
+		}
+		err = json.Unmarshal(data, &event)
+		require.NoError(t, err)
+		require.Equal(t, eventMessageCreated, event.Type)
+		require.Equal(t, message.ID, event.Data.ID)
+		require.Equal(t, message.Body, event.Data.Body)
+	}
+
+	// Closed sessions must be removed from the hub
+	for _, conn := range conns {
+		conn.Close()
+	}
+	waitForSessions(t, server, user.ID, 0)
+}
+
+func TestWebSocketClosesOnTokenExpiry(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenRevocation(store)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	server := newTestServer(t, store)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, token.TokenTypeAccessToken, 500*time.Millisecond)
+	require.NoError(t, err)
+
+	conn, _, err := dialWebSocket(httpServer, "", bearerHeader(accessToken))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The server must close the connection once the token expires
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	require.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation))
+	waitForSessions(t, server, user.ID, 0)
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		setupAuth  func(t *testing.T, server *Server) (http.Header, string)
+		buildStubs func(store *mockdb.MockStore)
+		wantStatus int
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, server *Server) (http.Header, string) {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, token.TokenTypeAccessToken, time.Minute)
+				require.NoError(t, err)
+				return bearerHeader(accessToken), ""
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			wantStatus: http.StatusSwitchingProtocols,
+		},
+		{
+			name: "QueryToken",
+			setupAuth: func(t *testing.T, server *Server) (http.Header, string) {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, token.TokenTypeAccessToken, time.Minute)
+				require.NoError(t, err)
+				return nil, "?" + accessTokenQueryKey + "=" + accessToken
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			wantStatus: http.StatusSwitchingProtocols,
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, server *Server) (http.Header, string) {
+				return nil, ""
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, server *Server) (http.Header, string) {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, util.UserRole, token.TokenTypeAccessToken, -time.Minute)
+				require.NoError(t, err)
+				return bearerHeader(accessToken), ""
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenRevocation(store)
+
+			server := newTestServer(t, store)
+			httpServer := httptest.NewServer(server.router)
+			defer httpServer.Close()
+
+			header, query := tc.setupAuth(t, server)
+			conn, rsp, _ := dialWebSocket(httpServer, query, header)
+			if conn != nil {
+				defer conn.Close()
+			}
+			require.NotNil(t, rsp)
+			require.Equal(t, tc.wantStatus, rsp.StatusCode)
+		})
+	}
+}
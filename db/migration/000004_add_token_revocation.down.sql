@@ -0,0 +1,3 @@
+ALTER TABLE "users" DROP COLUMN "tokens_revoked_at";
+
+DROP TABLE IF EXISTS revoked_tokens;
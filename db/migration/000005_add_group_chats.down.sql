@@ -0,0 +1,18 @@
+-- Group chats can't be represented without the members table
+DELETE FROM "messages" WHERE "chat_id" IN (SELECT "id" FROM "chats" WHERE "is_group");
+DELETE FROM "chats" WHERE "is_group";
+
+DROP TABLE IF EXISTS chat_members;
+
+-- Revert changes to "messages" table
+COMMENT ON COLUMN "messages"."to_user_id" IS NULL;
+ALTER TABLE "messages" ALTER COLUMN "to_user_id" SET NOT NULL;
+
+-- Revert changes to "chats" table
+COMMENT ON COLUMN "chats"."from_user_id" IS 'The from/to order makes no difference here';
+COMMENT ON COLUMN "chats"."to_user_id" IS 'The from/to order makes no difference here';
+ALTER TABLE "chats" ALTER COLUMN "to_user_id" SET NOT NULL;
+ALTER TABLE "chats" ALTER COLUMN "from_user_id" SET NOT NULL;
+ALTER TABLE "chats" DROP COLUMN "created_at";
+ALTER TABLE "chats" DROP COLUMN "is_group";
+ALTER TABLE "chats" DROP COLUMN "title";
@@ -0,0 +1,37 @@
+-- Apply changes to "chats" table
+ALTER TABLE "chats" ADD COLUMN "title" varchar;
+ALTER TABLE "chats" ADD COLUMN "is_group" boolean NOT NULL DEFAULT false;
+ALTER TABLE "chats" ADD COLUMN "created_at" timestamptz NOT NULL DEFAULT (now());
+ALTER TABLE "chats" ALTER COLUMN "from_user_id" DROP NOT NULL;
+ALTER TABLE "chats" ALTER COLUMN "to_user_id" DROP NOT NULL;
+
+COMMENT ON COLUMN "chats"."from_user_id" IS 'The from/to order makes no difference here, empty for group chats';
+COMMENT ON COLUMN "chats"."to_user_id" IS 'The from/to order makes no difference here, empty for group chats';
+
+-- Apply changes to "messages" table
+ALTER TABLE "messages" ALTER COLUMN "to_user_id" DROP NOT NULL;
+
+COMMENT ON COLUMN "messages"."to_user_id" IS 'Empty for messages sent to group chats';
+
+-- Create "chat_members" table
+CREATE TABLE "chat_members" (
+  "chat_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "role" varchar NOT NULL,
+  "joined_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("chat_id", "user_id")
+);
+
+CREATE INDEX ON "chat_members" ("user_id");
+
+COMMENT ON COLUMN "chat_members"."role" IS 'owner, admin or member';
+
+ALTER TABLE "chat_members" ADD FOREIGN KEY ("chat_id") REFERENCES "chats" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "chat_members" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
+
+-- Both users of the existing direct chats become its members
+INSERT INTO "chat_members" ("chat_id", "user_id", "role")
+SELECT "id", "from_user_id", 'member' FROM "chats"
+UNION
+SELECT "id", "to_user_id", 'member' FROM "chats";
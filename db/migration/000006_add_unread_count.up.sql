@@ -0,0 +1,3 @@
+ALTER TABLE "chat_members" ADD COLUMN "unread_count" integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "chat_members"."unread_count" IS 'Messages received by the member which were not read yet';
@@ -0,0 +1,2 @@
+ALTER TABLE "chat_members" DROP COLUMN "last_read_at";
+ALTER TABLE "chat_members" DROP COLUMN "last_read_message_id";
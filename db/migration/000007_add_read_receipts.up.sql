@@ -0,0 +1,6 @@
+ALTER TABLE "chat_members" ADD COLUMN "last_read_message_id" bigint;
+ALTER TABLE "chat_members" ADD COLUMN "last_read_at" timestamptz;
+
+COMMENT ON COLUMN "chat_members"."last_read_message_id" IS 'Read cursor, every message up to this one was read by the member';
+
+ALTER TABLE "chat_members" ADD FOREIGN KEY ("last_read_message_id") REFERENCES "messages" ("id") ON DELETE SET NULL;
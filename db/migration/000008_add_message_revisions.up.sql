@@ -0,0 +1,14 @@
+ALTER TABLE "messages" ADD COLUMN "edited_at" timestamptz;
+
+CREATE TABLE "message_revisions" (
+  "id" bigserial PRIMARY KEY,
+  "message_id" bigint NOT NULL,
+  "body" varchar NOT NULL,
+  "revised_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "message_revisions" ("message_id");
+
+COMMENT ON COLUMN "message_revisions"."body" IS 'Body of the message before it was edited';
+
+ALTER TABLE "message_revisions" ADD FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON DELETE CASCADE;
@@ -0,0 +1,16 @@
+ALTER TABLE "messages" ADD COLUMN "deleted_at" timestamptz;
+
+COMMENT ON COLUMN "messages"."deleted_at" IS 'Set when the message is deleted for everyone, leaving a tombstone with an empty body';
+
+CREATE TABLE "hidden_messages" (
+  "message_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "hidden_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("message_id", "user_id")
+);
+
+COMMENT ON TABLE "hidden_messages" IS 'Messages deleted only for the user, hidden from its listings';
+
+ALTER TABLE "hidden_messages" ADD FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "hidden_messages" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id");
@@ -0,0 +1,22 @@
+CREATE TABLE "attachments" (
+  "id" bigserial PRIMARY KEY,
+  "uploader_id" bigint NOT NULL,
+  "message_id" bigint,
+  "storage_key" varchar UNIQUE NOT NULL,
+  "file_name" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "size" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "attachments" ("uploader_id");
+
+CREATE INDEX ON "attachments" ("message_id");
+
+COMMENT ON COLUMN "attachments"."message_id" IS 'Empty until the attachment is sent with a message';
+
+COMMENT ON COLUMN "attachments"."storage_key" IS 'Key of the file on the blob store';
+
+ALTER TABLE "attachments" ADD FOREIGN KEY ("uploader_id") REFERENCES "users" ("id");
+
+ALTER TABLE "attachments" ADD FOREIGN KEY ("message_id") REFERENCES "messages" ("id") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE "messages" DROP COLUMN "body_tsv";
+
+CREATE INDEX ON "messages" ("body");
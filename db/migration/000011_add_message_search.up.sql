@@ -0,0 +1,8 @@
+-- The B-tree index on the body is useless for searching and bloats on long messages
+DROP INDEX IF EXISTS "messages_body_idx";
+
+ALTER TABLE "messages" ADD COLUMN "body_tsv" tsvector NOT NULL GENERATED ALWAYS AS (to_tsvector('simple', "body")) STORED;
+
+COMMENT ON COLUMN "messages"."body_tsv" IS 'Searchable version of the body, kept up to date by the database';
+
+CREATE INDEX ON "messages" USING GIN ("body_tsv");
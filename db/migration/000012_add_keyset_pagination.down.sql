@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "messages_chat_id_sent_at_id_idx";
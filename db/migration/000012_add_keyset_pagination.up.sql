@@ -0,0 +1,2 @@
+-- Messages are paginated within a chat by their sending time, with the ID breaking ties
+CREATE INDEX ON "messages" ("chat_id", "sent_at", "id");
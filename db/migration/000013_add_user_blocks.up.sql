@@ -0,0 +1,15 @@
+CREATE TABLE "user_blocks" (
+  "blocker_id" bigint NOT NULL,
+  "blocked_id" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("blocker_id", "blocked_id"),
+  CHECK ("blocker_id" <> "blocked_id")
+);
+
+CREATE INDEX ON "user_blocks" ("blocked_id");
+
+COMMENT ON TABLE "user_blocks" IS 'A block stops any interaction between the users, in both directions';
+
+ALTER TABLE "user_blocks" ADD FOREIGN KEY ("blocker_id") REFERENCES "users" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "user_blocks" ADD FOREIGN KEY ("blocked_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+ALTER TABLE "contacts" ADD COLUMN "rejected_at" timestamptz;
+
+COMMENT ON COLUMN "contacts"."rejected_at" IS 'The rejected user must wait a while after it before requesting the contact again';
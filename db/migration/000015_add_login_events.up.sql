@@ -0,0 +1,14 @@
+CREATE TABLE "login_events" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "succeeded" boolean NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "login_events" ("user_id", "id");
+
+COMMENT ON TABLE "login_events" IS 'Successful and failed login attempts, so users can review suspicious activity';
+
+ALTER TABLE "login_events" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
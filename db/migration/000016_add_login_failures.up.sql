@@ -0,0 +1,9 @@
+CREATE TABLE "login_failures" (
+  "key" varchar PRIMARY KEY,
+  "failures" integer NOT NULL,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "login_failures" ("last_failed_at");
+
+COMMENT ON TABLE "login_failures" IS 'Recent failed logins by account or client, shared by every instance to back off brute-force attempts';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS email_verifications;
+
+ALTER TABLE "users" DROP COLUMN "is_email_verified";
@@ -0,0 +1,19 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "email_verifications" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "email" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expires_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "email_verifications" ("user_id");
+
+COMMENT ON COLUMN "email_verifications"."email" IS 'The verification only applies while the user keeps this email';
+
+COMMENT ON COLUMN "email_verifications"."code_hash" IS 'Hash of the secret code sent by email, which is never stored';
+
+ALTER TABLE "email_verifications" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expires_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "password_resets" ("user_id");
+
+COMMENT ON COLUMN "password_resets"."token_hash" IS 'Hash of the reset token sent by email, which is never stored';
+
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
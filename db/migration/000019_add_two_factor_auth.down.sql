@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE "users" DROP COLUMN "totp_last_counter";
+
+ALTER TABLE "users" DROP COLUMN "is_totp_enabled";
+
+ALTER TABLE "users" DROP COLUMN "totp_secret";
@@ -0,0 +1,23 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+
+ALTER TABLE "users" ADD COLUMN "is_totp_enabled" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "users" ADD COLUMN "totp_last_counter" bigint;
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'Only required on login once enabled, after being confirmed with a first code';
+
+COMMENT ON COLUMN "users"."totp_last_counter" IS 'Time step of the last code used, so codes can''t be replayed';
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("user_id", "code_hash");
+
+COMMENT ON TABLE "recovery_codes" IS 'One-time codes replacing the TOTP ones when the user loses its authenticator';
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE;
@@ -0,0 +1,5 @@
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "users_role_check";
+
+ALTER TABLE "users" DROP COLUMN "suspended_at";
+
+ALTER TABLE "users" DROP COLUMN "role";
@@ -0,0 +1,9 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'user';
+
+ALTER TABLE "users" ADD COLUMN "suspended_at" timestamptz;
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('user', 'admin'));
+
+COMMENT ON COLUMN "users"."role" IS 'Grants the scopes of the role to the user''s tokens';
+
+COMMENT ON COLUMN "users"."suspended_at" IS 'Suspended users can''t log in until an admin lifts the suspension';
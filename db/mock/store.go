@@ -6,9 +6,12 @@ package mockdb
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	db "github.com/renatomh/api-simplechat/db/sqlc"
 )
 
@@ -50,6 +53,79 @@ func (mr *MockStoreMockRecorder) AcceptContact(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptContact", reflect.TypeOf((*MockStore)(nil).AcceptContact), arg0, arg1)
 }
 
+// AddChatMember mocks base method.
+func (m *MockStore) AddChatMember(arg0 context.Context, arg1 db.AddChatMemberParams) (db.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddChatMember", arg0, arg1)
+	ret0, _ := ret[0].(db.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddChatMember indicates an expected call of AddChatMember.
+func (mr *MockStoreMockRecorder) AddChatMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddChatMember", reflect.TypeOf((*MockStore)(nil).AddChatMember), arg0, arg1)
+}
+
+// AttachToMessage mocks base method.
+func (m *MockStore) AttachToMessage(arg0 context.Context, arg1 db.AttachToMessageParams) ([]db.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachToMessage", arg0, arg1)
+	ret0, _ := ret[0].([]db.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachToMessage indicates an expected call of AttachToMessage.
+func (mr *MockStoreMockRecorder) AttachToMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachToMessage", reflect.TypeOf((*MockStore)(nil).AttachToMessage), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 db.BlockSessionParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// BlockUser mocks base method.
+func (m *MockStore) BlockUser(arg0 context.Context, arg1 db.BlockUserParams) (db.UserBlock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUser", arg0, arg1)
+	ret0, _ := ret[0].(db.UserBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockUser indicates an expected call of BlockUser.
+func (mr *MockStoreMockRecorder) BlockUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUser", reflect.TypeOf((*MockStore)(nil).BlockUser), arg0, arg1)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
 // ChangeUserPassword mocks base method.
 func (m *MockStore) ChangeUserPassword(arg0 context.Context, arg1 db.ChangeUserPasswordParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +156,21 @@ func (mr *MockStoreMockRecorder) CheckExistingContact(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckExistingContact", reflect.TypeOf((*MockStore)(nil).CheckExistingContact), arg0, arg1)
 }
 
+// CreateAttachment mocks base method.
+func (m *MockStore) CreateAttachment(arg0 context.Context, arg1 db.CreateAttachmentParams) (db.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAttachment", arg0, arg1)
+	ret0, _ := ret[0].(db.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAttachment indicates an expected call of CreateAttachment.
+func (mr *MockStoreMockRecorder) CreateAttachment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAttachment", reflect.TypeOf((*MockStore)(nil).CreateAttachment), arg0, arg1)
+}
+
 // CreateChat mocks base method.
 func (m *MockStore) CreateChat(arg0 context.Context, arg1 db.CreateChatParams) (db.Chat, error) {
 	m.ctrl.T.Helper()
@@ -110,6 +201,81 @@ func (mr *MockStoreMockRecorder) CreateContact(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateContact", reflect.TypeOf((*MockStore)(nil).CreateContact), arg0, arg1)
 }
 
+// CreateDirectChatTx mocks base method.
+func (m *MockStore) CreateDirectChatTx(arg0 context.Context, arg1 db.CreateChatParams) (db.CreateChatTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDirectChatTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateChatTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDirectChatTx indicates an expected call of CreateDirectChatTx.
+func (mr *MockStoreMockRecorder) CreateDirectChatTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDirectChatTx", reflect.TypeOf((*MockStore)(nil).CreateDirectChatTx), arg0, arg1)
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockStore) CreateEmailVerification(arg0 context.Context, arg1 db.CreateEmailVerificationParams) (db.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockStoreMockRecorder) CreateEmailVerification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockStore)(nil).CreateEmailVerification), arg0, arg1)
+}
+
+// CreateGroupChat mocks base method.
+func (m *MockStore) CreateGroupChat(arg0 context.Context, arg1 sql.NullString) (db.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroupChat", arg0, arg1)
+	ret0, _ := ret[0].(db.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroupChat indicates an expected call of CreateGroupChat.
+func (mr *MockStoreMockRecorder) CreateGroupChat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroupChat", reflect.TypeOf((*MockStore)(nil).CreateGroupChat), arg0, arg1)
+}
+
+// CreateGroupChatTx mocks base method.
+func (m *MockStore) CreateGroupChatTx(arg0 context.Context, arg1 db.CreateGroupChatTxParams) (db.CreateChatTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateGroupChatTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateChatTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateGroupChatTx indicates an expected call of CreateGroupChatTx.
+func (mr *MockStoreMockRecorder) CreateGroupChatTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateGroupChatTx", reflect.TypeOf((*MockStore)(nil).CreateGroupChatTx), arg0, arg1)
+}
+
+// CreateLoginEvent mocks base method.
+func (m *MockStore) CreateLoginEvent(arg0 context.Context, arg1 db.CreateLoginEventParams) (db.LoginEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginEvent", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLoginEvent indicates an expected call of CreateLoginEvent.
+func (mr *MockStoreMockRecorder) CreateLoginEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginEvent", reflect.TypeOf((*MockStore)(nil).CreateLoginEvent), arg0, arg1)
+}
+
 // CreateMessage mocks base method.
 func (m *MockStore) CreateMessage(arg0 context.Context, arg1 db.CreateMessageParams) (db.Message, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +291,66 @@ func (mr *MockStoreMockRecorder) CreateMessage(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessage", reflect.TypeOf((*MockStore)(nil).CreateMessage), arg0, arg1)
 }
 
+// CreateMessageRevision mocks base method.
+func (m *MockStore) CreateMessageRevision(arg0 context.Context, arg1 db.CreateMessageRevisionParams) (db.MessageRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMessageRevision", arg0, arg1)
+	ret0, _ := ret[0].(db.MessageRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMessageRevision indicates an expected call of CreateMessageRevision.
+func (mr *MockStoreMockRecorder) CreateMessageRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMessageRevision", reflect.TypeOf((*MockStore)(nil).CreateMessageRevision), arg0, arg1)
+}
+
+// CreatePasswordReset mocks base method.
+func (m *MockStore) CreatePasswordReset(arg0 context.Context, arg1 db.CreatePasswordResetParams) (db.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordReset indicates an expected call of CreatePasswordReset.
+func (mr *MockStoreMockRecorder) CreatePasswordReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordReset", reflect.TypeOf((*MockStore)(nil).CreatePasswordReset), arg0, arg1)
+}
+
+// CreateRecoveryCode mocks base method.
+func (m *MockStore) CreateRecoveryCode(arg0 context.Context, arg1 db.CreateRecoveryCodeParams) (db.RecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.RecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRecoveryCode indicates an expected call of CreateRecoveryCode.
+func (mr *MockStoreMockRecorder) CreateRecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecoveryCode", reflect.TypeOf((*MockStore)(nil).CreateRecoveryCode), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateUser mocks base method.
 func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -140,6 +366,21 @@ func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
 }
 
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(arg0 context.Context, arg1 db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), arg0, arg1)
+}
+
 // DeleteChat mocks base method.
 func (m *MockStore) DeleteChat(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -168,6 +409,34 @@ func (mr *MockStoreMockRecorder) DeleteContact(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteContact", reflect.TypeOf((*MockStore)(nil).DeleteContact), arg0, arg1)
 }
 
+// DeleteExpiredRevokedTokens mocks base method.
+func (m *MockStore) DeleteExpiredRevokedTokens(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRevokedTokens", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteExpiredRevokedTokens indicates an expected call of DeleteExpiredRevokedTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredRevokedTokens(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRevokedTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredRevokedTokens), arg0)
+}
+
+// DeleteLoginFailure mocks base method.
+func (m *MockStore) DeleteLoginFailure(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginFailure indicates an expected call of DeleteLoginFailure.
+func (mr *MockStoreMockRecorder) DeleteLoginFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginFailure", reflect.TypeOf((*MockStore)(nil).DeleteLoginFailure), arg0, arg1)
+}
+
 // DeleteMessage mocks base method.
 func (m *MockStore) DeleteMessage(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -182,6 +451,49 @@ func (mr *MockStoreMockRecorder) DeleteMessage(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockStore)(nil).DeleteMessage), arg0, arg1)
 }
 
+// DeleteMessageForEveryoneTx mocks base method.
+func (m *MockStore) DeleteMessageForEveryoneTx(arg0 context.Context, arg1 int64) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessageForEveryoneTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMessageForEveryoneTx indicates an expected call of DeleteMessageForEveryoneTx.
+func (mr *MockStoreMockRecorder) DeleteMessageForEveryoneTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessageForEveryoneTx", reflect.TypeOf((*MockStore)(nil).DeleteMessageForEveryoneTx), arg0, arg1)
+}
+
+// DeleteMessageRevisions mocks base method.
+func (m *MockStore) DeleteMessageRevisions(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessageRevisions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessageRevisions indicates an expected call of DeleteMessageRevisions.
+func (mr *MockStoreMockRecorder) DeleteMessageRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessageRevisions", reflect.TypeOf((*MockStore)(nil).DeleteMessageRevisions), arg0, arg1)
+}
+
+// DeleteStaleLoginFailures mocks base method.
+func (m *MockStore) DeleteStaleLoginFailures(arg0 context.Context, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteStaleLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteStaleLoginFailures indicates an expected call of DeleteStaleLoginFailures.
+func (mr *MockStoreMockRecorder) DeleteStaleLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStaleLoginFailures", reflect.TypeOf((*MockStore)(nil).DeleteStaleLoginFailures), arg0, arg1)
+}
+
 // DeleteUser mocks base method.
 func (m *MockStore) DeleteUser(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -196,184 +508,601 @@ func (mr *MockStoreMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStore)(nil).DeleteUser), arg0, arg1)
 }
 
-// GetChat mocks base method.
-func (m *MockStore) GetChat(arg0 context.Context, arg1 int64) (db.Chat, error) {
+// DeleteUserRecoveryCodes mocks base method.
+func (m *MockStore) DeleteUserRecoveryCodes(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChat", arg0, arg1)
-	ret0, _ := ret[0].(db.Chat)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "DeleteUserRecoveryCodes", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetChat indicates an expected call of GetChat.
-func (mr *MockStoreMockRecorder) GetChat(arg0, arg1 interface{}) *gomock.Call {
+// DeleteUserRecoveryCodes indicates an expected call of DeleteUserRecoveryCodes.
+func (mr *MockStoreMockRecorder) DeleteUserRecoveryCodes(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockStore)(nil).GetChat), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRecoveryCodes", reflect.TypeOf((*MockStore)(nil).DeleteUserRecoveryCodes), arg0, arg1)
 }
 
-// GetChatByUserIDs mocks base method.
-func (m *MockStore) GetChatByUserIDs(arg0 context.Context, arg1 db.GetChatByUserIDsParams) (db.Chat, error) {
+// DisableTOTPTx mocks base method.
+func (m *MockStore) DisableTOTPTx(arg0 context.Context, arg1 int64) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetChatByUserIDs", arg0, arg1)
-	ret0, _ := ret[0].(db.Chat)
+	ret := m.ctrl.Call(m, "DisableTOTPTx", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetChatByUserIDs indicates an expected call of GetChatByUserIDs.
-func (mr *MockStoreMockRecorder) GetChatByUserIDs(arg0, arg1 interface{}) *gomock.Call {
+// DisableTOTPTx indicates an expected call of DisableTOTPTx.
+func (mr *MockStoreMockRecorder) DisableTOTPTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatByUserIDs", reflect.TypeOf((*MockStore)(nil).GetChatByUserIDs), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTPTx", reflect.TypeOf((*MockStore)(nil).DisableTOTPTx), arg0, arg1)
 }
 
-// GetContact mocks base method.
-func (m *MockStore) GetContact(arg0 context.Context, arg1 int64) (db.Contact, error) {
+// DisableUserTOTP mocks base method.
+func (m *MockStore) DisableUserTOTP(arg0 context.Context, arg1 int64) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetContact", arg0, arg1)
-	ret0, _ := ret[0].(db.Contact)
+	ret := m.ctrl.Call(m, "DisableUserTOTP", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetContact indicates an expected call of GetContact.
-func (mr *MockStoreMockRecorder) GetContact(arg0, arg1 interface{}) *gomock.Call {
+// DisableUserTOTP indicates an expected call of DisableUserTOTP.
+func (mr *MockStoreMockRecorder) DisableUserTOTP(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContact", reflect.TypeOf((*MockStore)(nil).GetContact), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUserTOTP", reflect.TypeOf((*MockStore)(nil).DisableUserTOTP), arg0, arg1)
 }
 
-// GetMessage mocks base method.
-func (m *MockStore) GetMessage(arg0 context.Context, arg1 int64) (db.Message, error) {
+// EditMessageTx mocks base method.
+func (m *MockStore) EditMessageTx(arg0 context.Context, arg1 db.UpdateMessageParams) (db.EditMessageTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMessage", arg0, arg1)
-	ret0, _ := ret[0].(db.Message)
+	ret := m.ctrl.Call(m, "EditMessageTx", arg0, arg1)
+	ret0, _ := ret[0].(db.EditMessageTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetMessage indicates an expected call of GetMessage.
-func (mr *MockStoreMockRecorder) GetMessage(arg0, arg1 interface{}) *gomock.Call {
+// EditMessageTx indicates an expected call of EditMessageTx.
+func (mr *MockStoreMockRecorder) EditMessageTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockStore)(nil).GetMessage), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditMessageTx", reflect.TypeOf((*MockStore)(nil).EditMessageTx), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 int64) (db.User, error) {
+// EnableTOTPTx mocks base method.
+func (m *MockStore) EnableTOTPTx(arg0 context.Context, arg1 db.EnableTOTPTxParams) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "EnableTOTPTx", arg0, arg1)
 	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+// EnableTOTPTx indicates an expected call of EnableTOTPTx.
+func (mr *MockStoreMockRecorder) EnableTOTPTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTOTPTx", reflect.TypeOf((*MockStore)(nil).EnableTOTPTx), arg0, arg1)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+// EnableUserTOTP mocks base method.
+func (m *MockStore) EnableUserTOTP(arg0 context.Context, arg1 int64) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret := m.ctrl.Call(m, "EnableUserTOTP", arg0, arg1)
 	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+// EnableUserTOTP indicates an expected call of EnableUserTOTP.
+func (mr *MockStoreMockRecorder) EnableUserTOTP(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserTOTP", reflect.TypeOf((*MockStore)(nil).EnableUserTOTP), arg0, arg1)
 }
 
-// ListAcceptedContacts mocks base method.
-func (m *MockStore) ListAcceptedContacts(arg0 context.Context, arg1 db.ListAcceptedContactsParams) ([]db.Contact, error) {
+// ExpireUserPasswordResets mocks base method.
+func (m *MockStore) ExpireUserPasswordResets(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAcceptedContacts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Contact)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "ExpireUserPasswordResets", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ListAcceptedContacts indicates an expected call of ListAcceptedContacts.
-func (mr *MockStoreMockRecorder) ListAcceptedContacts(arg0, arg1 interface{}) *gomock.Call {
+// ExpireUserPasswordResets indicates an expected call of ExpireUserPasswordResets.
+func (mr *MockStoreMockRecorder) ExpireUserPasswordResets(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAcceptedContacts", reflect.TypeOf((*MockStore)(nil).ListAcceptedContacts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireUserPasswordResets", reflect.TypeOf((*MockStore)(nil).ExpireUserPasswordResets), arg0, arg1)
 }
 
-// ListChats mocks base method.
-func (m *MockStore) ListChats(arg0 context.Context, arg1 db.ListChatsParams) ([]db.Chat, error) {
+// GetAttachment mocks base method.
+func (m *MockStore) GetAttachment(arg0 context.Context, arg1 int64) (db.Attachment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListChats", arg0, arg1)
-	ret0, _ := ret[0].([]db.Chat)
+	ret := m.ctrl.Call(m, "GetAttachment", arg0, arg1)
+	ret0, _ := ret[0].(db.Attachment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListChats indicates an expected call of ListChats.
-func (mr *MockStoreMockRecorder) ListChats(arg0, arg1 interface{}) *gomock.Call {
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockStoreMockRecorder) GetAttachment(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChats", reflect.TypeOf((*MockStore)(nil).ListChats), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockStore)(nil).GetAttachment), arg0, arg1)
 }
 
-// ListContacts mocks base method.
-func (m *MockStore) ListContacts(arg0 context.Context, arg1 db.ListContactsParams) ([]db.Contact, error) {
+// GetChat mocks base method.
+func (m *MockStore) GetChat(arg0 context.Context, arg1 int64) (db.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListContacts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Contact)
+	ret := m.ctrl.Call(m, "GetChat", arg0, arg1)
+	ret0, _ := ret[0].(db.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListContacts indicates an expected call of ListContacts.
-func (mr *MockStoreMockRecorder) ListContacts(arg0, arg1 interface{}) *gomock.Call {
+// GetChat indicates an expected call of GetChat.
+func (mr *MockStoreMockRecorder) GetChat(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContacts", reflect.TypeOf((*MockStore)(nil).ListContacts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChat", reflect.TypeOf((*MockStore)(nil).GetChat), arg0, arg1)
 }
 
-// ListMessages mocks base method.
-func (m *MockStore) ListMessages(arg0 context.Context, arg1 db.ListMessagesParams) ([]db.Message, error) {
+// GetChatByUserIDs mocks base method.
+func (m *MockStore) GetChatByUserIDs(arg0 context.Context, arg1 db.GetChatByUserIDsParams) (db.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMessages", arg0, arg1)
-	ret0, _ := ret[0].([]db.Message)
+	ret := m.ctrl.Call(m, "GetChatByUserIDs", arg0, arg1)
+	ret0, _ := ret[0].(db.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListMessages indicates an expected call of ListMessages.
-func (mr *MockStoreMockRecorder) ListMessages(arg0, arg1 interface{}) *gomock.Call {
+// GetChatByUserIDs indicates an expected call of GetChatByUserIDs.
+func (mr *MockStoreMockRecorder) GetChatByUserIDs(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessages", reflect.TypeOf((*MockStore)(nil).ListMessages), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatByUserIDs", reflect.TypeOf((*MockStore)(nil).GetChatByUserIDs), arg0, arg1)
 }
 
-// ListPendingContacts mocks base method.
-func (m *MockStore) ListPendingContacts(arg0 context.Context, arg1 db.ListPendingContactsParams) ([]db.Contact, error) {
+// GetChatMember mocks base method.
+func (m *MockStore) GetChatMember(arg0 context.Context, arg1 db.GetChatMemberParams) (db.ChatMember, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPendingContacts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Contact)
+	ret := m.ctrl.Call(m, "GetChatMember", arg0, arg1)
+	ret0, _ := ret[0].(db.ChatMember)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListPendingContacts indicates an expected call of ListPendingContacts.
-func (mr *MockStoreMockRecorder) ListPendingContacts(arg0, arg1 interface{}) *gomock.Call {
+// GetChatMember indicates an expected call of GetChatMember.
+func (mr *MockStoreMockRecorder) GetChatMember(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingContacts", reflect.TypeOf((*MockStore)(nil).ListPendingContacts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChatMember", reflect.TypeOf((*MockStore)(nil).GetChatMember), arg0, arg1)
 }
 
-// ListRejectedContacts mocks base method.
-func (m *MockStore) ListRejectedContacts(arg0 context.Context, arg1 db.ListRejectedContactsParams) ([]db.Contact, error) {
+// GetContact mocks base method.
+func (m *MockStore) GetContact(arg0 context.Context, arg1 int64) (db.Contact, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListRejectedContacts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Contact)
+	ret := m.ctrl.Call(m, "GetContact", arg0, arg1)
+	ret0, _ := ret[0].(db.Contact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContact indicates an expected call of GetContact.
+func (mr *MockStoreMockRecorder) GetContact(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContact", reflect.TypeOf((*MockStore)(nil).GetContact), arg0, arg1)
+}
+
+// GetLastChatMessage mocks base method.
+func (m *MockStore) GetLastChatMessage(arg0 context.Context, arg1 int64) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastChatMessage", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastChatMessage indicates an expected call of GetLastChatMessage.
+func (mr *MockStoreMockRecorder) GetLastChatMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastChatMessage", reflect.TypeOf((*MockStore)(nil).GetLastChatMessage), arg0, arg1)
+}
+
+// GetLoginFailure mocks base method.
+func (m *MockStore) GetLoginFailure(arg0 context.Context, arg1 string) (db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginFailure indicates an expected call of GetLoginFailure.
+func (mr *MockStoreMockRecorder) GetLoginFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginFailure", reflect.TypeOf((*MockStore)(nil).GetLoginFailure), arg0, arg1)
+}
+
+// GetMessage mocks base method.
+func (m *MockStore) GetMessage(arg0 context.Context, arg1 int64) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessage", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessage indicates an expected call of GetMessage.
+func (mr *MockStoreMockRecorder) GetMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockStore)(nil).GetMessage), arg0, arg1)
+}
+
+// GetMessageForUpdate mocks base method.
+func (m *MockStore) GetMessageForUpdate(arg0 context.Context, arg1 int64) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMessageForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMessageForUpdate indicates an expected call of GetMessageForUpdate.
+func (mr *MockStoreMockRecorder) GetMessageForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessageForUpdate", reflect.TypeOf((*MockStore)(nil).GetMessageForUpdate), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetSystemStats mocks base method.
+func (m *MockStore) GetSystemStats(arg0 context.Context, arg1 time.Time) (db.GetSystemStatsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSystemStats", arg0, arg1)
+	ret0, _ := ret[0].(db.GetSystemStatsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListRejectedContacts indicates an expected call of ListRejectedContacts.
-func (mr *MockStoreMockRecorder) ListRejectedContacts(arg0, arg1 interface{}) *gomock.Call {
+// GetSystemStats indicates an expected call of GetSystemStats.
+func (mr *MockStoreMockRecorder) GetSystemStats(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRejectedContacts", reflect.TypeOf((*MockStore)(nil).ListRejectedContacts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSystemStats", reflect.TypeOf((*MockStore)(nil).GetSystemStats), arg0, arg1)
+}
+
+// GetTokenRevocation mocks base method.
+func (m *MockStore) GetTokenRevocation(arg0 context.Context, arg1 db.GetTokenRevocationParams) (db.GetTokenRevocationRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenRevocation", arg0, arg1)
+	ret0, _ := ret[0].(db.GetTokenRevocationRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenRevocation indicates an expected call of GetTokenRevocation.
+func (mr *MockStoreMockRecorder) GetTokenRevocation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenRevocation", reflect.TypeOf((*MockStore)(nil).GetTokenRevocation), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 int64) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), arg0, arg1)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), arg0, arg1)
+}
+
+// HideMessage mocks base method.
+func (m *MockStore) HideMessage(arg0 context.Context, arg1 db.HideMessageParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HideMessage", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// HideMessage indicates an expected call of HideMessage.
+func (mr *MockStoreMockRecorder) HideMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HideMessage", reflect.TypeOf((*MockStore)(nil).HideMessage), arg0, arg1)
+}
+
+// IncrementUnreadCounts mocks base method.
+func (m *MockStore) IncrementUnreadCounts(arg0 context.Context, arg1 db.IncrementUnreadCountsParams) ([]db.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementUnreadCounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementUnreadCounts indicates an expected call of IncrementUnreadCounts.
+func (mr *MockStoreMockRecorder) IncrementUnreadCounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementUnreadCounts", reflect.TypeOf((*MockStore)(nil).IncrementUnreadCounts), arg0, arg1)
+}
+
+// IsBlockedBetween mocks base method.
+func (m *MockStore) IsBlockedBetween(arg0 context.Context, arg1 db.IsBlockedBetweenParams) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsBlockedBetween", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsBlockedBetween indicates an expected call of IsBlockedBetween.
+func (mr *MockStoreMockRecorder) IsBlockedBetween(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBlockedBetween", reflect.TypeOf((*MockStore)(nil).IsBlockedBetween), arg0, arg1)
+}
+
+// ListAllUsers mocks base method.
+func (m *MockStore) ListAllUsers(arg0 context.Context, arg1 db.ListAllUsersParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllUsers indicates an expected call of ListAllUsers.
+func (mr *MockStoreMockRecorder) ListAllUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUsers", reflect.TypeOf((*MockStore)(nil).ListAllUsers), arg0, arg1)
+}
+
+// ListAllUsersBefore mocks base method.
+func (m *MockStore) ListAllUsersBefore(arg0 context.Context, arg1 db.ListAllUsersBeforeParams) ([]db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllUsersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllUsersBefore indicates an expected call of ListAllUsersBefore.
+func (mr *MockStoreMockRecorder) ListAllUsersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllUsersBefore", reflect.TypeOf((*MockStore)(nil).ListAllUsersBefore), arg0, arg1)
+}
+
+// ListBlockedUsers mocks base method.
+func (m *MockStore) ListBlockedUsers(arg0 context.Context, arg1 db.ListBlockedUsersParams) ([]db.ListBlockedUsersRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlockedUsers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListBlockedUsersRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlockedUsers indicates an expected call of ListBlockedUsers.
+func (mr *MockStoreMockRecorder) ListBlockedUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlockedUsers", reflect.TypeOf((*MockStore)(nil).ListBlockedUsers), arg0, arg1)
+}
+
+// ListBlockedUsersBefore mocks base method.
+func (m *MockStore) ListBlockedUsersBefore(arg0 context.Context, arg1 db.ListBlockedUsersBeforeParams) ([]db.ListBlockedUsersBeforeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBlockedUsersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListBlockedUsersBeforeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBlockedUsersBefore indicates an expected call of ListBlockedUsersBefore.
+func (mr *MockStoreMockRecorder) ListBlockedUsersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBlockedUsersBefore", reflect.TypeOf((*MockStore)(nil).ListBlockedUsersBefore), arg0, arg1)
+}
+
+// ListChatMembers mocks base method.
+func (m *MockStore) ListChatMembers(arg0 context.Context, arg1 int64) ([]db.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChatMembers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChatMembers indicates an expected call of ListChatMembers.
+func (mr *MockStoreMockRecorder) ListChatMembers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChatMembers", reflect.TypeOf((*MockStore)(nil).ListChatMembers), arg0, arg1)
+}
+
+// ListChats mocks base method.
+func (m *MockStore) ListChats(arg0 context.Context, arg1 db.ListChatsParams) ([]db.ListChatsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChats", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListChatsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChats indicates an expected call of ListChats.
+func (mr *MockStoreMockRecorder) ListChats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChats", reflect.TypeOf((*MockStore)(nil).ListChats), arg0, arg1)
+}
+
+// ListChatsBefore mocks base method.
+func (m *MockStore) ListChatsBefore(arg0 context.Context, arg1 db.ListChatsBeforeParams) ([]db.ListChatsBeforeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListChatsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListChatsBeforeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListChatsBefore indicates an expected call of ListChatsBefore.
+func (mr *MockStoreMockRecorder) ListChatsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListChatsBefore", reflect.TypeOf((*MockStore)(nil).ListChatsBefore), arg0, arg1)
+}
+
+// ListContacts mocks base method.
+func (m *MockStore) ListContacts(arg0 context.Context, arg1 db.ListContactsParams) ([]db.ListContactsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListContacts", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListContactsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListContacts indicates an expected call of ListContacts.
+func (mr *MockStoreMockRecorder) ListContacts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContacts", reflect.TypeOf((*MockStore)(nil).ListContacts), arg0, arg1)
+}
+
+// ListContactsBefore mocks base method.
+func (m *MockStore) ListContactsBefore(arg0 context.Context, arg1 db.ListContactsBeforeParams) ([]db.ListContactsBeforeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListContactsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListContactsBeforeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListContactsBefore indicates an expected call of ListContactsBefore.
+func (mr *MockStoreMockRecorder) ListContactsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListContactsBefore", reflect.TypeOf((*MockStore)(nil).ListContactsBefore), arg0, arg1)
+}
+
+// ListLoginEvents mocks base method.
+func (m *MockStore) ListLoginEvents(arg0 context.Context, arg1 db.ListLoginEventsParams) ([]db.LoginEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginEvents", arg0, arg1)
+	ret0, _ := ret[0].([]db.LoginEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginEvents indicates an expected call of ListLoginEvents.
+func (mr *MockStoreMockRecorder) ListLoginEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginEvents", reflect.TypeOf((*MockStore)(nil).ListLoginEvents), arg0, arg1)
+}
+
+// ListLoginEventsBefore mocks base method.
+func (m *MockStore) ListLoginEventsBefore(arg0 context.Context, arg1 db.ListLoginEventsBeforeParams) ([]db.LoginEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginEventsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.LoginEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginEventsBefore indicates an expected call of ListLoginEventsBefore.
+func (mr *MockStoreMockRecorder) ListLoginEventsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginEventsBefore", reflect.TypeOf((*MockStore)(nil).ListLoginEventsBefore), arg0, arg1)
+}
+
+// ListMessageRevisions mocks base method.
+func (m *MockStore) ListMessageRevisions(arg0 context.Context, arg1 int64) ([]db.MessageRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessageRevisions", arg0, arg1)
+	ret0, _ := ret[0].([]db.MessageRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessageRevisions indicates an expected call of ListMessageRevisions.
+func (mr *MockStoreMockRecorder) ListMessageRevisions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessageRevisions", reflect.TypeOf((*MockStore)(nil).ListMessageRevisions), arg0, arg1)
+}
+
+// ListMessages mocks base method.
+func (m *MockStore) ListMessages(arg0 context.Context, arg1 db.ListMessagesParams) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessages", arg0, arg1)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessages indicates an expected call of ListMessages.
+func (mr *MockStoreMockRecorder) ListMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessages", reflect.TypeOf((*MockStore)(nil).ListMessages), arg0, arg1)
+}
+
+// ListMessagesAttachments mocks base method.
+func (m *MockStore) ListMessagesAttachments(arg0 context.Context, arg1 []int64) ([]db.Attachment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessagesAttachments", arg0, arg1)
+	ret0, _ := ret[0].([]db.Attachment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessagesAttachments indicates an expected call of ListMessagesAttachments.
+func (mr *MockStoreMockRecorder) ListMessagesAttachments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessagesAttachments", reflect.TypeOf((*MockStore)(nil).ListMessagesAttachments), arg0, arg1)
+}
+
+// ListMessagesBefore mocks base method.
+func (m *MockStore) ListMessagesBefore(arg0 context.Context, arg1 db.ListMessagesBeforeParams) ([]db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMessagesBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMessagesBefore indicates an expected call of ListMessagesBefore.
+func (mr *MockStoreMockRecorder) ListMessagesBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessagesBefore", reflect.TypeOf((*MockStore)(nil).ListMessagesBefore), arg0, arg1)
 }
 
 // ListUsers mocks base method.
@@ -391,6 +1120,66 @@ func (mr *MockStoreMockRecorder) ListUsers(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStore)(nil).ListUsers), arg0, arg1)
 }
 
+// ListUsersBefore mocks base method.
+func (m *MockStore) ListUsersBefore(arg0 context.Context, arg1 db.ListUsersBeforeParams) ([]db.ListUsersBeforeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListUsersBeforeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsersBefore indicates an expected call of ListUsersBefore.
+func (mr *MockStoreMockRecorder) ListUsersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsersBefore", reflect.TypeOf((*MockStore)(nil).ListUsersBefore), arg0, arg1)
+}
+
+// MarkChatRead mocks base method.
+func (m *MockStore) MarkChatRead(arg0 context.Context, arg1 db.MarkChatReadParams) (db.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkChatRead", arg0, arg1)
+	ret0, _ := ret[0].(db.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkChatRead indicates an expected call of MarkChatRead.
+func (mr *MockStoreMockRecorder) MarkChatRead(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkChatRead", reflect.TypeOf((*MockStore)(nil).MarkChatRead), arg0, arg1)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockStore) RecordLoginFailure(arg0 context.Context, arg1 db.RecordLoginFailureParams) (db.LoginFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", arg0, arg1)
+	ret0, _ := ret[0].(db.LoginFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockStoreMockRecorder) RecordLoginFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockStore)(nil).RecordLoginFailure), arg0, arg1)
+}
+
+// RecordLoginTx mocks base method.
+func (m *MockStore) RecordLoginTx(arg0 context.Context, arg1 db.CreateLoginEventParams) (db.RecordLoginTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginTx", arg0, arg1)
+	ret0, _ := ret[0].(db.RecordLoginTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginTx indicates an expected call of RecordLoginTx.
+func (mr *MockStoreMockRecorder) RecordLoginTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginTx", reflect.TypeOf((*MockStore)(nil).RecordLoginTx), arg0, arg1)
+}
+
 // RejectContact mocks base method.
 func (m *MockStore) RejectContact(arg0 context.Context, arg1 int64) (db.Contact, error) {
 	m.ctrl.T.Helper()
@@ -406,6 +1195,213 @@ func (mr *MockStoreMockRecorder) RejectContact(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectContact", reflect.TypeOf((*MockStore)(nil).RejectContact), arg0, arg1)
 }
 
+// RemoveChatMember mocks base method.
+func (m *MockStore) RemoveChatMember(arg0 context.Context, arg1 db.RemoveChatMemberParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveChatMember", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveChatMember indicates an expected call of RemoveChatMember.
+func (mr *MockStoreMockRecorder) RemoveChatMember(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveChatMember", reflect.TypeOf((*MockStore)(nil).RemoveChatMember), arg0, arg1)
+}
+
+// RenewContactRequest mocks base method.
+func (m *MockStore) RenewContactRequest(arg0 context.Context, arg1 db.RenewContactRequestParams) (db.Contact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenewContactRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.Contact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenewContactRequest indicates an expected call of RenewContactRequest.
+func (mr *MockStoreMockRecorder) RenewContactRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenewContactRequest", reflect.TypeOf((*MockStore)(nil).RenewContactRequest), arg0, arg1)
+}
+
+// ResetPasswordTx mocks base method.
+func (m *MockStore) ResetPasswordTx(arg0 context.Context, arg1 db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPasswordTx", arg0, arg1)
+	ret0, _ := ret[0].(db.ResetPasswordTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPasswordTx indicates an expected call of ResetPasswordTx.
+func (mr *MockStoreMockRecorder) ResetPasswordTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordTx", reflect.TypeOf((*MockStore)(nil).ResetPasswordTx), arg0, arg1)
+}
+
+// RevokeToken mocks base method.
+func (m *MockStore) RevokeToken(arg0 context.Context, arg1 db.RevokeTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockStoreMockRecorder) RevokeToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockStore)(nil).RevokeToken), arg0, arg1)
+}
+
+// RevokeUserTokens mocks base method.
+func (m *MockStore) RevokeUserTokens(arg0 context.Context, arg1 db.RevokeUserTokensParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserTokens", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserTokens indicates an expected call of RevokeUserTokens.
+func (mr *MockStoreMockRecorder) RevokeUserTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserTokens", reflect.TypeOf((*MockStore)(nil).RevokeUserTokens), arg0, arg1)
+}
+
+// SearchMessages mocks base method.
+func (m *MockStore) SearchMessages(arg0 context.Context, arg1 db.SearchMessagesParams) ([]db.SearchMessagesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMessages", arg0, arg1)
+	ret0, _ := ret[0].([]db.SearchMessagesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchMessages indicates an expected call of SearchMessages.
+func (mr *MockStoreMockRecorder) SearchMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMessages", reflect.TypeOf((*MockStore)(nil).SearchMessages), arg0, arg1)
+}
+
+// SearchMessagesBefore mocks base method.
+func (m *MockStore) SearchMessagesBefore(arg0 context.Context, arg1 db.SearchMessagesBeforeParams) ([]db.SearchMessagesBeforeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchMessagesBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.SearchMessagesBeforeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchMessagesBefore indicates an expected call of SearchMessagesBefore.
+func (mr *MockStoreMockRecorder) SearchMessagesBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchMessagesBefore", reflect.TypeOf((*MockStore)(nil).SearchMessagesBefore), arg0, arg1)
+}
+
+// SendMessageTx mocks base method.
+func (m *MockStore) SendMessageTx(arg0 context.Context, arg1 db.SendMessageTxParams) (db.SendMessageTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendMessageTx", arg0, arg1)
+	ret0, _ := ret[0].(db.SendMessageTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SendMessageTx indicates an expected call of SendMessageTx.
+func (mr *MockStoreMockRecorder) SendMessageTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessageTx", reflect.TypeOf((*MockStore)(nil).SendMessageTx), arg0, arg1)
+}
+
+// SetUserTOTPSecret mocks base method.
+func (m *MockStore) SetUserTOTPSecret(arg0 context.Context, arg1 db.SetUserTOTPSecretParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserTOTPSecret", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserTOTPSecret indicates an expected call of SetUserTOTPSecret.
+func (mr *MockStoreMockRecorder) SetUserTOTPSecret(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserTOTPSecret", reflect.TypeOf((*MockStore)(nil).SetUserTOTPSecret), arg0, arg1)
+}
+
+// SuspendUser mocks base method.
+func (m *MockStore) SuspendUser(arg0 context.Context, arg1 int64) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SuspendUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SuspendUser indicates an expected call of SuspendUser.
+func (mr *MockStoreMockRecorder) SuspendUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuspendUser", reflect.TypeOf((*MockStore)(nil).SuspendUser), arg0, arg1)
+}
+
+// TombstoneMessage mocks base method.
+func (m *MockStore) TombstoneMessage(arg0 context.Context, arg1 int64) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TombstoneMessage", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TombstoneMessage indicates an expected call of TombstoneMessage.
+func (mr *MockStoreMockRecorder) TombstoneMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TombstoneMessage", reflect.TypeOf((*MockStore)(nil).TombstoneMessage), arg0, arg1)
+}
+
+// TransferChatOwnershipTx mocks base method.
+func (m *MockStore) TransferChatOwnershipTx(arg0 context.Context, arg1 db.TransferChatOwnershipTxParams) (db.TransferChatOwnershipTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferChatOwnershipTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferChatOwnershipTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferChatOwnershipTx indicates an expected call of TransferChatOwnershipTx.
+func (mr *MockStoreMockRecorder) TransferChatOwnershipTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferChatOwnershipTx", reflect.TypeOf((*MockStore)(nil).TransferChatOwnershipTx), arg0, arg1)
+}
+
+// UnblockUser mocks base method.
+func (m *MockStore) UnblockUser(arg0 context.Context, arg1 db.UnblockUserParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnblockUser", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnblockUser indicates an expected call of UnblockUser.
+func (mr *MockStoreMockRecorder) UnblockUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblockUser", reflect.TypeOf((*MockStore)(nil).UnblockUser), arg0, arg1)
+}
+
+// UnsuspendUser mocks base method.
+func (m *MockStore) UnsuspendUser(arg0 context.Context, arg1 int64) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsuspendUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnsuspendUser indicates an expected call of UnsuspendUser.
+func (mr *MockStoreMockRecorder) UnsuspendUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsuspendUser", reflect.TypeOf((*MockStore)(nil).UnsuspendUser), arg0, arg1)
+}
+
 // UpdateChat mocks base method.
 func (m *MockStore) UpdateChat(arg0 context.Context, arg1 int64) (db.Chat, error) {
 	m.ctrl.T.Helper()
@@ -421,6 +1417,36 @@ func (mr *MockStoreMockRecorder) UpdateChat(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChat", reflect.TypeOf((*MockStore)(nil).UpdateChat), arg0, arg1)
 }
 
+// UpdateChatMemberRole mocks base method.
+func (m *MockStore) UpdateChatMemberRole(arg0 context.Context, arg1 db.UpdateChatMemberRoleParams) (db.ChatMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateChatMemberRole", arg0, arg1)
+	ret0, _ := ret[0].(db.ChatMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateChatMemberRole indicates an expected call of UpdateChatMemberRole.
+func (mr *MockStoreMockRecorder) UpdateChatMemberRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateChatMemberRole", reflect.TypeOf((*MockStore)(nil).UpdateChatMemberRole), arg0, arg1)
+}
+
+// UpdateMessage mocks base method.
+func (m *MockStore) UpdateMessage(arg0 context.Context, arg1 db.UpdateMessageParams) (db.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMessage", arg0, arg1)
+	ret0, _ := ret[0].(db.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMessage indicates an expected call of UpdateMessage.
+func (mr *MockStoreMockRecorder) UpdateMessage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMessage", reflect.TypeOf((*MockStore)(nil).UpdateMessage), arg0, arg1)
+}
+
 // UpdateUser mocks base method.
 func (m *MockStore) UpdateUser(arg0 context.Context, arg1 db.UpdateUserParams) (db.User, error) {
 	m.ctrl.T.Helper()
@@ -435,3 +1461,108 @@ func (mr *MockStoreMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStore)(nil).UpdateUser), arg0, arg1)
 }
+
+// UpdateUserLastLogin mocks base method.
+func (m *MockStore) UpdateUserLastLogin(arg0 context.Context, arg1 int64) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserLastLogin", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserLastLogin indicates an expected call of UpdateUserLastLogin.
+func (mr *MockStoreMockRecorder) UpdateUserLastLogin(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserLastLogin", reflect.TypeOf((*MockStore)(nil).UpdateUserLastLogin), arg0, arg1)
+}
+
+// UseEmailVerification mocks base method.
+func (m *MockStore) UseEmailVerification(arg0 context.Context, arg1 db.UseEmailVerificationParams) (db.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseEmailVerification", arg0, arg1)
+	ret0, _ := ret[0].(db.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseEmailVerification indicates an expected call of UseEmailVerification.
+func (mr *MockStoreMockRecorder) UseEmailVerification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseEmailVerification", reflect.TypeOf((*MockStore)(nil).UseEmailVerification), arg0, arg1)
+}
+
+// UsePasswordReset mocks base method.
+func (m *MockStore) UsePasswordReset(arg0 context.Context, arg1 string) (db.PasswordReset, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UsePasswordReset", arg0, arg1)
+	ret0, _ := ret[0].(db.PasswordReset)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UsePasswordReset indicates an expected call of UsePasswordReset.
+func (mr *MockStoreMockRecorder) UsePasswordReset(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UsePasswordReset", reflect.TypeOf((*MockStore)(nil).UsePasswordReset), arg0, arg1)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockStore) UseRecoveryCode(arg0 context.Context, arg1 db.UseRecoveryCodeParams) (db.RecoveryCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1)
+	ret0, _ := ret[0].(db.RecoveryCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStoreMockRecorder) UseRecoveryCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStore)(nil).UseRecoveryCode), arg0, arg1)
+}
+
+// UseUserTOTPCounter mocks base method.
+func (m *MockStore) UseUserTOTPCounter(arg0 context.Context, arg1 db.UseUserTOTPCounterParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseUserTOTPCounter", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseUserTOTPCounter indicates an expected call of UseUserTOTPCounter.
+func (mr *MockStoreMockRecorder) UseUserTOTPCounter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseUserTOTPCounter", reflect.TypeOf((*MockStore)(nil).UseUserTOTPCounter), arg0, arg1)
+}
+
+// VerifyEmailTx mocks base method.
+func (m *MockStore) VerifyEmailTx(arg0 context.Context, arg1 db.UseEmailVerificationParams) (db.VerifyEmailTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailTx", arg0, arg1)
+	ret0, _ := ret[0].(db.VerifyEmailTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmailTx indicates an expected call of VerifyEmailTx.
+func (mr *MockStoreMockRecorder) VerifyEmailTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailTx", reflect.TypeOf((*MockStore)(nil).VerifyEmailTx), arg0, arg1)
+}
+
+// VerifyUserEmail mocks base method.
+func (m *MockStore) VerifyUserEmail(arg0 context.Context, arg1 db.VerifyUserEmailParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyUserEmail", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyUserEmail indicates an expected call of VerifyUserEmail.
+func (mr *MockStoreMockRecorder) VerifyUserEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyUserEmail", reflect.TypeOf((*MockStore)(nil).VerifyUserEmail), arg0, arg1)
+}
@@ -0,0 +1,28 @@
+-- name: CreateAttachment :one
+INSERT INTO attachments (
+  uploader_id,
+  storage_key,
+  file_name,
+  content_type,
+  size
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetAttachment :one
+SELECT * FROM attachments
+WHERE id = $1 LIMIT 1;
+
+-- name: ListMessagesAttachments :many
+SELECT * FROM attachments
+WHERE message_id = ANY(sqlc.arg(message_ids)::bigint[])
+ORDER BY id;
+
+-- name: AttachToMessage :many
+UPDATE attachments
+SET message_id = sqlc.arg(message_id)::bigint
+WHERE
+  id = ANY(sqlc.arg(ids)::bigint[]) AND
+  uploader_id = sqlc.arg(uploader_id) AND
+  message_id IS NULL
+RETURNING *;
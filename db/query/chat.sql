@@ -6,6 +6,14 @@ INSERT INTO chats (
   $1, $2
 ) RETURNING *;
 
+-- name: CreateGroupChat :one
+INSERT INTO chats (
+  title,
+  is_group
+) VALUES (
+  $1, true
+) RETURNING *;
+
 -- name: GetChat :one
 SELECT * FROM chats
 WHERE id = $1 LIMIT 1;
@@ -18,13 +26,50 @@ WHERE
 LIMIT 1;
 
 -- name: ListChats :many
-SELECT * FROM chats
-WHERE 
-  from_user_id = $1 OR
-  to_user_id = $1
-ORDER BY last_message_received_at
-LIMIT $2
-OFFSET $3;
+SELECT
+  chats.*,
+  chat_members.unread_count,
+  chat_members.last_read_message_id,
+  last_message.id AS last_message_id,
+  last_message.from_user_id AS last_message_from_user_id,
+  last_message.body AS last_message_body,
+  last_message.sent_at AS last_message_sent_at
+FROM chats
+JOIN chat_members ON chat_members.chat_id = chats.id
+LEFT JOIN messages AS last_message ON last_message.id = (
+  SELECT max(messages.id) FROM messages
+  WHERE messages.chat_id = chats.id
+)
+WHERE
+  chat_members.user_id = sqlc.arg(user_id) AND
+  -- Chats without messages are sorted last, as if their last message was the oldest possible
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  (COALESCE(chats.last_message_received_at, '-infinity'::timestamptz), chats.id) <
+  (COALESCE(sqlc.narg(cursor_last_message_received_at)::timestamptz, '-infinity'::timestamptz), sqlc.narg(cursor_id)::bigint))
+ORDER BY COALESCE(chats.last_message_received_at, '-infinity'::timestamptz) DESC, chats.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListChatsBefore :many
+SELECT
+  chats.*,
+  chat_members.unread_count,
+  chat_members.last_read_message_id,
+  last_message.id AS last_message_id,
+  last_message.from_user_id AS last_message_from_user_id,
+  last_message.body AS last_message_body,
+  last_message.sent_at AS last_message_sent_at
+FROM chats
+JOIN chat_members ON chat_members.chat_id = chats.id
+LEFT JOIN messages AS last_message ON last_message.id = (
+  SELECT max(messages.id) FROM messages
+  WHERE messages.chat_id = chats.id
+)
+WHERE
+  chat_members.user_id = sqlc.arg(user_id) AND
+  (COALESCE(chats.last_message_received_at, '-infinity'::timestamptz), chats.id) >
+  (COALESCE(sqlc.narg(cursor_last_message_received_at)::timestamptz, '-infinity'::timestamptz), sqlc.arg(cursor_id)::bigint)
+ORDER BY COALESCE(chats.last_message_received_at, '-infinity'::timestamptz), chats.id
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateChat :one
 UPDATE chats
@@ -0,0 +1,52 @@
+-- name: AddChatMember :one
+INSERT INTO chat_members (
+  chat_id,
+  user_id,
+  role
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetChatMember :one
+SELECT * FROM chat_members
+WHERE chat_id = $1 AND user_id = $2
+LIMIT 1;
+
+-- name: ListChatMembers :many
+SELECT * FROM chat_members
+WHERE chat_id = $1
+ORDER BY joined_at, user_id;
+
+-- name: UpdateChatMemberRole :one
+UPDATE chat_members
+SET role = $3
+WHERE chat_id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: RemoveChatMember :exec
+DELETE FROM chat_members
+WHERE chat_id = $1 AND user_id = $2;
+
+-- name: IncrementUnreadCounts :many
+UPDATE chat_members
+SET unread_count = unread_count + 1
+WHERE chat_id = sqlc.arg(chat_id) AND user_id <> sqlc.arg(sender_id)
+RETURNING *;
+
+-- name: MarkChatRead :one
+UPDATE chat_members
+SET
+  last_read_message_id = sqlc.arg(message_id)::bigint,
+  last_read_at = now(),
+  unread_count = (
+    SELECT count(*) FROM messages
+    WHERE
+      messages.chat_id = chat_members.chat_id AND
+      messages.id > sqlc.arg(message_id)::bigint AND
+      messages.from_user_id <> chat_members.user_id
+  )
+WHERE
+  chat_members.chat_id = sqlc.arg(chat_id) AND
+  chat_members.user_id = sqlc.arg(user_id) AND
+  (chat_members.last_read_message_id IS NULL OR chat_members.last_read_message_id < sqlc.arg(message_id)::bigint)
+RETURNING *;
@@ -19,49 +19,70 @@ WHERE
 LIMIT 1;
 
 -- name: ListContacts :many
-SELECT * FROM contacts
-WHERE 
-  from_user_id = $1 OR
-  to_user_id = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3;
-
--- name: ListPendingContacts :many
-SELECT * FROM contacts
-WHERE 
-  (from_user_id = $1 OR
-  to_user_id = $1) AND
-  status = 'Pending'
-ORDER BY id
-LIMIT $2
-OFFSET $3;
+SELECT
+  contacts.id,
+  contacts.status,
+  contacts.requested_at,
+  contacts.accepted_at,
+  contacts.rejected_at,
+  (contacts.from_user_id = sqlc.arg(user_id))::bool AS is_outgoing,
+  counterpart.id AS counterpart_id,
+  counterpart.username AS counterpart_username,
+  counterpart.full_name AS counterpart_full_name,
+  counterpart.avatar_url AS counterpart_avatar_url,
+  counterpart.last_login_at AS counterpart_last_login_at
+FROM contacts
+JOIN users AS counterpart ON counterpart.id = CASE
+  WHEN contacts.from_user_id = sqlc.arg(user_id) THEN contacts.to_user_id
+  ELSE contacts.from_user_id
+END
+WHERE
+  (contacts.from_user_id = sqlc.arg(user_id) OR
+  contacts.to_user_id = sqlc.arg(user_id)) AND
+  (sqlc.narg(status)::varchar IS NULL OR
+  contacts.status = sqlc.narg(status)::varchar) AND
+  (sqlc.narg(is_outgoing)::bool IS NULL OR
+  (contacts.from_user_id = sqlc.arg(user_id)) = sqlc.narg(is_outgoing)::bool) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  contacts.id > sqlc.narg(cursor_id)::bigint)
+ORDER BY contacts.id
+LIMIT sqlc.arg('limit');
 
--- name: ListAcceptedContacts :many
-SELECT * FROM contacts
-WHERE 
-  (from_user_id = $1 OR
-  to_user_id = $1) AND
-  status = 'Accepted'
-ORDER BY id
-LIMIT $2
-OFFSET $3;
-
--- name: ListRejectedContacts :many
-SELECT * FROM contacts
-WHERE 
-  (from_user_id = $1 OR
-  to_user_id = $1) AND
-  status = 'Rejected'
-ORDER BY id
-LIMIT $2
-OFFSET $3;
+-- name: ListContactsBefore :many
+SELECT
+  contacts.id,
+  contacts.status,
+  contacts.requested_at,
+  contacts.accepted_at,
+  contacts.rejected_at,
+  (contacts.from_user_id = sqlc.arg(user_id))::bool AS is_outgoing,
+  counterpart.id AS counterpart_id,
+  counterpart.username AS counterpart_username,
+  counterpart.full_name AS counterpart_full_name,
+  counterpart.avatar_url AS counterpart_avatar_url,
+  counterpart.last_login_at AS counterpart_last_login_at
+FROM contacts
+JOIN users AS counterpart ON counterpart.id = CASE
+  WHEN contacts.from_user_id = sqlc.arg(user_id) THEN contacts.to_user_id
+  ELSE contacts.from_user_id
+END
+WHERE
+  (contacts.from_user_id = sqlc.arg(user_id) OR
+  contacts.to_user_id = sqlc.arg(user_id)) AND
+  (sqlc.narg(status)::varchar IS NULL OR
+  contacts.status = sqlc.narg(status)::varchar) AND
+  (sqlc.narg(is_outgoing)::bool IS NULL OR
+  (contacts.from_user_id = sqlc.arg(user_id)) = sqlc.narg(is_outgoing)::bool) AND
+  contacts.id < sqlc.arg(cursor_id)::bigint
+ORDER BY contacts.id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: AcceptContact :one
 UPDATE contacts
 SET
   status = 'Accepted',
-  accepted_at = now()
+  accepted_at = now(),
+  rejected_at = NULL
 WHERE id = $1
 RETURNING *;
 
@@ -69,7 +90,20 @@ RETURNING *;
 UPDATE contacts
 SET
   status = 'Rejected',
-  accepted_at = NULL
+  accepted_at = NULL,
+  rejected_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: RenewContactRequest :one
+UPDATE contacts
+SET
+  from_user_id = $2,
+  to_user_id = $3,
+  status = 'Pending',
+  requested_at = now(),
+  accepted_at = NULL,
+  rejected_at = NULL
 WHERE id = $1
 RETURNING *;
 
@@ -0,0 +1,19 @@
+-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (
+  user_id,
+  email,
+  code_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: UseEmailVerification :one
+UPDATE email_verifications
+SET is_used = true
+WHERE
+  id = sqlc.arg(id) AND
+  code_hash = sqlc.arg(code_hash) AND
+  is_used = false AND
+  expires_at > now()
+RETURNING *;
@@ -0,0 +1,26 @@
+-- name: CreateLoginEvent :one
+INSERT INTO login_events (
+  user_id,
+  succeeded,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListLoginEvents :many
+SELECT * FROM login_events
+WHERE
+  user_id = sqlc.arg(user_id) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  id < sqlc.narg(cursor_id)::bigint)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListLoginEventsBefore :many
+SELECT * FROM login_events
+WHERE
+  user_id = sqlc.arg(user_id) AND
+  id > sqlc.arg(cursor_id)::bigint
+ORDER BY id
+LIMIT sqlc.arg('limit');
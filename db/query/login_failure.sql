@@ -0,0 +1,26 @@
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE key = $1 LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (
+  key,
+  failures
+) VALUES (
+  sqlc.arg(key), 1
+) ON CONFLICT (key) DO UPDATE
+SET
+  failures = CASE
+    WHEN login_failures.last_failed_at < sqlc.arg(forget_before)::timestamptz THEN 1
+    ELSE login_failures.failures + 1
+  END,
+  last_failed_at = now()
+RETURNING *;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE key = $1;
+
+-- name: DeleteStaleLoginFailures :exec
+DELETE FROM login_failures
+WHERE last_failed_at < sqlc.arg(forget_before)::timestamptz;
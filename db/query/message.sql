@@ -12,12 +12,136 @@ INSERT INTO messages (
 SELECT * FROM messages
 WHERE id = $1 LIMIT 1;
 
+-- name: GetMessageForUpdate :one
+SELECT * FROM messages
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListMessages :many
 SELECT * FROM messages
-WHERE chat_id = $1
-ORDER BY sent_at DESC
-LIMIT $2
-OFFSET $3;
+WHERE
+  chat_id = sqlc.arg(chat_id) AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = sqlc.arg(user_id)
+  ) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  (sent_at, id) < (sqlc.narg(cursor_sent_at)::timestamptz, sqlc.narg(cursor_id)::bigint))
+ORDER BY sent_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListMessagesBefore :many
+SELECT * FROM messages
+WHERE
+  chat_id = sqlc.arg(chat_id) AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = sqlc.arg(user_id)
+  ) AND
+  (sent_at, id) > (sqlc.arg(cursor_sent_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY sent_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateMessage :one
+UPDATE messages
+SET
+  body = $2,
+  edited_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: TombstoneMessage :one
+UPDATE messages
+SET
+  body = '',
+  deleted_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: HideMessage :exec
+INSERT INTO hidden_messages (
+  message_id,
+  user_id
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING;
 
 -- name: DeleteMessage :exec
 DELETE FROM messages WHERE id = $1;
+
+-- name: GetLastChatMessage :one
+SELECT * FROM messages
+WHERE chat_id = $1
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: SearchMessages :many
+SELECT
+  messages.id,
+  messages.chat_id,
+  messages.from_user_id,
+  messages.sent_at,
+  messages.edited_at,
+  ts_rank(messages.body_tsv, to_tsquery('simple', sqlc.arg(query)))::real AS rank,
+  ts_headline(
+    'simple',
+    replace(replace(replace(replace(replace(messages.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+    to_tsquery('simple', sqlc.arg(query)),
+    'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, FragmentDelimiter=" ... "'
+  )::text AS snippet
+FROM messages
+JOIN chat_members ON chat_members.chat_id = messages.chat_id
+WHERE
+  chat_members.user_id = sqlc.arg(user_id) AND
+  messages.body_tsv @@ to_tsquery('simple', sqlc.arg(query)) AND
+  messages.deleted_at IS NULL AND
+  (sqlc.narg(chat_id)::bigint IS NULL OR messages.chat_id = sqlc.narg(chat_id)::bigint) AND
+  (sqlc.narg(sent_from)::timestamptz IS NULL OR messages.sent_at >= sqlc.narg(sent_from)::timestamptz) AND
+  (sqlc.narg(sent_to)::timestamptz IS NULL OR messages.sent_at < sqlc.narg(sent_to)::timestamptz) AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = sqlc.arg(user_id)
+  ) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  (ts_rank(messages.body_tsv, to_tsquery('simple', sqlc.arg(query)))::real, messages.id) < (sqlc.narg(cursor_rank)::real, sqlc.narg(cursor_id)::bigint))
+ORDER BY ts_rank(messages.body_tsv, to_tsquery('simple', sqlc.arg(query)))::real DESC, messages.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchMessagesBefore :many
+SELECT
+  messages.id,
+  messages.chat_id,
+  messages.from_user_id,
+  messages.sent_at,
+  messages.edited_at,
+  ts_rank(messages.body_tsv, to_tsquery('simple', sqlc.arg(query)))::real AS rank,
+  ts_headline(
+    'simple',
+    replace(replace(replace(replace(replace(messages.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+    to_tsquery('simple', sqlc.arg(query)),
+    'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, FragmentDelimiter=" ... "'
+  )::text AS snippet
+FROM messages
+JOIN chat_members ON chat_members.chat_id = messages.chat_id
+WHERE
+  chat_members.user_id = sqlc.arg(user_id) AND
+  messages.body_tsv @@ to_tsquery('simple', sqlc.arg(query)) AND
+  messages.deleted_at IS NULL AND
+  (sqlc.narg(chat_id)::bigint IS NULL OR messages.chat_id = sqlc.narg(chat_id)::bigint) AND
+  (sqlc.narg(sent_from)::timestamptz IS NULL OR messages.sent_at >= sqlc.narg(sent_from)::timestamptz) AND
+  (sqlc.narg(sent_to)::timestamptz IS NULL OR messages.sent_at < sqlc.narg(sent_to)::timestamptz) AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = sqlc.arg(user_id)
+  ) AND
+  (ts_rank(messages.body_tsv, to_tsquery('simple', sqlc.arg(query)))::real, messages.id) > (sqlc.arg(cursor_rank)::real, sqlc.arg(cursor_id)::bigint)
+ORDER BY ts_rank(messages.body_tsv, to_tsquery('simple', sqlc.arg(query)))::real, messages.id
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,16 @@
+-- name: CreateMessageRevision :one
+INSERT INTO message_revisions (
+  message_id,
+  body
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: ListMessageRevisions :many
+SELECT * FROM message_revisions
+WHERE message_id = $1
+ORDER BY id;
+
+-- name: DeleteMessageRevisions :exec
+DELETE FROM message_revisions
+WHERE message_id = $1;
@@ -0,0 +1,24 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  user_id,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE
+  token_hash = $1 AND
+  is_used = false AND
+  expires_at > now()
+RETURNING *;
+
+-- name: ExpireUserPasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE
+  user_id = $1 AND
+  is_used = false;
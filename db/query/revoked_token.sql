@@ -0,0 +1,27 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (
+  id,
+  username,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (id) DO NOTHING;
+
+-- name: GetTokenRevocation :one
+SELECT
+  EXISTS (
+    SELECT 1 FROM revoked_tokens
+    WHERE revoked_tokens.id = sqlc.arg(token_id)
+  ) AS is_revoked,
+  GREATEST(users.tokens_revoked_at, users.password_changed_at)::timestamptz AS valid_after
+FROM users
+WHERE users.username = sqlc.arg(username)
+LIMIT 1;
+
+-- name: RevokeUserTokens :exec
+UPDATE users
+SET tokens_revoked_at = $2
+WHERE username = $1;
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens WHERE expires_at < now();
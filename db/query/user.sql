@@ -16,6 +16,10 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = sqlc.arg(email)::varchar LIMIT 1;
+
 -- name: ListUsers :many
 SELECT
   id,
@@ -25,9 +29,38 @@ SELECT
   avatar_url,
   last_login_at
 FROM users
+WHERE
+  -- Users who blocked the one listing are hidden from it
+  NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE
+      user_blocks.blocker_id = users.id AND
+      user_blocks.blocked_id = sqlc.arg(user_id)
+  ) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  id > sqlc.narg(cursor_id)::bigint)
 ORDER BY id
-LIMIT $1
-OFFSET $2;
+LIMIT sqlc.arg('limit');
+
+-- name: ListUsersBefore :many
+SELECT
+  id,
+  full_name,
+  username,
+  email,
+  avatar_url,
+  last_login_at
+FROM users
+WHERE
+  NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE
+      user_blocks.blocker_id = users.id AND
+      user_blocks.blocked_id = sqlc.arg(user_id)
+  ) AND
+  id < sqlc.arg(cursor_id)::bigint
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateUser :one
 UPDATE users
@@ -35,7 +68,8 @@ SET
   full_name = $2,
   username = $3,
   email = $4,
-  avatar_url = $5
+  avatar_url = $5,
+  is_email_verified = is_email_verified AND email IS NOT DISTINCT FROM $4
 WHERE id = $1
 RETURNING *;
 
@@ -43,9 +77,98 @@ RETURNING *;
 UPDATE users
 SET
   hash_pass = $2,
-  password_changed_at = now()
+  password_changed_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserLastLogin :one
+UPDATE users
+SET last_login_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true
+WHERE
+  id = sqlc.arg(id) AND
+  email = sqlc.arg(email)::varchar
+RETURNING *;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET
+  totp_secret = sqlc.arg(totp_secret)::varchar,
+  totp_last_counter = NULL
+WHERE
+  id = sqlc.arg(id) AND
+  is_totp_enabled = false
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true
+WHERE
+  id = $1 AND
+  totp_secret IS NOT NULL
+RETURNING *;
+
+-- name: DisableUserTOTP :one
+UPDATE users
+SET
+  totp_secret = NULL,
+  is_totp_enabled = false,
+  totp_last_counter = NULL
 WHERE id = $1
 RETURNING *;
 
+-- name: UseUserTOTPCounter :one
+UPDATE users
+SET totp_last_counter = sqlc.arg(counter)::bigint
+WHERE
+  id = sqlc.arg(id) AND
+  (totp_last_counter IS NULL OR
+  totp_last_counter < sqlc.arg(counter)::bigint)
+RETURNING *;
+
+-- name: ListAllUsers :many
+SELECT * FROM users
+WHERE
+  sqlc.narg(cursor_id)::bigint IS NULL OR
+  id > sqlc.narg(cursor_id)::bigint
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ListAllUsersBefore :many
+SELECT * FROM users
+WHERE id < sqlc.arg(cursor_id)::bigint
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SuspendUser :one
+UPDATE users
+SET suspended_at = now()
+WHERE
+  id = $1 AND
+  suspended_at IS NULL
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_at = NULL
+WHERE
+  id = $1 AND
+  suspended_at IS NOT NULL
+RETURNING *;
+
+-- name: GetSystemStats :one
+SELECT
+  (SELECT count(*) FROM users) AS users,
+  (SELECT count(*) FROM users WHERE suspended_at IS NOT NULL) AS suspended_users,
+  (SELECT count(*) FROM chats) AS chats,
+  (SELECT count(*) FROM messages) AS messages,
+  (SELECT count(*) FROM messages WHERE sent_at > sqlc.arg(since)::timestamptz) AS recent_messages,
+  (SELECT count(*) FROM sessions WHERE NOT is_blocked AND expires_at > now()) AS active_sessions;
+
 -- name: DeleteUser :exec
 DELETE FROM users WHERE id = $1;
@@ -0,0 +1,51 @@
+-- name: BlockUser :one
+INSERT INTO user_blocks (
+  blocker_id,
+  blocked_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (blocker_id, blocked_id) DO UPDATE
+SET created_at = user_blocks.created_at
+RETURNING *;
+
+-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlockedBetween :one
+SELECT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE
+    (blocker_id = sqlc.arg(user_id) AND blocked_id = sqlc.arg(other_user_id)) OR
+    (blocker_id = sqlc.arg(other_user_id) AND blocked_id = sqlc.arg(user_id))
+)::bool;
+
+-- name: ListBlockedUsers :many
+SELECT
+  users.id,
+  users.full_name,
+  users.username,
+  user_blocks.created_at AS blocked_at
+FROM user_blocks
+JOIN users ON users.id = user_blocks.blocked_id
+WHERE
+  user_blocks.blocker_id = sqlc.arg(blocker_id) AND
+  (sqlc.narg(cursor_id)::bigint IS NULL OR
+  users.id > sqlc.narg(cursor_id)::bigint)
+ORDER BY users.id
+LIMIT sqlc.arg('limit');
+
+-- name: ListBlockedUsersBefore :many
+SELECT
+  users.id,
+  users.full_name,
+  users.username,
+  user_blocks.created_at AS blocked_at
+FROM user_blocks
+JOIN users ON users.id = user_blocks.blocked_id
+WHERE
+  user_blocks.blocker_id = sqlc.arg(blocker_id) AND
+  users.id < sqlc.arg(cursor_id)::bigint
+ORDER BY users.id DESC
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,161 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: attachment.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+const attachToMessage = `-- name: AttachToMessage :many
+UPDATE attachments
+SET message_id = $1::bigint
+WHERE
+  id = ANY($2::bigint[]) AND
+  uploader_id = $3 AND
+  message_id IS NULL
+RETURNING id, uploader_id, message_id, storage_key, file_name, content_type, size, created_at
+`
+
+type AttachToMessageParams struct {
+	MessageID  int64   `json:"message_id"`
+	Ids        []int64 `json:"ids"`
+	UploaderID int64   `json:"uploader_id"`
+}
+
+func (q *Queries) AttachToMessage(ctx context.Context, arg AttachToMessageParams) ([]Attachment, error) {
+	rows, err := q.db.QueryContext(ctx, attachToMessage, arg.MessageID, pq.Array(arg.Ids), arg.UploaderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UploaderID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.FileName,
+			&i.ContentType,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (
+  uploader_id,
+  storage_key,
+  file_name,
+  content_type,
+  size
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING id, uploader_id, message_id, storage_key, file_name, content_type, size, created_at
+`
+
+type CreateAttachmentParams struct {
+	UploaderID  int64  `json:"uploader_id"`
+	StorageKey  string `json:"storage_key"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, createAttachment,
+		arg.UploaderID,
+		arg.StorageKey,
+		arg.FileName,
+		arg.ContentType,
+		arg.Size,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.UploaderID,
+		&i.MessageID,
+		&i.StorageKey,
+		&i.FileName,
+		&i.ContentType,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAttachment = `-- name: GetAttachment :one
+SELECT id, uploader_id, message_id, storage_key, file_name, content_type, size, created_at FROM attachments
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAttachment(ctx context.Context, id int64) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, getAttachment, id)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.UploaderID,
+		&i.MessageID,
+		&i.StorageKey,
+		&i.FileName,
+		&i.ContentType,
+		&i.Size,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listMessagesAttachments = `-- name: ListMessagesAttachments :many
+SELECT id, uploader_id, message_id, storage_key, file_name, content_type, size, created_at FROM attachments
+WHERE message_id = ANY($1::bigint[])
+ORDER BY id
+`
+
+func (q *Queries) ListMessagesAttachments(ctx context.Context, messageIds []int64) ([]Attachment, error) {
+	rows, err := q.db.QueryContext(ctx, listMessagesAttachments, pq.Array(messageIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Attachment{}
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UploaderID,
+			&i.MessageID,
+			&i.StorageKey,
+			&i.FileName,
+			&i.ContentType,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAttachment(t *testing.T, uploader User) Attachment {
+	arg := CreateAttachmentParams{
+		UploaderID:  uploader.ID,
+		StorageKey:  util.RandomString(32),
+		FileName:    util.RandomString(8) + ".png",
+		ContentType: "image/png",
+		Size:        util.RandomInt(1, 1024),
+	}
+
+	attachment, err := testQueries.CreateAttachment(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, attachment)
+
+	require.Equal(t, arg.UploaderID, attachment.UploaderID)
+	require.Equal(t, arg.StorageKey, attachment.StorageKey)
+	require.Equal(t, arg.FileName, attachment.FileName)
+	require.Equal(t, arg.ContentType, attachment.ContentType)
+	require.Equal(t, arg.Size, attachment.Size)
+	require.False(t, attachment.MessageID.Valid)
+	require.NotZero(t, attachment.CreatedAt)
+
+	return attachment
+}
+
+func TestCreateAttachment(t *testing.T) {
+	user, _ := createRandomUser(t)
+	createRandomAttachment(t, user)
+}
+
+func TestGetAttachment(t *testing.T) {
+	user, _ := createRandomUser(t)
+	attachment := createRandomAttachment(t, user)
+
+	queriedAttachment, err := testQueries.GetAttachment(context.Background(), attachment.ID)
+	require.NoError(t, err)
+	require.Equal(t, attachment, queriedAttachment)
+}
+
+func TestSendMessageTxWithAttachments(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	attachmentIDs := []int64{}
+	for i := 0; i < 2; i++ {
+		attachment := createRandomAttachment(t, user1)
+		attachmentIDs = append(attachmentIDs, attachment.ID)
+	}
+
+	arg := SendMessageTxParams{
+		ChatID:        chat.Chat.ID,
+		FromUserID:    user1.ID,
+		ToUserID:      sql.NullInt64{Int64: user2.ID, Valid: true},
+		AttachmentIDs: attachmentIDs,
+	}
+	result, err := store.SendMessageTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Attachments, 2)
+	for _, attachment := range result.Attachments {
+		require.Equal(t, result.Message.ID, attachment.MessageID.Int64)
+	}
+
+	attachments, err := testQueries.ListMessagesAttachments(context.Background(), []int64{result.Message.ID})
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+
+	// Attachments which were already sent can't be used again
+	_, err = store.SendMessageTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidAttachments)
+
+	// Neither can attachments uploaded by someone else
+	otherAttachment := createRandomAttachment(t, user2)
+	arg.AttachmentIDs = []int64{otherAttachment.ID}
+	_, err = store.SendMessageTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidAttachments)
+
+	// The failed sends must not leave any message behind
+	messages, err := testQueries.ListMessages(context.Background(), ListMessagesParams{
+		ChatID: chat.Chat.ID,
+		UserID: user2.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+}
@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createChat = `-- name: CreateChat :one
@@ -15,12 +17,12 @@ INSERT INTO chats (
   to_user_id
 ) VALUES (
   $1, $2
-) RETURNING id, from_user_id, to_user_id, last_message_received_at
+) RETURNING id, from_user_id, to_user_id, last_message_received_at, title, is_group, created_at
 `
 
 type CreateChatParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	ToUserID   int64 `json:"to_user_id"`
+	FromUserID sql.NullInt64 `json:"from_user_id"`
+	ToUserID   sql.NullInt64 `json:"to_user_id"`
 }
 
 func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, error) {
@@ -31,6 +33,33 @@ func (q *Queries) CreateChat(ctx context.Context, arg CreateChatParams) (Chat, e
 		&i.FromUserID,
 		&i.ToUserID,
 		&i.LastMessageReceivedAt,
+		&i.Title,
+		&i.IsGroup,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createGroupChat = `-- name: CreateGroupChat :one
+INSERT INTO chats (
+  title,
+  is_group
+) VALUES (
+  $1, true
+) RETURNING id, from_user_id, to_user_id, last_message_received_at, title, is_group, created_at
+`
+
+func (q *Queries) CreateGroupChat(ctx context.Context, title sql.NullString) (Chat, error) {
+	row := q.db.QueryRowContext(ctx, createGroupChat, title)
+	var i Chat
+	err := row.Scan(
+		&i.ID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.LastMessageReceivedAt,
+		&i.Title,
+		&i.IsGroup,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -45,7 +74,7 @@ func (q *Queries) DeleteChat(ctx context.Context, id int64) error {
 }
 
 const getChat = `-- name: GetChat :one
-SELECT id, from_user_id, to_user_id, last_message_received_at FROM chats
+SELECT id, from_user_id, to_user_id, last_message_received_at, title, is_group, created_at FROM chats
 WHERE id = $1 LIMIT 1
 `
 
@@ -57,12 +86,15 @@ func (q *Queries) GetChat(ctx context.Context, id int64) (Chat, error) {
 		&i.FromUserID,
 		&i.ToUserID,
 		&i.LastMessageReceivedAt,
+		&i.Title,
+		&i.IsGroup,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getChatByUserIDs = `-- name: GetChatByUserIDs :one
-SELECT id, from_user_id, to_user_id, last_message_received_at FROM chats
+SELECT id, from_user_id, to_user_id, last_message_received_at, title, is_group, created_at FROM chats
 WHERE
   (from_user_id = $1 AND to_user_id = $2) OR 
   (from_user_id = $2 AND to_user_id = $1)
@@ -70,8 +102,8 @@ LIMIT 1
 `
 
 type GetChatByUserIDsParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	ToUserID   int64 `json:"to_user_id"`
+	FromUserID sql.NullInt64 `json:"from_user_id"`
+	ToUserID   sql.NullInt64 `json:"to_user_id"`
 }
 
 func (q *Queries) GetChatByUserIDs(ctx context.Context, arg GetChatByUserIDsParams) (Chat, error) {
@@ -82,40 +114,177 @@ func (q *Queries) GetChatByUserIDs(ctx context.Context, arg GetChatByUserIDsPara
 		&i.FromUserID,
 		&i.ToUserID,
 		&i.LastMessageReceivedAt,
+		&i.Title,
+		&i.IsGroup,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const listChats = `-- name: ListChats :many
-SELECT id, from_user_id, to_user_id, last_message_received_at FROM chats
-WHERE 
-  from_user_id = $1 OR
-  to_user_id = $1
-ORDER BY last_message_received_at
-LIMIT $2
-OFFSET $3
+SELECT
+  chats.id, chats.from_user_id, chats.to_user_id, chats.last_message_received_at, chats.title, chats.is_group, chats.created_at,
+  chat_members.unread_count,
+  chat_members.last_read_message_id,
+  last_message.id AS last_message_id,
+  last_message.from_user_id AS last_message_from_user_id,
+  last_message.body AS last_message_body,
+  last_message.sent_at AS last_message_sent_at
+FROM chats
+JOIN chat_members ON chat_members.chat_id = chats.id
+LEFT JOIN messages AS last_message ON last_message.id = (
+  SELECT max(messages.id) FROM messages
+  WHERE messages.chat_id = chats.id
+)
+WHERE
+  chat_members.user_id = $1 AND
+  -- Chats without messages are sorted last, as if their last message was the oldest possible
+  ($2::bigint IS NULL OR
+  (COALESCE(chats.last_message_received_at, '-infinity'::timestamptz), chats.id) <
+  (COALESCE($3::timestamptz, '-infinity'::timestamptz), $2::bigint))
+ORDER BY COALESCE(chats.last_message_received_at, '-infinity'::timestamptz) DESC, chats.id DESC
+LIMIT $4
 `
 
 type ListChatsParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	Limit      int32 `json:"limit"`
-	Offset     int32 `json:"offset"`
+	UserID                      int64         `json:"user_id"`
+	CursorID                    sql.NullInt64 `json:"cursor_id"`
+	CursorLastMessageReceivedAt sql.NullTime  `json:"cursor_last_message_received_at"`
+	Limit                       int32         `json:"limit"`
+}
+
+type ListChatsRow struct {
+	ID                    int64          `json:"id"`
+	FromUserID            sql.NullInt64  `json:"from_user_id"`
+	ToUserID              sql.NullInt64  `json:"to_user_id"`
+	LastMessageReceivedAt sql.NullTime   `json:"last_message_received_at"`
+	Title                 sql.NullString `json:"title"`
+	IsGroup               bool           `json:"is_group"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UnreadCount           int32          `json:"unread_count"`
+	LastReadMessageID     sql.NullInt64  `json:"last_read_message_id"`
+	LastMessageID         sql.NullInt64  `json:"last_message_id"`
+	LastMessageFromUserID sql.NullInt64  `json:"last_message_from_user_id"`
+	LastMessageBody       sql.NullString `json:"last_message_body"`
+	LastMessageSentAt     sql.NullTime   `json:"last_message_sent_at"`
+}
+
+func (q *Queries) ListChats(ctx context.Context, arg ListChatsParams) ([]ListChatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChats,
+		arg.UserID,
+		arg.CursorID,
+		arg.CursorLastMessageReceivedAt,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListChatsRow{}
+	for rows.Next() {
+		var i ListChatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromUserID,
+			&i.ToUserID,
+			&i.LastMessageReceivedAt,
+			&i.Title,
+			&i.IsGroup,
+			&i.CreatedAt,
+			&i.UnreadCount,
+			&i.LastReadMessageID,
+			&i.LastMessageID,
+			&i.LastMessageFromUserID,
+			&i.LastMessageBody,
+			&i.LastMessageSentAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) ListChats(ctx context.Context, arg ListChatsParams) ([]Chat, error) {
-	rows, err := q.db.QueryContext(ctx, listChats, arg.FromUserID, arg.Limit, arg.Offset)
+const listChatsBefore = `-- name: ListChatsBefore :many
+SELECT
+  chats.id, chats.from_user_id, chats.to_user_id, chats.last_message_received_at, chats.title, chats.is_group, chats.created_at,
+  chat_members.unread_count,
+  chat_members.last_read_message_id,
+  last_message.id AS last_message_id,
+  last_message.from_user_id AS last_message_from_user_id,
+  last_message.body AS last_message_body,
+  last_message.sent_at AS last_message_sent_at
+FROM chats
+JOIN chat_members ON chat_members.chat_id = chats.id
+LEFT JOIN messages AS last_message ON last_message.id = (
+  SELECT max(messages.id) FROM messages
+  WHERE messages.chat_id = chats.id
+)
+WHERE
+  chat_members.user_id = $1 AND
+  (COALESCE(chats.last_message_received_at, '-infinity'::timestamptz), chats.id) >
+  (COALESCE($2::timestamptz, '-infinity'::timestamptz), $3::bigint)
+ORDER BY COALESCE(chats.last_message_received_at, '-infinity'::timestamptz), chats.id
+LIMIT $4
+`
+
+type ListChatsBeforeParams struct {
+	UserID                      int64        `json:"user_id"`
+	CursorLastMessageReceivedAt sql.NullTime `json:"cursor_last_message_received_at"`
+	CursorID                    int64        `json:"cursor_id"`
+	Limit                       int32        `json:"limit"`
+}
+
+type ListChatsBeforeRow struct {
+	ID                    int64          `json:"id"`
+	FromUserID            sql.NullInt64  `json:"from_user_id"`
+	ToUserID              sql.NullInt64  `json:"to_user_id"`
+	LastMessageReceivedAt sql.NullTime   `json:"last_message_received_at"`
+	Title                 sql.NullString `json:"title"`
+	IsGroup               bool           `json:"is_group"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UnreadCount           int32          `json:"unread_count"`
+	LastReadMessageID     sql.NullInt64  `json:"last_read_message_id"`
+	LastMessageID         sql.NullInt64  `json:"last_message_id"`
+	LastMessageFromUserID sql.NullInt64  `json:"last_message_from_user_id"`
+	LastMessageBody       sql.NullString `json:"last_message_body"`
+	LastMessageSentAt     sql.NullTime   `json:"last_message_sent_at"`
+}
+
+func (q *Queries) ListChatsBefore(ctx context.Context, arg ListChatsBeforeParams) ([]ListChatsBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listChatsBefore,
+		arg.UserID,
+		arg.CursorLastMessageReceivedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Chat{}
+	items := []ListChatsBeforeRow{}
 	for rows.Next() {
-		var i Chat
+		var i ListChatsBeforeRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.FromUserID,
 			&i.ToUserID,
 			&i.LastMessageReceivedAt,
+			&i.Title,
+			&i.IsGroup,
+			&i.CreatedAt,
+			&i.UnreadCount,
+			&i.LastReadMessageID,
+			&i.LastMessageID,
+			&i.LastMessageFromUserID,
+			&i.LastMessageBody,
+			&i.LastMessageSentAt,
 		); err != nil {
 			return nil, err
 		}
@@ -134,7 +303,7 @@ const updateChat = `-- name: UpdateChat :one
 UPDATE chats
 SET last_message_received_at = now()
 WHERE id = $1
-RETURNING id, from_user_id, to_user_id, last_message_received_at
+RETURNING id, from_user_id, to_user_id, last_message_received_at, title, is_group, created_at
 `
 
 func (q *Queries) UpdateChat(ctx context.Context, id int64) (Chat, error) {
@@ -145,6 +314,9 @@ func (q *Queries) UpdateChat(ctx context.Context, id int64) (Chat, error) {
 		&i.FromUserID,
 		&i.ToUserID,
 		&i.LastMessageReceivedAt,
+		&i.Title,
+		&i.IsGroup,
+		&i.CreatedAt,
 	)
 	return i, err
 }
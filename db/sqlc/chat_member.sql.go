@@ -0,0 +1,230 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: chat_member.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addChatMember = `-- name: AddChatMember :one
+INSERT INTO chat_members (
+  chat_id,
+  user_id,
+  role
+) VALUES (
+  $1, $2, $3
+) RETURNING chat_id, user_id, role, joined_at, unread_count, last_read_message_id, last_read_at
+`
+
+type AddChatMemberParams struct {
+	ChatID int64  `json:"chat_id"`
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) AddChatMember(ctx context.Context, arg AddChatMemberParams) (ChatMember, error) {
+	row := q.db.QueryRowContext(ctx, addChatMember, arg.ChatID, arg.UserID, arg.Role)
+	var i ChatMember
+	err := row.Scan(
+		&i.ChatID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+		&i.UnreadCount,
+		&i.LastReadMessageID,
+		&i.LastReadAt,
+	)
+	return i, err
+}
+
+const getChatMember = `-- name: GetChatMember :one
+SELECT chat_id, user_id, role, joined_at, unread_count, last_read_message_id, last_read_at FROM chat_members
+WHERE chat_id = $1 AND user_id = $2
+LIMIT 1
+`
+
+type GetChatMemberParams struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) GetChatMember(ctx context.Context, arg GetChatMemberParams) (ChatMember, error) {
+	row := q.db.QueryRowContext(ctx, getChatMember, arg.ChatID, arg.UserID)
+	var i ChatMember
+	err := row.Scan(
+		&i.ChatID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+		&i.UnreadCount,
+		&i.LastReadMessageID,
+		&i.LastReadAt,
+	)
+	return i, err
+}
+
+const incrementUnreadCounts = `-- name: IncrementUnreadCounts :many
+UPDATE chat_members
+SET unread_count = unread_count + 1
+WHERE chat_id = $1 AND user_id <> $2
+RETURNING chat_id, user_id, role, joined_at, unread_count, last_read_message_id, last_read_at
+`
+
+type IncrementUnreadCountsParams struct {
+	ChatID   int64 `json:"chat_id"`
+	SenderID int64 `json:"sender_id"`
+}
+
+func (q *Queries) IncrementUnreadCounts(ctx context.Context, arg IncrementUnreadCountsParams) ([]ChatMember, error) {
+	rows, err := q.db.QueryContext(ctx, incrementUnreadCounts, arg.ChatID, arg.SenderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ChatMember{}
+	for rows.Next() {
+		var i ChatMember
+		if err := rows.Scan(
+			&i.ChatID,
+			&i.UserID,
+			&i.Role,
+			&i.JoinedAt,
+			&i.UnreadCount,
+			&i.LastReadMessageID,
+			&i.LastReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChatMembers = `-- name: ListChatMembers :many
+SELECT chat_id, user_id, role, joined_at, unread_count, last_read_message_id, last_read_at FROM chat_members
+WHERE chat_id = $1
+ORDER BY joined_at, user_id
+`
+
+func (q *Queries) ListChatMembers(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	rows, err := q.db.QueryContext(ctx, listChatMembers, chatID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ChatMember{}
+	for rows.Next() {
+		var i ChatMember
+		if err := rows.Scan(
+			&i.ChatID,
+			&i.UserID,
+			&i.Role,
+			&i.JoinedAt,
+			&i.UnreadCount,
+			&i.LastReadMessageID,
+			&i.LastReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markChatRead = `-- name: MarkChatRead :one
+UPDATE chat_members
+SET
+  last_read_message_id = $1::bigint,
+  last_read_at = now(),
+  unread_count = (
+    SELECT count(*) FROM messages
+    WHERE
+      messages.chat_id = chat_members.chat_id AND
+      messages.id > $1::bigint AND
+      messages.from_user_id <> chat_members.user_id
+  )
+WHERE
+  chat_members.chat_id = $2 AND
+  chat_members.user_id = $3 AND
+  (chat_members.last_read_message_id IS NULL OR chat_members.last_read_message_id < $1::bigint)
+RETURNING chat_id, user_id, role, joined_at, unread_count, last_read_message_id, last_read_at
+`
+
+type MarkChatReadParams struct {
+	MessageID int64 `json:"message_id"`
+	ChatID    int64 `json:"chat_id"`
+	UserID    int64 `json:"user_id"`
+}
+
+func (q *Queries) MarkChatRead(ctx context.Context, arg MarkChatReadParams) (ChatMember, error) {
+	row := q.db.QueryRowContext(ctx, markChatRead, arg.MessageID, arg.ChatID, arg.UserID)
+	var i ChatMember
+	err := row.Scan(
+		&i.ChatID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+		&i.UnreadCount,
+		&i.LastReadMessageID,
+		&i.LastReadAt,
+	)
+	return i, err
+}
+
+const removeChatMember = `-- name: RemoveChatMember :exec
+DELETE FROM chat_members
+WHERE chat_id = $1 AND user_id = $2
+`
+
+type RemoveChatMemberParams struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+}
+
+func (q *Queries) RemoveChatMember(ctx context.Context, arg RemoveChatMemberParams) error {
+	_, err := q.db.ExecContext(ctx, removeChatMember, arg.ChatID, arg.UserID)
+	return err
+}
+
+const updateChatMemberRole = `-- name: UpdateChatMemberRole :one
+UPDATE chat_members
+SET role = $3
+WHERE chat_id = $1 AND user_id = $2
+RETURNING chat_id, user_id, role, joined_at, unread_count, last_read_message_id, last_read_at
+`
+
+type UpdateChatMemberRoleParams struct {
+	ChatID int64  `json:"chat_id"`
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+func (q *Queries) UpdateChatMemberRole(ctx context.Context, arg UpdateChatMemberRoleParams) (ChatMember, error) {
+	row := q.db.QueryRowContext(ctx, updateChatMemberRole, arg.ChatID, arg.UserID, arg.Role)
+	var i ChatMember
+	err := row.Scan(
+		&i.ChatID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+		&i.UnreadCount,
+		&i.LastReadMessageID,
+		&i.LastReadAt,
+	)
+	return i, err
+}
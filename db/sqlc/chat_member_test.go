@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomGroupChat(t *testing.T) Chat {
+	chat, err := testQueries.CreateGroupChat(context.Background(), sql.NullString{String: "Group chat", Valid: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, chat)
+
+	require.True(t, chat.IsGroup)
+	require.Equal(t, "Group chat", chat.Title.String)
+	require.False(t, chat.FromUserID.Valid)
+	require.False(t, chat.ToUserID.Valid)
+
+	return chat
+}
+
+func TestAddChatMember(t *testing.T) {
+	user, _ := createRandomUser(t)
+	chat := createRandomGroupChat(t)
+
+	arg := AddChatMemberParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+		Role:   ChatRoleOwner,
+	}
+	member, err := testQueries.AddChatMember(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, member)
+
+	require.Equal(t, arg.ChatID, member.ChatID)
+	require.Equal(t, arg.UserID, member.UserID)
+	require.Equal(t, arg.Role, member.Role)
+	require.WithinDuration(t, time.Now(), member.JoinedAt, time.Second)
+
+	// The same user can't be added twice to the chat
+	_, err = testQueries.AddChatMember(context.Background(), arg)
+	require.Error(t, err)
+}
+
+func TestListChatMembers(t *testing.T) {
+	chat := createRandomGroupChat(t)
+	for i := 0; i < 3; i++ {
+		user, _ := createRandomUser(t)
+		testQueries.AddChatMember(context.Background(), AddChatMemberParams{
+			ChatID: chat.ID,
+			UserID: user.ID,
+			Role:   ChatRoleMember,
+		})
+	}
+
+	members, err := testQueries.ListChatMembers(context.Background(), chat.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	for _, member := range members {
+		require.Equal(t, chat.ID, member.ChatID)
+	}
+}
+
+func TestUpdateChatMemberRole(t *testing.T) {
+	user, _ := createRandomUser(t)
+	chat := createRandomGroupChat(t)
+	testQueries.AddChatMember(context.Background(), AddChatMemberParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+		Role:   ChatRoleMember,
+	})
+
+	member, err := testQueries.UpdateChatMemberRole(context.Background(), UpdateChatMemberRoleParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+		Role:   ChatRoleAdmin,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ChatRoleAdmin, member.Role)
+}
+
+func TestRemoveChatMember(t *testing.T) {
+	user, _ := createRandomUser(t)
+	chat := createRandomGroupChat(t)
+	testQueries.AddChatMember(context.Background(), AddChatMemberParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+		Role:   ChatRoleMember,
+	})
+
+	err := testQueries.RemoveChatMember(context.Background(), RemoveChatMemberParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+	})
+	require.NoError(t, err)
+
+	// Checking if member was removed
+	member, err := testQueries.GetChatMember(context.Background(), GetChatMemberParams{
+		ChatID: chat.ID,
+		UserID: user.ID,
+	})
+	require.Error(t, err)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	require.Empty(t, member)
+}
+
+func TestMarkChatRead(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Sending some messages to the second user
+	messages := []Message{}
+	for i := 0; i < 3; i++ {
+		result, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+			ChatID:     chat.Chat.ID,
+			FromUserID: user1.ID,
+			ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+			Body:       "Hello!",
+		})
+		require.NoError(t, err)
+		messages = append(messages, result.Message)
+	}
+
+	// Reading up to the second message leaves a single unread one
+	member, err := testQueries.MarkChatRead(context.Background(), MarkChatReadParams{
+		MessageID: messages[1].ID,
+		ChatID:    chat.Chat.ID,
+		UserID:    user2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, messages[1].ID, member.LastReadMessageID.Int64)
+	require.WithinDuration(t, time.Now(), member.LastReadAt.Time, time.Second)
+	require.Equal(t, int32(1), member.UnreadCount)
+
+	// The read cursor can't move back
+	_, err = testQueries.MarkChatRead(context.Background(), MarkChatReadParams{
+		MessageID: messages[0].ID,
+		ChatID:    chat.Chat.ID,
+		UserID:    user2.ID,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// Reading the last message clears the unread counter
+	member, err = testQueries.MarkChatRead(context.Background(), MarkChatReadParams{
+		MessageID: messages[2].ID,
+		ChatID:    chat.Chat.ID,
+		UserID:    user2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, messages[2].ID, member.LastReadMessageID.Int64)
+	require.Zero(t, member.UnreadCount)
+}
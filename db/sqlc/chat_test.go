@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
+	"github.com/renatomh/api-simplechat/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -30,14 +32,14 @@ func TestCreateChat(t *testing.T) {
 
 	// Creating chats for the contacts
 	chat1, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact1.FromUserID,
-		ToUserID:   contact1.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact1.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact1.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, chat1)
 	chat2, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact2.FromUserID,
-		ToUserID:   contact2.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact2.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact2.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, chat2)
@@ -58,8 +60,8 @@ func TestGetChat(t *testing.T) {
 	})
 	// Creating chat for the contacts
 	chat, _ := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 
 	// Querying the newly created chat by its ID
@@ -89,8 +91,8 @@ func TestUpdateChat(t *testing.T) {
 	})
 	// Creating chat for the contact
 	chat, _ := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 
 	// Updating the newly created chat by its ID
@@ -125,28 +127,68 @@ func TestListChats(t *testing.T) {
 	testQueries.AcceptContact(context.Background(), contact1.ID)
 	testQueries.AcceptContact(context.Background(), contact2.ID)
 
-	// Creating chats for the contacts
-	testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact1.FromUserID,
-		ToUserID:   contact1.ToUserID,
+	// Creating chats for the contacts, along with their members
+	store := NewStore(testDB)
+	store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: contact1.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact1.ToUserID, Valid: true},
 	})
-	testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact2.FromUserID,
-		ToUserID:   contact2.ToUserID,
+	store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: contact2.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact2.ToUserID, Valid: true},
 	})
 
 	// Listing the chats
 	chats, err := testQueries.ListChats(context.Background(),
 		ListChatsParams{
-			FromUserID: users[0].ID,
-			Limit:      10,
-			Offset:     0,
+			UserID: users[0].ID,
+			Limit:  10,
 		})
 	require.NoError(t, err)
 	require.Len(t, chats, 2)
 	for _, chat := range chats {
 		require.NotEmpty(t, chat)
+		require.Zero(t, chat.UnreadCount)
+		require.False(t, chat.LastMessageID.Valid)
+	}
+}
+
+func TestListChatsLastMessage(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Sending messages from both users
+	var result SendMessageTxResult
+	for _, fromUserID := range []int64{user1.ID, user2.ID, user1.ID} {
+		result, err = store.SendMessageTx(context.Background(), SendMessageTxParams{
+			ChatID:     chat.Chat.ID,
+			FromUserID: fromUserID,
+			Body:       util.RandomString(12),
+		})
+		require.NoError(t, err)
 	}
+
+	chats, err := testQueries.ListChats(context.Background(),
+		ListChatsParams{
+			UserID: user2.ID,
+			Limit:  10,
+		})
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+
+	// The listing brings the unread counter and the last message of the chat
+	require.Equal(t, chat.Chat.ID, chats[0].ID)
+	require.Equal(t, int32(2), chats[0].UnreadCount)
+	require.Equal(t, result.Message.ID, chats[0].LastMessageID.Int64)
+	require.Equal(t, user1.ID, chats[0].LastMessageFromUserID.Int64)
+	require.Equal(t, result.Message.Body, chats[0].LastMessageBody.String)
 }
 
 func TestDeleteChat(t *testing.T) {
@@ -166,8 +208,8 @@ func TestDeleteChat(t *testing.T) {
 
 	// Creating chat for the contact
 	chat, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 
@@ -7,15 +7,18 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const acceptContact = `-- name: AcceptContact :one
 UPDATE contacts
 SET
   status = 'Accepted',
-  accepted_at = now()
+  accepted_at = now(),
+  rejected_at = NULL
 WHERE id = $1
-RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at
+RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at, rejected_at
 `
 
 func (q *Queries) AcceptContact(ctx context.Context, id int64) (Contact, error) {
@@ -28,12 +31,13 @@ func (q *Queries) AcceptContact(ctx context.Context, id int64) (Contact, error)
 		&i.Status,
 		&i.RequestedAt,
 		&i.AcceptedAt,
+		&i.RejectedAt,
 	)
 	return i, err
 }
 
 const checkExistingContact = `-- name: CheckExistingContact :many
-SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at FROM contacts
+SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at, rejected_at FROM contacts
 WHERE 
   (from_user_id = $1 AND to_user_id = $2) OR
   (from_user_id = $2 AND to_user_id = $1)
@@ -61,6 +65,7 @@ func (q *Queries) CheckExistingContact(ctx context.Context, arg CheckExistingCon
 			&i.Status,
 			&i.RequestedAt,
 			&i.AcceptedAt,
+			&i.RejectedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -82,7 +87,7 @@ INSERT INTO contacts (
   status
 ) VALUES (
   $1, $2, 'Pending'
-) RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at
+) RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at, rejected_at
 `
 
 type CreateContactParams struct {
@@ -100,6 +105,7 @@ func (q *Queries) CreateContact(ctx context.Context, arg CreateContactParams) (C
 		&i.Status,
 		&i.RequestedAt,
 		&i.AcceptedAt,
+		&i.RejectedAt,
 	)
 	return i, err
 }
@@ -114,7 +120,7 @@ func (q *Queries) DeleteContact(ctx context.Context, id int64) error {
 }
 
 const getContact = `-- name: GetContact :one
-SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at FROM contacts
+SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at, rejected_at FROM contacts
 WHERE id = $1 LIMIT 1
 `
 
@@ -128,136 +134,91 @@ func (q *Queries) GetContact(ctx context.Context, id int64) (Contact, error) {
 		&i.Status,
 		&i.RequestedAt,
 		&i.AcceptedAt,
+		&i.RejectedAt,
 	)
 	return i, err
 }
 
-const listAcceptedContacts = `-- name: ListAcceptedContacts :many
-SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at FROM contacts
-WHERE 
-  (from_user_id = $1 OR
-  to_user_id = $1) AND
-  status = 'Accepted'
-ORDER BY id
-LIMIT $2
-OFFSET $3
-`
-
-type ListAcceptedContactsParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	Limit      int32 `json:"limit"`
-	Offset     int32 `json:"offset"`
-}
-
-func (q *Queries) ListAcceptedContacts(ctx context.Context, arg ListAcceptedContactsParams) ([]Contact, error) {
-	rows, err := q.db.QueryContext(ctx, listAcceptedContacts, arg.FromUserID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Contact{}
-	for rows.Next() {
-		var i Contact
-		if err := rows.Scan(
-			&i.ID,
-			&i.FromUserID,
-			&i.ToUserID,
-			&i.Status,
-			&i.RequestedAt,
-			&i.AcceptedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const listContacts = `-- name: ListContacts :many
-SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at FROM contacts
-WHERE 
-  from_user_id = $1 OR
-  to_user_id = $1
-ORDER BY id
-LIMIT $2
-OFFSET $3
+SELECT
+  contacts.id,
+  contacts.status,
+  contacts.requested_at,
+  contacts.accepted_at,
+  contacts.rejected_at,
+  (contacts.from_user_id = $1)::bool AS is_outgoing,
+  counterpart.id AS counterpart_id,
+  counterpart.username AS counterpart_username,
+  counterpart.full_name AS counterpart_full_name,
+  counterpart.avatar_url AS counterpart_avatar_url,
+  counterpart.last_login_at AS counterpart_last_login_at
+FROM contacts
+JOIN users AS counterpart ON counterpart.id = CASE
+  WHEN contacts.from_user_id = $1 THEN contacts.to_user_id
+  ELSE contacts.from_user_id
+END
+WHERE
+  (contacts.from_user_id = $1 OR
+  contacts.to_user_id = $1) AND
+  ($2::varchar IS NULL OR
+  contacts.status = $2::varchar) AND
+  ($3::bool IS NULL OR
+  (contacts.from_user_id = $1) = $3::bool) AND
+  ($4::bigint IS NULL OR
+  contacts.id > $4::bigint)
+ORDER BY contacts.id
+LIMIT $5
 `
 
 type ListContactsParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	Limit      int32 `json:"limit"`
-	Offset     int32 `json:"offset"`
+	UserID     int64          `json:"user_id"`
+	Status     sql.NullString `json:"status"`
+	IsOutgoing sql.NullBool   `json:"is_outgoing"`
+	CursorID   sql.NullInt64  `json:"cursor_id"`
+	Limit      int32          `json:"limit"`
 }
 
-func (q *Queries) ListContacts(ctx context.Context, arg ListContactsParams) ([]Contact, error) {
-	rows, err := q.db.QueryContext(ctx, listContacts, arg.FromUserID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	items := []Contact{}
-	for rows.Next() {
-		var i Contact
-		if err := rows.Scan(
-			&i.ID,
-			&i.FromUserID,
-			&i.ToUserID,
-			&i.Status,
-			&i.RequestedAt,
-			&i.AcceptedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
-const listPendingContacts = `-- name: ListPendingContacts :many
-SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at FROM contacts
-WHERE 
-  (from_user_id = $1 OR
-  to_user_id = $1) AND
-  status = 'Pending'
-ORDER BY id
-LIMIT $2
-OFFSET $3
-`
-
-type ListPendingContactsParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	Limit      int32 `json:"limit"`
-	Offset     int32 `json:"offset"`
+type ListContactsRow struct {
+	ID                     int64          `json:"id"`
+	Status                 string         `json:"status"`
+	RequestedAt            time.Time      `json:"requested_at"`
+	AcceptedAt             sql.NullTime   `json:"accepted_at"`
+	RejectedAt             sql.NullTime   `json:"rejected_at"`
+	IsOutgoing             bool           `json:"is_outgoing"`
+	CounterpartID          int64          `json:"counterpart_id"`
+	CounterpartUsername    string         `json:"counterpart_username"`
+	CounterpartFullName    string         `json:"counterpart_full_name"`
+	CounterpartAvatarUrl   sql.NullString `json:"counterpart_avatar_url"`
+	CounterpartLastLoginAt sql.NullTime   `json:"counterpart_last_login_at"`
 }
 
-func (q *Queries) ListPendingContacts(ctx context.Context, arg ListPendingContactsParams) ([]Contact, error) {
-	rows, err := q.db.QueryContext(ctx, listPendingContacts, arg.FromUserID, arg.Limit, arg.Offset)
+func (q *Queries) ListContacts(ctx context.Context, arg ListContactsParams) ([]ListContactsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listContacts,
+		arg.UserID,
+		arg.Status,
+		arg.IsOutgoing,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Contact{}
+	items := []ListContactsRow{}
 	for rows.Next() {
-		var i Contact
+		var i ListContactsRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.FromUserID,
-			&i.ToUserID,
 			&i.Status,
 			&i.RequestedAt,
 			&i.AcceptedAt,
+			&i.RejectedAt,
+			&i.IsOutgoing,
+			&i.CounterpartID,
+			&i.CounterpartUsername,
+			&i.CounterpartFullName,
+			&i.CounterpartAvatarUrl,
+			&i.CounterpartLastLoginAt,
 		); err != nil {
 			return nil, err
 		}
@@ -272,39 +233,85 @@ func (q *Queries) ListPendingContacts(ctx context.Context, arg ListPendingContac
 	return items, nil
 }
 
-const listRejectedContacts = `-- name: ListRejectedContacts :many
-SELECT id, from_user_id, to_user_id, status, requested_at, accepted_at FROM contacts
-WHERE 
-  (from_user_id = $1 OR
-  to_user_id = $1) AND
-  status = 'Rejected'
-ORDER BY id
-LIMIT $2
-OFFSET $3
+const listContactsBefore = `-- name: ListContactsBefore :many
+SELECT
+  contacts.id,
+  contacts.status,
+  contacts.requested_at,
+  contacts.accepted_at,
+  contacts.rejected_at,
+  (contacts.from_user_id = $1)::bool AS is_outgoing,
+  counterpart.id AS counterpart_id,
+  counterpart.username AS counterpart_username,
+  counterpart.full_name AS counterpart_full_name,
+  counterpart.avatar_url AS counterpart_avatar_url,
+  counterpart.last_login_at AS counterpart_last_login_at
+FROM contacts
+JOIN users AS counterpart ON counterpart.id = CASE
+  WHEN contacts.from_user_id = $1 THEN contacts.to_user_id
+  ELSE contacts.from_user_id
+END
+WHERE
+  (contacts.from_user_id = $1 OR
+  contacts.to_user_id = $1) AND
+  ($2::varchar IS NULL OR
+  contacts.status = $2::varchar) AND
+  ($3::bool IS NULL OR
+  (contacts.from_user_id = $1) = $3::bool) AND
+  contacts.id < $4::bigint
+ORDER BY contacts.id DESC
+LIMIT $5
 `
 
-type ListRejectedContactsParams struct {
-	FromUserID int64 `json:"from_user_id"`
-	Limit      int32 `json:"limit"`
-	Offset     int32 `json:"offset"`
+type ListContactsBeforeParams struct {
+	UserID     int64          `json:"user_id"`
+	Status     sql.NullString `json:"status"`
+	IsOutgoing sql.NullBool   `json:"is_outgoing"`
+	CursorID   int64          `json:"cursor_id"`
+	Limit      int32          `json:"limit"`
 }
 
-func (q *Queries) ListRejectedContacts(ctx context.Context, arg ListRejectedContactsParams) ([]Contact, error) {
-	rows, err := q.db.QueryContext(ctx, listRejectedContacts, arg.FromUserID, arg.Limit, arg.Offset)
+type ListContactsBeforeRow struct {
+	ID                     int64          `json:"id"`
+	Status                 string         `json:"status"`
+	RequestedAt            time.Time      `json:"requested_at"`
+	AcceptedAt             sql.NullTime   `json:"accepted_at"`
+	RejectedAt             sql.NullTime   `json:"rejected_at"`
+	IsOutgoing             bool           `json:"is_outgoing"`
+	CounterpartID          int64          `json:"counterpart_id"`
+	CounterpartUsername    string         `json:"counterpart_username"`
+	CounterpartFullName    string         `json:"counterpart_full_name"`
+	CounterpartAvatarUrl   sql.NullString `json:"counterpart_avatar_url"`
+	CounterpartLastLoginAt sql.NullTime   `json:"counterpart_last_login_at"`
+}
+
+func (q *Queries) ListContactsBefore(ctx context.Context, arg ListContactsBeforeParams) ([]ListContactsBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listContactsBefore,
+		arg.UserID,
+		arg.Status,
+		arg.IsOutgoing,
+		arg.CursorID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Contact{}
+	items := []ListContactsBeforeRow{}
 	for rows.Next() {
-		var i Contact
+		var i ListContactsBeforeRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.FromUserID,
-			&i.ToUserID,
 			&i.Status,
 			&i.RequestedAt,
 			&i.AcceptedAt,
+			&i.RejectedAt,
+			&i.IsOutgoing,
+			&i.CounterpartID,
+			&i.CounterpartUsername,
+			&i.CounterpartFullName,
+			&i.CounterpartAvatarUrl,
+			&i.CounterpartLastLoginAt,
 		); err != nil {
 			return nil, err
 		}
@@ -323,9 +330,10 @@ const rejectContact = `-- name: RejectContact :one
 UPDATE contacts
 SET
   status = 'Rejected',
-  accepted_at = NULL
+  accepted_at = NULL,
+  rejected_at = now()
 WHERE id = $1
-RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at
+RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at, rejected_at
 `
 
 func (q *Queries) RejectContact(ctx context.Context, id int64) (Contact, error) {
@@ -338,6 +346,41 @@ func (q *Queries) RejectContact(ctx context.Context, id int64) (Contact, error)
 		&i.Status,
 		&i.RequestedAt,
 		&i.AcceptedAt,
+		&i.RejectedAt,
+	)
+	return i, err
+}
+
+const renewContactRequest = `-- name: RenewContactRequest :one
+UPDATE contacts
+SET
+  from_user_id = $2,
+  to_user_id = $3,
+  status = 'Pending',
+  requested_at = now(),
+  accepted_at = NULL,
+  rejected_at = NULL
+WHERE id = $1
+RETURNING id, from_user_id, to_user_id, status, requested_at, accepted_at, rejected_at
+`
+
+type RenewContactRequestParams struct {
+	ID         int64 `json:"id"`
+	FromUserID int64 `json:"from_user_id"`
+	ToUserID   int64 `json:"to_user_id"`
+}
+
+func (q *Queries) RenewContactRequest(ctx context.Context, arg RenewContactRequestParams) (Contact, error) {
+	row := q.db.QueryRowContext(ctx, renewContactRequest, arg.ID, arg.FromUserID, arg.ToUserID)
+	var i Contact
+	err := row.Scan(
+		&i.ID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.Status,
+		&i.RequestedAt,
+		&i.AcceptedAt,
+		&i.RejectedAt,
 	)
 	return i, err
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -162,29 +163,46 @@ func TestListContacts(t *testing.T) {
 
 	// Now, we'll list the contacts
 	allContacts, err := testQueries.ListContacts(context.Background(), ListContactsParams{
-		FromUserID: users[0].ID,
-		Limit:      10,
-		Offset:     0,
+		UserID: users[0].ID,
+		Limit:  10,
 	})
 	require.NoError(t, err)
 	require.Len(t, allContacts, pendingCount+acceptedCount+rejectedCount)
+	for i, contact := range allContacts {
+		require.True(t, contact.IsOutgoing)
+		require.Equal(t, users[i+1].ID, contact.CounterpartID)
+		require.Equal(t, users[i+1].Username, contact.CounterpartUsername)
+	}
 
-	// Then, we'll list the pending contacts
-	pendingContacts, err := testQueries.ListPendingContacts(context.Background(),
-		ListPendingContactsParams{
-			FromUserID: users[0].ID,
+	// The contacts are incoming from the point of view of the requested users
+	for _, user := range users[1:] {
+		incomingContacts, err := testQueries.ListContacts(context.Background(), ListContactsParams{
+			UserID:     user.ID,
+			IsOutgoing: sql.NullBool{Bool: false, Valid: true},
 			Limit:      10,
-			Offset:     0,
+		})
+		require.NoError(t, err)
+		require.Len(t, incomingContacts, 1)
+		require.False(t, incomingContacts[0].IsOutgoing)
+		require.Equal(t, users[0].ID, incomingContacts[0].CounterpartID)
+	}
+
+	// Then, we'll list the pending contacts
+	pendingContacts, err := testQueries.ListContacts(context.Background(),
+		ListContactsParams{
+			UserID: users[0].ID,
+			Status: sql.NullString{String: "Pending", Valid: true},
+			Limit:  10,
 		})
 	require.NoError(t, err)
 	require.Len(t, pendingContacts, pendingCount)
 
 	// Listing the accepted contacts
-	acceptedContacts, err := testQueries.ListAcceptedContacts(context.Background(),
-		ListAcceptedContactsParams{
-			FromUserID: users[0].ID,
-			Limit:      10,
-			Offset:     0,
+	acceptedContacts, err := testQueries.ListContacts(context.Background(),
+		ListContactsParams{
+			UserID: users[0].ID,
+			Status: sql.NullString{String: "Accepted", Valid: true},
+			Limit:  10,
 		})
 	require.NoError(t, err)
 	require.Len(t, acceptedContacts, acceptedCount)
@@ -195,11 +213,11 @@ func TestListContacts(t *testing.T) {
 	}
 
 	// Listing the rejected contacts
-	rejectedContacts, err := testQueries.ListRejectedContacts(context.Background(),
-		ListRejectedContactsParams{
-			FromUserID: users[0].ID,
-			Limit:      10,
-			Offset:     0,
+	rejectedContacts, err := testQueries.ListContacts(context.Background(),
+		ListContactsParams{
+			UserID: users[0].ID,
+			Status: sql.NullString{String: "Rejected", Valid: true},
+			Limit:  10,
 		})
 	require.NoError(t, err)
 	require.Len(t, rejectedContacts, rejectedCount)
@@ -233,3 +251,32 @@ func TestDeleteContact(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, deletedContact)
 }
+
+func TestRenewContactRequest(t *testing.T) {
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	contact, err := testQueries.CreateContact(context.Background(), CreateContactParams{
+		FromUserID: user1.ID,
+		ToUserID:   user2.ID,
+	})
+	require.NoError(t, err)
+
+	rejectedContact, err := testQueries.RejectContact(context.Background(), contact.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), rejectedContact.RejectedAt.Time, time.Second)
+
+	// The rejecting user requests the contact back, on the same row
+	renewedContact, err := testQueries.RenewContactRequest(context.Background(), RenewContactRequestParams{
+		ID:         contact.ID,
+		FromUserID: user2.ID,
+		ToUserID:   user1.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, contact.ID, renewedContact.ID)
+	require.Equal(t, user2.ID, renewedContact.FromUserID)
+	require.Equal(t, user1.ID, renewedContact.ToUserID)
+	require.Equal(t, "Pending", renewedContact.Status)
+	require.False(t, renewedContact.RejectedAt.Valid)
+	require.False(t, renewedContact.AcceptedAt.Valid)
+}
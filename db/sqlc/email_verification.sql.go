@@ -0,0 +1,80 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: email_verification.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createEmailVerification = `-- name: CreateEmailVerification :one
+INSERT INTO email_verifications (
+  user_id,
+  email,
+  code_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, user_id, email, code_hash, is_used, created_at, expires_at
+`
+
+type CreateEmailVerificationParams struct {
+	UserID    int64     `json:"user_id"`
+	Email     string    `json:"email"`
+	CodeHash  string    `json:"code_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, createEmailVerification,
+		arg.UserID,
+		arg.Email,
+		arg.CodeHash,
+		arg.ExpiresAt,
+	)
+	var i EmailVerification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const useEmailVerification = `-- name: UseEmailVerification :one
+UPDATE email_verifications
+SET is_used = true
+WHERE
+  id = $1 AND
+  code_hash = $2 AND
+  is_used = false AND
+  expires_at > now()
+RETURNING id, user_id, email, code_hash, is_used, created_at, expires_at
+`
+
+type UseEmailVerificationParams struct {
+	ID       int64  `json:"id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseEmailVerification(ctx context.Context, arg UseEmailVerificationParams) (EmailVerification, error) {
+	row := q.db.QueryRowContext(ctx, useEmailVerification, arg.ID, arg.CodeHash)
+	var i EmailVerification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Email,
+		&i.CodeHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
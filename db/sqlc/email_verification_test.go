@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	username := util.RandomUsername()
+	codeHash := util.HashSecret(util.RandomString(32))
+
+	result, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			FullName: username,
+			Username: username,
+			Email:    sql.NullString{String: username + "@example.com", Valid: true},
+			HashPass: util.RandomString(32),
+		},
+		VerifyCodeHash:  codeHash,
+		VerifyExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.False(t, result.User.IsEmailVerified)
+	require.Equal(t, result.User.ID, result.EmailVerification.UserID)
+	require.Equal(t, result.User.Email.String, result.EmailVerification.Email)
+	require.Equal(t, codeHash, result.EmailVerification.CodeHash)
+	require.False(t, result.EmailVerification.IsUsed)
+
+	// Users without an email get no verification
+	username = util.RandomUsername()
+	result, err = store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			FullName: username,
+			Username: username,
+			HashPass: util.RandomString(32),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, username, result.User.Username)
+	require.Zero(t, result.EmailVerification.ID)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, _ := createRandomUser(t)
+	codeHash := util.HashSecret(util.RandomString(32))
+
+	verification, err := testQueries.CreateEmailVerification(context.Background(), CreateEmailVerificationParams{
+		UserID:    user.ID,
+		Email:     user.Email.String,
+		CodeHash:  codeHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The code must match
+	_, err = store.VerifyEmailTx(context.Background(), UseEmailVerificationParams{
+		ID:       verification.ID,
+		CodeHash: util.HashSecret(util.RandomString(32)),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	arg := UseEmailVerificationParams{
+		ID:       verification.ID,
+		CodeHash: codeHash,
+	}
+	result, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.True(t, result.EmailVerification.IsUsed)
+
+	// Codes can only be used once
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// Changing the email takes the verification back
+	updatedUser, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		ID:       user.ID,
+		FullName: user.FullName,
+		Username: user.Username,
+		Email:    sql.NullString{String: util.RandomUsername() + "@example.com", Valid: true},
+	})
+	require.NoError(t, err)
+	require.False(t, updatedUser.IsEmailVerified)
+}
+
+func TestVerifyEmailTxChangedEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user, _ := createRandomUser(t)
+	codeHash := util.HashSecret(util.RandomString(32))
+
+	verification, err := testQueries.CreateEmailVerification(context.Background(), CreateEmailVerificationParams{
+		UserID:    user.ID,
+		Email:     "old." + user.Email.String,
+		CodeHash:  codeHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// Verifications sent to previous emails no longer apply, leaving the code unused
+	_, err = store.VerifyEmailTx(context.Background(), UseEmailVerificationParams{
+		ID:       verification.ID,
+		CodeHash: codeHash,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	sameUser, err := testQueries.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, sameUser.IsEmailVerified)
+}
@@ -0,0 +1,139 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: login_event.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createLoginEvent = `-- name: CreateLoginEvent :one
+INSERT INTO login_events (
+  user_id,
+  succeeded,
+  client_ip,
+  user_agent
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, user_id, succeeded, client_ip, user_agent, created_at
+`
+
+type CreateLoginEventParams struct {
+	UserID    int64  `json:"user_id"`
+	Succeeded bool   `json:"succeeded"`
+	ClientIp  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+func (q *Queries) CreateLoginEvent(ctx context.Context, arg CreateLoginEventParams) (LoginEvent, error) {
+	row := q.db.QueryRowContext(ctx, createLoginEvent,
+		arg.UserID,
+		arg.Succeeded,
+		arg.ClientIp,
+		arg.UserAgent,
+	)
+	var i LoginEvent
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Succeeded,
+		&i.ClientIp,
+		&i.UserAgent,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listLoginEvents = `-- name: ListLoginEvents :many
+SELECT id, user_id, succeeded, client_ip, user_agent, created_at FROM login_events
+WHERE
+  user_id = $1 AND
+  ($2::bigint IS NULL OR
+  id < $2::bigint)
+ORDER BY id DESC
+LIMIT $3
+`
+
+type ListLoginEventsParams struct {
+	UserID   int64         `json:"user_id"`
+	CursorID sql.NullInt64 `json:"cursor_id"`
+	Limit    int32         `json:"limit"`
+}
+
+func (q *Queries) ListLoginEvents(ctx context.Context, arg ListLoginEventsParams) ([]LoginEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listLoginEvents, arg.UserID, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoginEvent{}
+	for rows.Next() {
+		var i LoginEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Succeeded,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoginEventsBefore = `-- name: ListLoginEventsBefore :many
+SELECT id, user_id, succeeded, client_ip, user_agent, created_at FROM login_events
+WHERE
+  user_id = $1 AND
+  id > $2::bigint
+ORDER BY id
+LIMIT $3
+`
+
+type ListLoginEventsBeforeParams struct {
+	UserID   int64 `json:"user_id"`
+	CursorID int64 `json:"cursor_id"`
+	Limit    int32 `json:"limit"`
+}
+
+func (q *Queries) ListLoginEventsBefore(ctx context.Context, arg ListLoginEventsBeforeParams) ([]LoginEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listLoginEventsBefore, arg.UserID, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LoginEvent{}
+	for rows.Next() {
+		var i LoginEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Succeeded,
+			&i.ClientIp,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
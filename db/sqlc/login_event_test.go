@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, _ := createRandomUser(t)
+
+	// Failed attempts don't change the user's last login
+	result, err := store.RecordLoginTx(context.Background(), CreateLoginEventParams{
+		UserID:    user.ID,
+		Succeeded: false,
+		ClientIp:  "127.0.0.1",
+		UserAgent: "test-agent",
+	})
+	require.NoError(t, err)
+	require.False(t, result.Event.Succeeded)
+	require.Equal(t, user.ID, result.Event.UserID)
+
+	sameUser, err := testQueries.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.LastLoginAt, sameUser.LastLoginAt)
+
+	result, err = store.RecordLoginTx(context.Background(), CreateLoginEventParams{
+		UserID:    user.ID,
+		Succeeded: true,
+		ClientIp:  "127.0.0.1",
+		UserAgent: "test-agent",
+	})
+	require.NoError(t, err)
+	require.True(t, result.Event.Succeeded)
+	require.Equal(t, user.ID, result.User.ID)
+	require.True(t, result.User.LastLoginAt.Valid)
+	require.WithinDuration(t, time.Now(), result.User.LastLoginAt.Time, time.Second)
+}
+
+func TestListLoginEvents(t *testing.T) {
+	user, _ := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		testQueries.CreateLoginEvent(context.Background(), CreateLoginEventParams{
+			UserID:    user.ID,
+			Succeeded: i%2 == 0,
+			ClientIp:  "127.0.0.1",
+			UserAgent: "test-agent",
+		})
+	}
+
+	events, err := testQueries.ListLoginEvents(context.Background(), ListLoginEventsParams{
+		UserID: user.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	// The most recent attempts come first
+	require.Greater(t, events[0].ID, events[1].ID)
+
+	earlierEvents, err := testQueries.ListLoginEventsBefore(context.Background(), ListLoginEventsBeforeParams{
+		UserID:   user.ID,
+		CursorID: events[1].ID,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, earlierEvents, 1)
+	require.Equal(t, events[0].ID, earlierEvents[0].ID)
+}
@@ -0,0 +1,71 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: login_failure.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteLoginFailure = `-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE key = $1
+`
+
+func (q *Queries) DeleteLoginFailure(ctx context.Context, key string) error {
+	_, err := q.db.ExecContext(ctx, deleteLoginFailure, key)
+	return err
+}
+
+const deleteStaleLoginFailures = `-- name: DeleteStaleLoginFailures :exec
+DELETE FROM login_failures
+WHERE last_failed_at < $1::timestamptz
+`
+
+func (q *Queries) DeleteStaleLoginFailures(ctx context.Context, forgetBefore time.Time) error {
+	_, err := q.db.ExecContext(ctx, deleteStaleLoginFailures, forgetBefore)
+	return err
+}
+
+const getLoginFailure = `-- name: GetLoginFailure :one
+SELECT key, failures, last_failed_at FROM login_failures
+WHERE key = $1 LIMIT 1
+`
+
+func (q *Queries) GetLoginFailure(ctx context.Context, key string) (LoginFailure, error) {
+	row := q.db.QueryRowContext(ctx, getLoginFailure, key)
+	var i LoginFailure
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailedAt)
+	return i, err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_failures (
+  key,
+  failures
+) VALUES (
+  $1, 1
+) ON CONFLICT (key) DO UPDATE
+SET
+  failures = CASE
+    WHEN login_failures.last_failed_at < $2::timestamptz THEN 1
+    ELSE login_failures.failures + 1
+  END,
+  last_failed_at = now()
+RETURNING key, failures, last_failed_at
+`
+
+type RecordLoginFailureParams struct {
+	Key          string    `json:"key"`
+	ForgetBefore time.Time `json:"forget_before"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginFailure, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Key, arg.ForgetBefore)
+	var i LoginFailure
+	err := row.Scan(&i.Key, &i.Failures, &i.LastFailedAt)
+	return i, err
+}
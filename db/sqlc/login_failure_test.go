@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := "account:" + util.RandomUsername()
+	arg := RecordLoginFailureParams{
+		Key:          key,
+		ForgetBefore: time.Now().Add(-time.Hour),
+	}
+
+	for i := 1; i <= 3; i++ {
+		failure, err := testQueries.RecordLoginFailure(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, key, failure.Key)
+		require.Equal(t, int32(i), failure.Failures)
+		require.WithinDuration(t, time.Now(), failure.LastFailedAt, time.Second)
+	}
+
+	// Failures older than the given time are forgotten
+	arg.ForgetBefore = time.Now().Add(time.Second)
+	failure, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.Failures)
+
+	err = testQueries.DeleteLoginFailure(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginFailure(context.Background(), key)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createMessage = `-- name: CreateMessage :one
@@ -17,14 +19,14 @@ INSERT INTO messages (
   body
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING id, chat_id, from_user_id, to_user_id, body, sent_at
+) RETURNING id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv
 `
 
 type CreateMessageParams struct {
-	ChatID     int64  `json:"chat_id"`
-	FromUserID int64  `json:"from_user_id"`
-	ToUserID   int64  `json:"to_user_id"`
-	Body       string `json:"body"`
+	ChatID     int64         `json:"chat_id"`
+	FromUserID int64         `json:"from_user_id"`
+	ToUserID   sql.NullInt64 `json:"to_user_id"`
+	Body       string        `json:"body"`
 }
 
 func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error) {
@@ -42,6 +44,9 @@ func (q *Queries) CreateMessage(ctx context.Context, arg CreateMessageParams) (M
 		&i.ToUserID,
 		&i.Body,
 		&i.SentAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.BodyTsv,
 	)
 	return i, err
 }
@@ -55,8 +60,32 @@ func (q *Queries) DeleteMessage(ctx context.Context, id int64) error {
 	return err
 }
 
+const getLastChatMessage = `-- name: GetLastChatMessage :one
+SELECT id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv FROM messages
+WHERE chat_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastChatMessage(ctx context.Context, chatID int64) (Message, error) {
+	row := q.db.QueryRowContext(ctx, getLastChatMessage, chatID)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.Body,
+		&i.SentAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.BodyTsv,
+	)
+	return i, err
+}
+
 const getMessage = `-- name: GetMessage :one
-SELECT id, chat_id, from_user_id, to_user_id, body, sent_at FROM messages
+SELECT id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv FROM messages
 WHERE id = $1 LIMIT 1
 `
 
@@ -70,26 +99,87 @@ func (q *Queries) GetMessage(ctx context.Context, id int64) (Message, error) {
 		&i.ToUserID,
 		&i.Body,
 		&i.SentAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.BodyTsv,
 	)
 	return i, err
 }
 
+const getMessageForUpdate = `-- name: GetMessageForUpdate :one
+SELECT id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv FROM messages
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetMessageForUpdate(ctx context.Context, id int64) (Message, error) {
+	row := q.db.QueryRowContext(ctx, getMessageForUpdate, id)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.Body,
+		&i.SentAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.BodyTsv,
+	)
+	return i, err
+}
+
+const hideMessage = `-- name: HideMessage :exec
+INSERT INTO hidden_messages (
+  message_id,
+  user_id
+) VALUES (
+  $1, $2
+) ON CONFLICT DO NOTHING
+`
+
+type HideMessageParams struct {
+	MessageID int64 `json:"message_id"`
+	UserID    int64 `json:"user_id"`
+}
+
+func (q *Queries) HideMessage(ctx context.Context, arg HideMessageParams) error {
+	_, err := q.db.ExecContext(ctx, hideMessage, arg.MessageID, arg.UserID)
+	return err
+}
+
 const listMessages = `-- name: ListMessages :many
-SELECT id, chat_id, from_user_id, to_user_id, body, sent_at FROM messages
-WHERE chat_id = $1
-ORDER BY sent_at DESC
-LIMIT $2
-OFFSET $3
+SELECT id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv FROM messages
+WHERE
+  chat_id = $1 AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = $2
+  ) AND
+  ($3::bigint IS NULL OR
+  (sent_at, id) < ($4::timestamptz, $3::bigint))
+ORDER BY sent_at DESC, id DESC
+LIMIT $5
 `
 
 type ListMessagesParams struct {
-	ChatID int64 `json:"chat_id"`
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	ChatID       int64         `json:"chat_id"`
+	UserID       int64         `json:"user_id"`
+	CursorID     sql.NullInt64 `json:"cursor_id"`
+	CursorSentAt sql.NullTime  `json:"cursor_sent_at"`
+	Limit        int32         `json:"limit"`
 }
 
 func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]Message, error) {
-	rows, err := q.db.QueryContext(ctx, listMessages, arg.ChatID, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listMessages,
+		arg.ChatID,
+		arg.UserID,
+		arg.CursorID,
+		arg.CursorSentAt,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +194,9 @@ func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]M
 			&i.ToUserID,
 			&i.Body,
 			&i.SentAt,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.BodyTsv,
 		); err != nil {
 			return nil, err
 		}
@@ -117,3 +210,313 @@ func (q *Queries) ListMessages(ctx context.Context, arg ListMessagesParams) ([]M
 	}
 	return items, nil
 }
+
+const listMessagesBefore = `-- name: ListMessagesBefore :many
+SELECT id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv FROM messages
+WHERE
+  chat_id = $1 AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = $2
+  ) AND
+  (sent_at, id) > ($3::timestamptz, $4::bigint)
+ORDER BY sent_at, id
+LIMIT $5
+`
+
+type ListMessagesBeforeParams struct {
+	ChatID       int64     `json:"chat_id"`
+	UserID       int64     `json:"user_id"`
+	CursorSentAt time.Time `json:"cursor_sent_at"`
+	CursorID     int64     `json:"cursor_id"`
+	Limit        int32     `json:"limit"`
+}
+
+func (q *Queries) ListMessagesBefore(ctx context.Context, arg ListMessagesBeforeParams) ([]Message, error) {
+	rows, err := q.db.QueryContext(ctx, listMessagesBefore,
+		arg.ChatID,
+		arg.UserID,
+		arg.CursorSentAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Message{}
+	for rows.Next() {
+		var i Message
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.FromUserID,
+			&i.ToUserID,
+			&i.Body,
+			&i.SentAt,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.BodyTsv,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMessages = `-- name: SearchMessages :many
+SELECT
+  messages.id,
+  messages.chat_id,
+  messages.from_user_id,
+  messages.sent_at,
+  messages.edited_at,
+  ts_rank(messages.body_tsv, to_tsquery('simple', $1))::real AS rank,
+  ts_headline(
+    'simple',
+    replace(replace(replace(replace(replace(messages.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+    to_tsquery('simple', $1),
+    'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, FragmentDelimiter=" ... "'
+  )::text AS snippet
+FROM messages
+JOIN chat_members ON chat_members.chat_id = messages.chat_id
+WHERE
+  chat_members.user_id = $2 AND
+  messages.body_tsv @@ to_tsquery('simple', $1) AND
+  messages.deleted_at IS NULL AND
+  ($3::bigint IS NULL OR messages.chat_id = $3::bigint) AND
+  ($4::timestamptz IS NULL OR messages.sent_at >= $4::timestamptz) AND
+  ($5::timestamptz IS NULL OR messages.sent_at < $5::timestamptz) AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = $2
+  ) AND
+  ($6::bigint IS NULL OR
+  (ts_rank(messages.body_tsv, to_tsquery('simple', $1))::real, messages.id) < ($7::real, $6::bigint))
+ORDER BY ts_rank(messages.body_tsv, to_tsquery('simple', $1))::real DESC, messages.id DESC
+LIMIT $8
+`
+
+type SearchMessagesParams struct {
+	Query      string          `json:"query"`
+	UserID     int64           `json:"user_id"`
+	ChatID     sql.NullInt64   `json:"chat_id"`
+	SentFrom   sql.NullTime    `json:"sent_from"`
+	SentTo     sql.NullTime    `json:"sent_to"`
+	CursorID   sql.NullInt64   `json:"cursor_id"`
+	CursorRank sql.NullFloat64 `json:"cursor_rank"`
+	Limit      int32           `json:"limit"`
+}
+
+type SearchMessagesRow struct {
+	ID         int64        `json:"id"`
+	ChatID     int64        `json:"chat_id"`
+	FromUserID int64        `json:"from_user_id"`
+	SentAt     time.Time    `json:"sent_at"`
+	EditedAt   sql.NullTime `json:"edited_at"`
+	Rank       float32      `json:"rank"`
+	Snippet    string       `json:"snippet"`
+}
+
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchMessages,
+		arg.Query,
+		arg.UserID,
+		arg.ChatID,
+		arg.SentFrom,
+		arg.SentTo,
+		arg.CursorID,
+		arg.CursorRank,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesRow{}
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.FromUserID,
+			&i.SentAt,
+			&i.EditedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMessagesBefore = `-- name: SearchMessagesBefore :many
+SELECT
+  messages.id,
+  messages.chat_id,
+  messages.from_user_id,
+  messages.sent_at,
+  messages.edited_at,
+  ts_rank(messages.body_tsv, to_tsquery('simple', $1))::real AS rank,
+  ts_headline(
+    'simple',
+    replace(replace(replace(replace(replace(messages.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+    to_tsquery('simple', $1),
+    'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, FragmentDelimiter=" ... "'
+  )::text AS snippet
+FROM messages
+JOIN chat_members ON chat_members.chat_id = messages.chat_id
+WHERE
+  chat_members.user_id = $2 AND
+  messages.body_tsv @@ to_tsquery('simple', $1) AND
+  messages.deleted_at IS NULL AND
+  ($3::bigint IS NULL OR messages.chat_id = $3::bigint) AND
+  ($4::timestamptz IS NULL OR messages.sent_at >= $4::timestamptz) AND
+  ($5::timestamptz IS NULL OR messages.sent_at < $5::timestamptz) AND
+  NOT EXISTS (
+    SELECT 1 FROM hidden_messages
+    WHERE
+      hidden_messages.message_id = messages.id AND
+      hidden_messages.user_id = $2
+  ) AND
+  (ts_rank(messages.body_tsv, to_tsquery('simple', $1))::real, messages.id) > ($6::real, $7::bigint)
+ORDER BY ts_rank(messages.body_tsv, to_tsquery('simple', $1))::real, messages.id
+LIMIT $8
+`
+
+type SearchMessagesBeforeParams struct {
+	Query      string        `json:"query"`
+	UserID     int64         `json:"user_id"`
+	ChatID     sql.NullInt64 `json:"chat_id"`
+	SentFrom   sql.NullTime  `json:"sent_from"`
+	SentTo     sql.NullTime  `json:"sent_to"`
+	CursorRank float32       `json:"cursor_rank"`
+	CursorID   int64         `json:"cursor_id"`
+	Limit      int32         `json:"limit"`
+}
+
+type SearchMessagesBeforeRow struct {
+	ID         int64        `json:"id"`
+	ChatID     int64        `json:"chat_id"`
+	FromUserID int64        `json:"from_user_id"`
+	SentAt     time.Time    `json:"sent_at"`
+	EditedAt   sql.NullTime `json:"edited_at"`
+	Rank       float32      `json:"rank"`
+	Snippet    string       `json:"snippet"`
+}
+
+func (q *Queries) SearchMessagesBefore(ctx context.Context, arg SearchMessagesBeforeParams) ([]SearchMessagesBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchMessagesBefore,
+		arg.Query,
+		arg.UserID,
+		arg.ChatID,
+		arg.SentFrom,
+		arg.SentTo,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesBeforeRow{}
+	for rows.Next() {
+		var i SearchMessagesBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChatID,
+			&i.FromUserID,
+			&i.SentAt,
+			&i.EditedAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const tombstoneMessage = `-- name: TombstoneMessage :one
+UPDATE messages
+SET
+  body = '',
+  deleted_at = now()
+WHERE id = $1
+RETURNING id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv
+`
+
+func (q *Queries) TombstoneMessage(ctx context.Context, id int64) (Message, error) {
+	row := q.db.QueryRowContext(ctx, tombstoneMessage, id)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.Body,
+		&i.SentAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.BodyTsv,
+	)
+	return i, err
+}
+
+const updateMessage = `-- name: UpdateMessage :one
+UPDATE messages
+SET
+  body = $2,
+  edited_at = now()
+WHERE id = $1
+RETURNING id, chat_id, from_user_id, to_user_id, body, sent_at, edited_at, deleted_at, body_tsv
+`
+
+type UpdateMessageParams struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) (Message, error) {
+	row := q.db.QueryRowContext(ctx, updateMessage, arg.ID, arg.Body)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ChatID,
+		&i.FromUserID,
+		&i.ToUserID,
+		&i.Body,
+		&i.SentAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.BodyTsv,
+	)
+	return i, err
+}
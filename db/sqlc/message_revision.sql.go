@@ -0,0 +1,80 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: message_revision.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createMessageRevision = `-- name: CreateMessageRevision :one
+INSERT INTO message_revisions (
+  message_id,
+  body
+) VALUES (
+  $1, $2
+) RETURNING id, message_id, body, revised_at
+`
+
+type CreateMessageRevisionParams struct {
+	MessageID int64  `json:"message_id"`
+	Body      string `json:"body"`
+}
+
+func (q *Queries) CreateMessageRevision(ctx context.Context, arg CreateMessageRevisionParams) (MessageRevision, error) {
+	row := q.db.QueryRowContext(ctx, createMessageRevision, arg.MessageID, arg.Body)
+	var i MessageRevision
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.Body,
+		&i.RevisedAt,
+	)
+	return i, err
+}
+
+const deleteMessageRevisions = `-- name: DeleteMessageRevisions :exec
+DELETE FROM message_revisions
+WHERE message_id = $1
+`
+
+func (q *Queries) DeleteMessageRevisions(ctx context.Context, messageID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteMessageRevisions, messageID)
+	return err
+}
+
+const listMessageRevisions = `-- name: ListMessageRevisions :many
+SELECT id, message_id, body, revised_at FROM message_revisions
+WHERE message_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListMessageRevisions(ctx context.Context, messageID int64) ([]MessageRevision, error) {
+	rows, err := q.db.QueryContext(ctx, listMessageRevisions, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MessageRevision{}
+	for rows.Next() {
+		var i MessageRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Body,
+			&i.RevisedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -2,9 +2,12 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/renatomh/api-simplechat/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,8 +29,8 @@ func TestCreateMessage(t *testing.T) {
 
 	// Creating a chat for the contact
 	chat, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, chat)
@@ -35,7 +38,7 @@ func TestCreateMessage(t *testing.T) {
 	// Sending messages for the chat
 	arg := CreateMessageParams{
 		ChatID:     chat.ID,
-		FromUserID: chat.FromUserID,
+		FromUserID: chat.FromUserID.Int64,
 		ToUserID:   chat.ToUserID,
 		Body:       "Hello!",
 	}
@@ -56,7 +59,7 @@ func TestCreateMessage(t *testing.T) {
 	// Sending messages for the chat
 	arg = CreateMessageParams{
 		ChatID:     chat.ID,
-		FromUserID: chat.ToUserID,
+		FromUserID: chat.ToUserID.Int64,
 		ToUserID:   chat.FromUserID,
 		Body:       "Hi, there!",
 	}
@@ -93,8 +96,8 @@ func TestGetMessage(t *testing.T) {
 
 	// Creating a chat for the contact
 	chat, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, chat)
@@ -102,7 +105,7 @@ func TestGetMessage(t *testing.T) {
 	// Sending a message for the chat
 	arg := CreateMessageParams{
 		ChatID:     chat.ID,
-		FromUserID: chat.FromUserID,
+		FromUserID: chat.FromUserID.Int64,
 		ToUserID:   chat.ToUserID,
 		Body:       "Hello!",
 	}
@@ -150,8 +153,8 @@ func TestListMessages(t *testing.T) {
 
 	// Creating a chat for the contact
 	chat, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, chat)
@@ -159,7 +162,7 @@ func TestListMessages(t *testing.T) {
 	// Sending messages for the chat
 	arg := CreateMessageParams{
 		ChatID:     chat.ID,
-		FromUserID: chat.FromUserID,
+		FromUserID: chat.FromUserID.Int64,
 		ToUserID:   chat.ToUserID,
 		Body:       "Hello!",
 	}
@@ -173,7 +176,7 @@ func TestListMessages(t *testing.T) {
 	// Sending messages for the chat
 	arg = CreateMessageParams{
 		ChatID:     chat.ID,
-		FromUserID: chat.ToUserID,
+		FromUserID: chat.ToUserID.Int64,
 		ToUserID:   chat.FromUserID,
 		Body:       "Hi, there!",
 	}
@@ -189,7 +192,6 @@ func TestListMessages(t *testing.T) {
 		ListMessagesParams{
 			ChatID: chat.ID,
 			Limit:  10,
-			Offset: 0,
 		})
 	require.NoError(t, err)
 	require.Len(t, messages, 2)
@@ -216,8 +218,8 @@ func TestDeleteMessage(t *testing.T) {
 
 	// Creating a chat for the contact
 	chat, err := testQueries.CreateChat(context.Background(), CreateChatParams{
-		FromUserID: contact.FromUserID,
-		ToUserID:   contact.ToUserID,
+		FromUserID: sql.NullInt64{Int64: contact.FromUserID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: contact.ToUserID, Valid: true},
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, chat)
@@ -225,7 +227,7 @@ func TestDeleteMessage(t *testing.T) {
 	// Sending a message for the chat
 	arg := CreateMessageParams{
 		ChatID:     chat.ID,
-		FromUserID: chat.FromUserID,
+		FromUserID: chat.FromUserID.Int64,
 		ToUserID:   chat.ToUserID,
 		Body:       "Hello!",
 	}
@@ -266,3 +268,298 @@ func TestDeleteMessage(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, deletedChat)
 }
+
+func TestEditMessageTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	sent, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+		ChatID:     chat.Chat.ID,
+		FromUserID: user1.ID,
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+		Body:       "Helo!",
+	})
+	require.NoError(t, err)
+	require.False(t, sent.Message.EditedAt.Valid)
+
+	// Editing the message twice
+	bodies := []string{"Hello!", "Hello there!"}
+	for _, body := range bodies {
+		result, err := store.EditMessageTx(context.Background(), UpdateMessageParams{
+			ID:   sent.Message.ID,
+			Body: body,
+		})
+		require.NoError(t, err)
+		require.Equal(t, body, result.Message.Body)
+		require.Equal(t, sent.Message.SentAt, result.Message.SentAt)
+		require.WithinDuration(t, time.Now(), result.Message.EditedAt.Time, time.Second)
+		require.Equal(t, sent.Message.ID, result.Revision.MessageID)
+	}
+
+	// Every previous body is kept as a revision, in order
+	revisions, err := testQueries.ListMessageRevisions(context.Background(), sent.Message.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	require.Equal(t, "Helo!", revisions[0].Body)
+	require.Equal(t, bodies[0], revisions[1].Body)
+}
+
+func TestHideMessage(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	sent, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+		ChatID:     chat.Chat.ID,
+		FromUserID: user1.ID,
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+		Body:       "Hello!",
+	})
+	require.NoError(t, err)
+
+	// Hiding the message twice must be accepted
+	for i := 0; i < 2; i++ {
+		err = testQueries.HideMessage(context.Background(), HideMessageParams{
+			MessageID: sent.Message.ID,
+			UserID:    user1.ID,
+		})
+		require.NoError(t, err)
+	}
+
+	// The message is only hidden for the user who deleted it
+	for user, n := range map[int64]int{user1.ID: 0, user2.ID: 1} {
+		messages, err := testQueries.ListMessages(context.Background(), ListMessagesParams{
+			ChatID: chat.Chat.ID,
+			UserID: user,
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		require.Len(t, messages, n)
+	}
+}
+
+func TestDeleteMessageForEveryoneTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	sent, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+		ChatID:     chat.Chat.ID,
+		FromUserID: user1.ID,
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+		Body:       "Helo!",
+	})
+	require.NoError(t, err)
+	_, err = store.EditMessageTx(context.Background(), UpdateMessageParams{
+		ID:   sent.Message.ID,
+		Body: "Hello!",
+	})
+	require.NoError(t, err)
+
+	tombstone, err := store.DeleteMessageForEveryoneTx(context.Background(), sent.Message.ID)
+	require.NoError(t, err)
+	require.Equal(t, sent.Message.ID, tombstone.ID)
+	require.Empty(t, tombstone.Body)
+	require.WithinDuration(t, time.Now(), tombstone.DeletedAt.Time, time.Second)
+
+	// The tombstone is still listed, but no previous body is kept
+	messages, err := testQueries.ListMessages(context.Background(), ListMessagesParams{
+		ChatID: chat.Chat.ID,
+		UserID: user2.ID,
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.True(t, messages[0].DeletedAt.Valid)
+
+	revisions, err := testQueries.ListMessageRevisions(context.Background(), sent.Message.ID)
+	require.NoError(t, err)
+	require.Empty(t, revisions)
+}
+
+func TestSearchMessages(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	outsider, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// A random word makes the search results independent from other tests
+	word := strings.ToLower(util.RandomString(12))
+	markupWord := strings.ToLower(util.RandomString(12))
+	bodies := []string{
+		"the quick brown fox " + word,
+		"brown quick " + word + "s",
+		"nothing to see here",
+		"<b> " + markupWord + " </b> & more",
+	}
+	messages := []Message{}
+	for _, body := range bodies {
+		result, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+			ChatID:     chat.Chat.ID,
+			FromUserID: user1.ID,
+			Body:       body,
+		})
+		require.NoError(t, err)
+		messages = append(messages, result.Message)
+	}
+
+	search := func(userID int64, query string) []SearchMessagesRow {
+		rows, err := testQueries.SearchMessages(context.Background(), SearchMessagesParams{
+			Query:  query,
+			UserID: userID,
+			ChatID: sql.NullInt64{Int64: chat.Chat.ID, Valid: true},
+			Limit:  10,
+		})
+		require.NoError(t, err)
+		return rows
+	}
+
+	// Exact words, phrases and prefixes
+	rows := search(user2.ID, word)
+	require.Len(t, rows, 1)
+	require.Equal(t, messages[0].ID, rows[0].ID)
+	require.Contains(t, rows[0].Snippet, "<mark>"+word+"</mark>")
+
+	require.Len(t, search(user2.ID, "quick <-> brown"), 1)
+	require.Len(t, search(user2.ID, "quick & brown & "+word+":*"), 2)
+
+	// Results are paged by their rank, with the ID breaking ties
+	ranked := search(user2.ID, "quick & brown & "+word+":*")
+	nextPage, err := testQueries.SearchMessages(context.Background(), SearchMessagesParams{
+		Query:      "quick & brown & " + word + ":*",
+		UserID:     user2.ID,
+		CursorID:   sql.NullInt64{Int64: ranked[0].ID, Valid: true},
+		CursorRank: sql.NullFloat64{Float64: float64(ranked[0].Rank), Valid: true},
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, nextPage, 1)
+	require.Equal(t, ranked[1].ID, nextPage[0].ID)
+
+	prevPage, err := testQueries.SearchMessagesBefore(context.Background(), SearchMessagesBeforeParams{
+		Query:      "quick & brown & " + word + ":*",
+		UserID:     user2.ID,
+		CursorRank: ranked[1].Rank,
+		CursorID:   ranked[1].ID,
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, prevPage, 1)
+	require.Equal(t, ranked[0].ID, prevPage[0].ID)
+
+	// The body is escaped, so only the highlights are markup
+	rows = search(user2.ID, markupWord)
+	require.Len(t, rows, 1)
+	require.Contains(t, rows[0].Snippet, "<mark>"+markupWord+"</mark>")
+	require.Contains(t, rows[0].Snippet, "&lt;b&gt;")
+	require.Contains(t, rows[0].Snippet, "&amp;")
+	require.NotContains(t, rows[0].Snippet, "<b>")
+
+	// Only chats the user takes part on are searched
+	require.Empty(t, search(outsider.ID, word+":*"))
+
+	// Deleted messages are left out
+	_, err = store.DeleteMessageForEveryoneTx(context.Background(), messages[0].ID)
+	require.NoError(t, err)
+	require.Empty(t, search(user2.ID, word))
+
+	// So are messages sent outside the date range
+	rows, err = testQueries.SearchMessages(context.Background(), SearchMessagesParams{
+		Query:    word + ":*",
+		UserID:   user2.ID,
+		SentFrom: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, rows)
+}
+
+func TestListMessagesKeyset(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	messages := []Message{}
+	for i := 0; i < 6; i++ {
+		result, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+			ChatID:     chat.Chat.ID,
+			FromUserID: user1.ID,
+			Body:       util.RandomString(12),
+		})
+		require.NoError(t, err)
+		messages = append(messages, result.Message)
+	}
+
+	// The first page brings the newest messages
+	firstPage, err := testQueries.ListMessages(context.Background(), ListMessagesParams{
+		ChatID: chat.Chat.ID,
+		UserID: user2.ID,
+		Limit:  3,
+	})
+	require.NoError(t, err)
+	require.Len(t, firstPage, 3)
+	for i, message := range firstPage {
+		require.Equal(t, messages[5-i].ID, message.ID)
+	}
+
+	// The next page starts right after the last message of the first one
+	last := firstPage[len(firstPage)-1]
+	secondPage, err := testQueries.ListMessages(context.Background(), ListMessagesParams{
+		ChatID:       chat.Chat.ID,
+		UserID:       user2.ID,
+		CursorID:     sql.NullInt64{Int64: last.ID, Valid: true},
+		CursorSentAt: sql.NullTime{Time: last.SentAt, Valid: true},
+		Limit:        3,
+	})
+	require.NoError(t, err)
+	require.Len(t, secondPage, 3)
+	for i, message := range secondPage {
+		require.Equal(t, messages[2-i].ID, message.ID)
+	}
+
+	// Going back from the second page brings the first one again, oldest messages first
+	first := secondPage[0]
+	previousPage, err := testQueries.ListMessagesBefore(context.Background(), ListMessagesBeforeParams{
+		ChatID:       chat.Chat.ID,
+		UserID:       user2.ID,
+		CursorSentAt: first.SentAt,
+		CursorID:     first.ID,
+		Limit:        3,
+	})
+	require.NoError(t, err)
+	require.Len(t, previousPage, 3)
+	for i, message := range previousPage {
+		require.Equal(t, messages[3+i].ID, message.ID)
+	}
+}
@@ -7,15 +7,46 @@ package db
 import (
 	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+type Attachment struct {
+	ID         int64 `json:"id"`
+	UploaderID int64 `json:"uploader_id"`
+	// Empty until the attachment is sent with a message
+	MessageID sql.NullInt64 `json:"message_id"`
+	// Key of the file on the blob store
+	StorageKey  string    `json:"storage_key"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 type Chat struct {
 	ID int64 `json:"id"`
-	// The from/to order makes no difference here
-	FromUserID int64 `json:"from_user_id"`
-	// The from/to order makes no difference here
-	ToUserID              int64        `json:"to_user_id"`
-	LastMessageReceivedAt sql.NullTime `json:"last_message_received_at"`
+	// The from/to order makes no difference here, empty for group chats
+	FromUserID sql.NullInt64 `json:"from_user_id"`
+	// The from/to order makes no difference here, empty for group chats
+	ToUserID              sql.NullInt64  `json:"to_user_id"`
+	LastMessageReceivedAt sql.NullTime   `json:"last_message_received_at"`
+	Title                 sql.NullString `json:"title"`
+	IsGroup               bool           `json:"is_group"`
+	CreatedAt             time.Time      `json:"created_at"`
+}
+
+type ChatMember struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+	// owner, admin or member
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+	// Messages received by the member which were not read yet
+	UnreadCount int32 `json:"unread_count"`
+	// Read cursor, every message up to this one was read by the member
+	LastReadMessageID sql.NullInt64 `json:"last_read_message_id"`
+	LastReadAt        sql.NullTime  `json:"last_read_at"`
 }
 
 type Contact struct {
@@ -28,15 +59,104 @@ type Contact struct {
 	Status      string       `json:"status"`
 	RequestedAt time.Time    `json:"requested_at"`
 	AcceptedAt  sql.NullTime `json:"accepted_at"`
+	// The rejected user must wait a while after it before requesting the contact again
+	RejectedAt sql.NullTime `json:"rejected_at"`
+}
+
+type EmailVerification struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+	// The verification only applies while the user keeps this email
+	Email string `json:"email"`
+	// Hash of the secret code sent by email, which is never stored
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Messages deleted only for the user, hidden from its listings
+type HiddenMessage struct {
+	MessageID int64     `json:"message_id"`
+	UserID    int64     `json:"user_id"`
+	HiddenAt  time.Time `json:"hidden_at"`
+}
+
+// Successful and failed login attempts, so users can review suspicious activity
+type LoginEvent struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Succeeded bool      `json:"succeeded"`
+	ClientIp  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Recent failed logins by account or client, shared by every instance to back off brute-force attempts
+type LoginFailure struct {
+	Key          string    `json:"key"`
+	Failures     int32     `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
 }
 
 type Message struct {
-	ID         int64     `json:"id"`
-	ChatID     int64     `json:"chat_id"`
-	FromUserID int64     `json:"from_user_id"`
-	ToUserID   int64     `json:"to_user_id"`
-	Body       string    `json:"body"`
-	SentAt     time.Time `json:"sent_at"`
+	ID         int64 `json:"id"`
+	ChatID     int64 `json:"chat_id"`
+	FromUserID int64 `json:"from_user_id"`
+	// Empty for messages sent to group chats
+	ToUserID sql.NullInt64 `json:"to_user_id"`
+	Body     string        `json:"body"`
+	SentAt   time.Time     `json:"sent_at"`
+	EditedAt sql.NullTime  `json:"edited_at"`
+	// Set when the message is deleted for everyone, leaving a tombstone with an empty body
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	// Searchable version of the body, kept up to date by the database
+	BodyTsv string `json:"-"`
+}
+
+type MessageRevision struct {
+	ID        int64 `json:"id"`
+	MessageID int64 `json:"message_id"`
+	// Body of the message before it was edited
+	Body      string    `json:"body"`
+	RevisedAt time.Time `json:"revised_at"`
+}
+
+type PasswordReset struct {
+	ID     int64 `json:"id"`
+	UserID int64 `json:"user_id"`
+	// Hash of the reset token sent by email, which is never stored
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// One-time codes replacing the TOTP ones when the user loses its authenticator
+type RecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type User struct {
@@ -49,4 +169,23 @@ type User struct {
 	LastLoginAt       sql.NullTime   `json:"last_login_at"`
 	HashPass          string         `json:"hash_pass"`
 	PasswordChangedAt time.Time      `json:"password_changed_at"`
+	// Tokens issued before this moment are no longer valid
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
+	IsEmailVerified bool      `json:"is_email_verified"`
+	// Only required on login once enabled, after being confirmed with a first code
+	TotpSecret    sql.NullString `json:"totp_secret"`
+	IsTotpEnabled bool           `json:"is_totp_enabled"`
+	// Time step of the last code used, so codes can't be replayed
+	TotpLastCounter sql.NullInt64 `json:"totp_last_counter"`
+	// Grants the scopes of the role to the user's tokens
+	Role string `json:"role"`
+	// Suspended users can't log in until an admin lifts the suspension
+	SuspendedAt sql.NullTime `json:"suspended_at"`
+}
+
+// A block stops any interaction between the users, in both directions
+type UserBlock struct {
+	BlockerID int64     `json:"blocker_id"`
+	BlockedID int64     `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,78 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: password_reset.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  user_id,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING id, user_id, token_hash, is_used, created_at, expires_at
+`
+
+type CreatePasswordResetParams struct {
+	UserID    int64     `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordReset, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const expireUserPasswordResets = `-- name: ExpireUserPasswordResets :exec
+UPDATE password_resets
+SET is_used = true
+WHERE
+  user_id = $1 AND
+  is_used = false
+`
+
+func (q *Queries) ExpireUserPasswordResets(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, expireUserPasswordResets, userID)
+	return err
+}
+
+const usePasswordReset = `-- name: UsePasswordReset :one
+UPDATE password_resets
+SET is_used = true
+WHERE
+  token_hash = $1 AND
+  is_used = false AND
+  expires_at > now()
+RETURNING id, user_id, token_hash, is_used, created_at, expires_at
+`
+
+func (q *Queries) UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordReset, tokenHash)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordReset(t *testing.T, user User, expiresAt time.Time) string {
+	tokenHash := util.HashSecret(util.RandomString(32))
+	reset, err := testQueries.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, reset.UserID)
+	require.False(t, reset.IsUsed)
+
+	return tokenHash
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, _ := createRandomUser(t)
+	tokenHash := createRandomPasswordReset(t, user, time.Now().Add(time.Hour))
+	otherTokenHash := createRandomPasswordReset(t, user, time.Now().Add(time.Hour))
+
+	hashPass, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	result, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:         tokenHash,
+		HashPass:          hashPass,
+		PasswordChangedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, result.User.ID)
+	require.Equal(t, hashPass, result.User.HashPass)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.True(t, result.PasswordReset.IsUsed)
+
+	// The used token and every other one of the user can't be used anymore
+	for _, hash := range []string{tokenHash, otherTokenHash} {
+		_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+			TokenHash: hash,
+			HashPass:  hashPass,
+		})
+		require.EqualError(t, err, sql.ErrNoRows.Error())
+	}
+}
+
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user, _ := createRandomUser(t)
+	tokenHash := createRandomPasswordReset(t, user, time.Now().Add(-time.Minute))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash: tokenHash,
+		HashPass:  util.RandomString(32),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	sameUser, err := testQueries.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.HashPass, sameUser.HashPass)
+}
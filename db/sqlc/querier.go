@@ -6,36 +6,104 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AcceptContact(ctx context.Context, id int64) (Contact, error)
+	AddChatMember(ctx context.Context, arg AddChatMemberParams) (ChatMember, error)
+	AttachToMessage(ctx context.Context, arg AttachToMessageParams) ([]Attachment, error)
+	BlockSession(ctx context.Context, arg BlockSessionParams) error
+	BlockUser(ctx context.Context, arg BlockUserParams) (UserBlock, error)
+	BlockUserSessions(ctx context.Context, username string) error
 	ChangeUserPassword(ctx context.Context, arg ChangeUserPasswordParams) (User, error)
 	CheckExistingContact(ctx context.Context, arg CheckExistingContactParams) ([]Contact, error)
+	CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error)
 	CreateChat(ctx context.Context, arg CreateChatParams) (Chat, error)
 	CreateContact(ctx context.Context, arg CreateContactParams) (Contact, error)
+	CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) (EmailVerification, error)
+	CreateGroupChat(ctx context.Context, title sql.NullString) (Chat, error)
+	CreateLoginEvent(ctx context.Context, arg CreateLoginEventParams) (LoginEvent, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreateMessageRevision(ctx context.Context, arg CreateMessageRevisionParams) (MessageRevision, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteChat(ctx context.Context, id int64) error
 	DeleteContact(ctx context.Context, id int64) error
+	DeleteExpiredRevokedTokens(ctx context.Context) error
+	DeleteLoginFailure(ctx context.Context, key string) error
 	DeleteMessage(ctx context.Context, id int64) error
+	DeleteMessageRevisions(ctx context.Context, messageID int64) error
+	DeleteStaleLoginFailures(ctx context.Context, forgetBefore time.Time) error
 	DeleteUser(ctx context.Context, id int64) error
+	DeleteUserRecoveryCodes(ctx context.Context, userID int64) error
+	DisableUserTOTP(ctx context.Context, id int64) (User, error)
+	EnableUserTOTP(ctx context.Context, id int64) (User, error)
+	ExpireUserPasswordResets(ctx context.Context, userID int64) error
+	GetAttachment(ctx context.Context, id int64) (Attachment, error)
 	GetChat(ctx context.Context, id int64) (Chat, error)
 	GetChatByUserIDs(ctx context.Context, arg GetChatByUserIDsParams) (Chat, error)
+	GetChatMember(ctx context.Context, arg GetChatMemberParams) (ChatMember, error)
 	GetContact(ctx context.Context, id int64) (Contact, error)
+	GetLastChatMessage(ctx context.Context, chatID int64) (Message, error)
+	GetLoginFailure(ctx context.Context, key string) (LoginFailure, error)
 	GetMessage(ctx context.Context, id int64) (Message, error)
+	GetMessageForUpdate(ctx context.Context, id int64) (Message, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSystemStats(ctx context.Context, since time.Time) (GetSystemStatsRow, error)
+	GetTokenRevocation(ctx context.Context, arg GetTokenRevocationParams) (GetTokenRevocationRow, error)
 	GetUser(ctx context.Context, id int64) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
-	ListAcceptedContacts(ctx context.Context, arg ListAcceptedContactsParams) ([]Contact, error)
-	ListChats(ctx context.Context, arg ListChatsParams) ([]Chat, error)
-	ListContacts(ctx context.Context, arg ListContactsParams) ([]Contact, error)
+	HideMessage(ctx context.Context, arg HideMessageParams) error
+	IncrementUnreadCounts(ctx context.Context, arg IncrementUnreadCountsParams) ([]ChatMember, error)
+	IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error)
+	ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]User, error)
+	ListAllUsersBefore(ctx context.Context, arg ListAllUsersBeforeParams) ([]User, error)
+	ListBlockedUsers(ctx context.Context, arg ListBlockedUsersParams) ([]ListBlockedUsersRow, error)
+	ListBlockedUsersBefore(ctx context.Context, arg ListBlockedUsersBeforeParams) ([]ListBlockedUsersBeforeRow, error)
+	ListChatMembers(ctx context.Context, chatID int64) ([]ChatMember, error)
+	ListChats(ctx context.Context, arg ListChatsParams) ([]ListChatsRow, error)
+	ListChatsBefore(ctx context.Context, arg ListChatsBeforeParams) ([]ListChatsBeforeRow, error)
+	ListContacts(ctx context.Context, arg ListContactsParams) ([]ListContactsRow, error)
+	ListContactsBefore(ctx context.Context, arg ListContactsBeforeParams) ([]ListContactsBeforeRow, error)
+	ListLoginEvents(ctx context.Context, arg ListLoginEventsParams) ([]LoginEvent, error)
+	ListLoginEventsBefore(ctx context.Context, arg ListLoginEventsBeforeParams) ([]LoginEvent, error)
+	ListMessageRevisions(ctx context.Context, messageID int64) ([]MessageRevision, error)
 	ListMessages(ctx context.Context, arg ListMessagesParams) ([]Message, error)
-	ListPendingContacts(ctx context.Context, arg ListPendingContactsParams) ([]Contact, error)
-	ListRejectedContacts(ctx context.Context, arg ListRejectedContactsParams) ([]Contact, error)
+	ListMessagesAttachments(ctx context.Context, messageIds []int64) ([]Attachment, error)
+	ListMessagesBefore(ctx context.Context, arg ListMessagesBeforeParams) ([]Message, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	ListUsersBefore(ctx context.Context, arg ListUsersBeforeParams) ([]ListUsersBeforeRow, error)
+	MarkChatRead(ctx context.Context, arg MarkChatReadParams) (ChatMember, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginFailure, error)
 	RejectContact(ctx context.Context, id int64) (Contact, error)
+	RemoveChatMember(ctx context.Context, arg RemoveChatMemberParams) error
+	RenewContactRequest(ctx context.Context, arg RenewContactRequestParams) (Contact, error)
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error
+	SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error)
+	SearchMessagesBefore(ctx context.Context, arg SearchMessagesBeforeParams) ([]SearchMessagesBeforeRow, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	SuspendUser(ctx context.Context, id int64) (User, error)
+	TombstoneMessage(ctx context.Context, id int64) (Message, error)
+	UnblockUser(ctx context.Context, arg UnblockUserParams) (int64, error)
+	UnsuspendUser(ctx context.Context, id int64) (User, error)
 	UpdateChat(ctx context.Context, id int64) (Chat, error)
+	UpdateChatMemberRole(ctx context.Context, arg UpdateChatMemberRoleParams) (ChatMember, error)
+	UpdateMessage(ctx context.Context, arg UpdateMessageParams) (Message, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserLastLogin(ctx context.Context, id int64) (User, error)
+	UseEmailVerification(ctx context.Context, arg UseEmailVerificationParams) (EmailVerification, error)
+	UsePasswordReset(ctx context.Context, tokenHash string) (PasswordReset, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseUserTOTPCounter(ctx context.Context, arg UseUserTOTPCounterParams) (User, error)
+	VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
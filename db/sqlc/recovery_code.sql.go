@@ -0,0 +1,75 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: recovery_code.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (
+  user_id,
+  code_hash
+) VALUES (
+  $1, $2
+) RETURNING id, user_id, code_hash, used_at, created_at
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   int64  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, createRecoveryCode, arg.UserID, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeHash,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserRecoveryCodes = `-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUserRecoveryCodes, userID)
+	return err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = now()
+WHERE
+  user_id = $1 AND
+  code_hash = $2 AND
+  used_at IS NULL
+RETURNING id, user_id, code_hash, used_at, created_at
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int64  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, useRecoveryCode, arg.UserID, arg.CodeHash)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.CodeHash,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, _ := createRandomUser(t)
+
+	// Two-factor authentication can't be enabled before enrolling
+	_, err := store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{UserID: user.ID})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = testQueries.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.True(t, user.TotpSecret.Valid)
+	require.False(t, user.IsTotpEnabled)
+
+	codeHash := util.HashSecret(util.RandomString(8))
+	user, err = store.EnableTOTPTx(context.Background(), EnableTOTPTxParams{
+		UserID:             user.ID,
+		RecoveryCodeHashes: []string{codeHash, util.HashSecret(util.RandomString(8))},
+	})
+	require.NoError(t, err)
+	require.True(t, user.IsTotpEnabled)
+
+	// The secret can't be replaced while enabled
+	_, err = testQueries.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		ID:         user.ID,
+		TotpSecret: util.RandomString(32),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Recovery codes can only be used once
+	code, err := testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: codeHash,
+	})
+	require.NoError(t, err)
+	require.True(t, code.UsedAt.Valid)
+
+	_, err = testQueries.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		UserID:   user.ID,
+		CodeHash: codeHash,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	user, err = store.DisableTOTPTx(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, user.IsTotpEnabled)
+	require.False(t, user.TotpSecret.Valid)
+}
+
+func TestUseUserTOTPCounter(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	user, err := testQueries.UseUserTOTPCounter(context.Background(), UseUserTOTPCounterParams{
+		ID:      user.ID,
+		Counter: 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), user.TotpLastCounter.Int64)
+
+	// Codes from the same or earlier time steps can't be replayed
+	for _, counter := range []int64{100, 99} {
+		_, err = testQueries.UseUserTOTPCounter(context.Background(), UseUserTOTPCounterParams{
+			ID:      user.ID,
+			Counter: counter,
+		})
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	}
+}
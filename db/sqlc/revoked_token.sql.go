@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: revoked_token.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteExpiredRevokedTokens)
+	return err
+}
+
+const getTokenRevocation = `-- name: GetTokenRevocation :one
+SELECT
+  EXISTS (
+    SELECT 1 FROM revoked_tokens
+    WHERE revoked_tokens.id = $1
+  ) AS is_revoked,
+  GREATEST(users.tokens_revoked_at, users.password_changed_at)::timestamptz AS valid_after
+FROM users
+WHERE users.username = $2
+LIMIT 1
+`
+
+type GetTokenRevocationParams struct {
+	TokenID  uuid.UUID `json:"token_id"`
+	Username string    `json:"username"`
+}
+
+type GetTokenRevocationRow struct {
+	IsRevoked  bool      `json:"is_revoked"`
+	ValidAfter time.Time `json:"valid_after"`
+}
+
+func (q *Queries) GetTokenRevocation(ctx context.Context, arg GetTokenRevocationParams) (GetTokenRevocationRow, error) {
+	row := q.db.QueryRowContext(ctx, getTokenRevocation, arg.TokenID, arg.Username)
+	var i GetTokenRevocationRow
+	err := row.Scan(&i.IsRevoked, &i.ValidAfter)
+	return i, err
+}
+
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (
+  id,
+  username,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (id) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.ExecContext(ctx, revokeToken, arg.ID, arg.Username, arg.ExpiresAt)
+	return err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE users
+SET tokens_revoked_at = $2
+WHERE username = $1
+`
+
+type RevokeUserTokensParams struct {
+	Username        string    `json:"username"`
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
+}
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserTokens, arg.Username, arg.TokensRevokedAt)
+	return err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeToken(t *testing.T) {
+	user, _ := createRandomUser(t)
+	tokenID := uuid.New()
+
+	// A token which was never revoked
+	revocation, err := testQueries.GetTokenRevocation(context.Background(), GetTokenRevocationParams{
+		TokenID:  tokenID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.False(t, revocation.IsRevoked)
+
+	// Revoking the token twice must not fail
+	for i := 0; i < 2; i++ {
+		err = testQueries.RevokeToken(context.Background(), RevokeTokenParams{
+			ID:        tokenID,
+			Username:  user.Username,
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	revocation, err = testQueries.GetTokenRevocation(context.Background(), GetTokenRevocationParams{
+		TokenID:  tokenID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, revocation.IsRevoked)
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	user, _ := createRandomUser(t)
+
+	cutoff := time.Now()
+	err := testQueries.RevokeUserTokens(context.Background(), RevokeUserTokensParams{
+		Username:        user.Username,
+		TokensRevokedAt: cutoff,
+	})
+	require.NoError(t, err)
+
+	// The most recent between the revocation and the password change must be used
+	revocation, err := testQueries.GetTokenRevocation(context.Background(), GetTokenRevocationParams{
+		TokenID:  uuid.New(),
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.False(t, revocation.IsRevoked)
+	require.WithinDuration(t, cutoff, revocation.ValidAfter, time.Millisecond)
+
+	updatedUser, err := testQueries.ChangeUserPassword(context.Background(), ChangeUserPasswordParams{
+		ID:                user.ID,
+		HashPass:          user.HashPass,
+		PasswordChangedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	revocation, err = testQueries.GetTokenRevocation(context.Background(), GetTokenRevocationParams{
+		TokenID:  uuid.New(),
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, updatedUser.PasswordChangedAt, revocation.ValidAfter, time.Millisecond)
+}
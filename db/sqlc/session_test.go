@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(12),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	// Creating session in the database
+	session, err := testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, session)
+
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.Equal(t, arg.UserAgent, session.UserAgent)
+	require.Equal(t, arg.ClientIp, session.ClientIp)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.NotZero(t, session.CreatedAt)
+
+	return session
+}
+
+func TestCreateSession(t *testing.T) {
+	user, _ := createRandomUser(t)
+	createRandomSession(t, user)
+}
+
+func TestGetSession(t *testing.T) {
+	user, _ := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	// Trying to get the newly created session
+	queriedSession, err := testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, queriedSession)
+
+	require.Equal(t, session.ID, queriedSession.ID)
+	require.Equal(t, session.Username, queriedSession.Username)
+	require.Equal(t, session.RefreshToken, queriedSession.RefreshToken)
+	require.Equal(t, session.IsBlocked, queriedSession.IsBlocked)
+	require.WithinDuration(t, session.ExpiresAt, queriedSession.ExpiresAt, time.Second)
+}
+
+func TestBlockSessions(t *testing.T) {
+	user, _ := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+
+	// Blocking a single session
+	err := testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session1.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+
+	queriedSession, err := testQueries.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, queriedSession.IsBlocked)
+
+	queriedSession, err = testQueries.GetSession(context.Background(), session2.ID)
+	require.NoError(t, err)
+	require.False(t, queriedSession.IsBlocked)
+
+	// Blocking every session of the user
+	err = testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	queriedSession, err = testQueries.GetSession(context.Background(), session2.ID)
+	require.NoError(t, err)
+	require.True(t, queriedSession.IsBlocked)
+}
@@ -1,12 +1,26 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 )
 
 // Store defines all functions to execute db queries and transactions
 type Store interface {
 	Querier
+	CreateDirectChatTx(ctx context.Context, arg CreateChatParams) (CreateChatTxResult, error)
+	CreateGroupChatTx(ctx context.Context, arg CreateGroupChatTxParams) (CreateChatTxResult, error)
+	TransferChatOwnershipTx(ctx context.Context, arg TransferChatOwnershipTxParams) (TransferChatOwnershipTxResult, error)
+	SendMessageTx(ctx context.Context, arg SendMessageTxParams) (SendMessageTxResult, error)
+	EditMessageTx(ctx context.Context, arg UpdateMessageParams) (EditMessageTxResult, error)
+	DeleteMessageForEveryoneTx(ctx context.Context, id int64) (Message, error)
+	RecordLoginTx(ctx context.Context, arg CreateLoginEventParams) (RecordLoginTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg UseEmailVerificationParams) (VerifyEmailTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error)
+	DisableTOTPTx(ctx context.Context, userID int64) (User, error)
 }
 
 // SQLStore implements Store interface, defining all function to execute SQL queries and transactions
@@ -22,3 +36,23 @@ func NewStore(db *sql.DB) Store {
 		Queries: New(db),
 	}
 }
+
+// execTx executes a function within a database transaction
+func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// The queries run by the function will be part of the transaction
+	q := New(tx)
+	err = fn(q)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDirectChatTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	result, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.False(t, result.Chat.IsGroup)
+	require.Equal(t, user1.ID, result.Chat.FromUserID.Int64)
+	require.Equal(t, user2.ID, result.Chat.ToUserID.Int64)
+
+	// Both users must be members of the chat
+	require.Len(t, result.Members, 2)
+	for _, member := range result.Members {
+		require.Equal(t, result.Chat.ID, member.ChatID)
+		require.Equal(t, ChatRoleMember, member.Role)
+	}
+}
+
+func TestCreateGroupChatTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	owner, _ := createRandomUser(t)
+	memberIDs := []int64{}
+	for i := 0; i < 3; i++ {
+		user, _ := createRandomUser(t)
+		memberIDs = append(memberIDs, user.ID)
+	}
+
+	result, err := store.CreateGroupChatTx(context.Background(), CreateGroupChatTxParams{
+		Title:     "Group chat",
+		OwnerID:   owner.ID,
+		MemberIDs: memberIDs,
+	})
+	require.NoError(t, err)
+	require.True(t, result.Chat.IsGroup)
+	require.Equal(t, "Group chat", result.Chat.Title.String)
+
+	require.Len(t, result.Members, 4)
+	require.Equal(t, owner.ID, result.Members[0].UserID)
+	require.Equal(t, ChatRoleOwner, result.Members[0].Role)
+	for i, member := range result.Members[1:] {
+		require.Equal(t, memberIDs[i], member.UserID)
+		require.Equal(t, ChatRoleMember, member.Role)
+	}
+}
+
+func TestCreateGroupChatTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+
+	owner, _ := createRandomUser(t)
+
+	// Adding the owner twice as a member must fail, leaving no chat behind
+	result, err := store.CreateGroupChatTx(context.Background(), CreateGroupChatTxParams{
+		Title:     "Group chat",
+		OwnerID:   owner.ID,
+		MemberIDs: []int64{owner.ID},
+	})
+	require.Error(t, err)
+
+	_, err = testQueries.GetChat(context.Background(), result.Chat.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestTransferChatOwnershipTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	owner, _ := createRandomUser(t)
+	member, _ := createRandomUser(t)
+
+	chat, err := store.CreateGroupChatTx(context.Background(), CreateGroupChatTxParams{
+		Title:     "Group chat",
+		OwnerID:   owner.ID,
+		MemberIDs: []int64{member.ID},
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferChatOwnershipTx(context.Background(), TransferChatOwnershipTxParams{
+		ChatID:     chat.Chat.ID,
+		FromUserID: owner.ID,
+		ToUserID:   member.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, owner.ID, result.PreviousOwner.UserID)
+	require.Equal(t, ChatRoleAdmin, result.PreviousOwner.Role)
+	require.Equal(t, member.ID, result.Owner.UserID)
+	require.Equal(t, ChatRoleOwner, result.Owner.Role)
+}
+
+func TestSendMessageTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	owner, _ := createRandomUser(t)
+	members := []User{owner}
+	memberIDs := []int64{}
+	for i := 0; i < 2; i++ {
+		user, _ := createRandomUser(t)
+		members = append(members, user)
+		memberIDs = append(memberIDs, user.ID)
+	}
+
+	chat, err := store.CreateGroupChatTx(context.Background(), CreateGroupChatTxParams{
+		Title:     "Group chat",
+		OwnerID:   owner.ID,
+		MemberIDs: memberIDs,
+	})
+	require.NoError(t, err)
+
+	// Running concurrent sends against the same chat, each member sending the same amount of messages
+	n := 5
+	errs := make(chan error)
+	results := make(chan SendMessageTxResult)
+	for i := 0; i < n; i++ {
+		for _, member := range members {
+			go func(fromUserID int64) {
+				result, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+					ChatID:     chat.Chat.ID,
+					FromUserID: fromUserID,
+					Body:       "Hello!",
+				})
+				errs <- err
+				results <- result
+			}(member.ID)
+		}
+	}
+
+	for i := 0; i < n*len(members); i++ {
+		err := <-errs
+		require.NoError(t, err)
+
+		result := <-results
+		require.NotEmpty(t, result.Message)
+		require.Equal(t, chat.Chat.ID, result.Message.ChatID)
+		require.False(t, result.Message.ToUserID.Valid)
+
+		// The chat's last activity must be bumped by the message
+		require.True(t, result.Chat.LastMessageReceivedAt.Valid)
+		require.False(t, result.Chat.LastMessageReceivedAt.Time.Before(result.Message.SentAt))
+
+		// Every member but the sender must receive the message
+		require.Len(t, result.Recipients, len(members)-1)
+		for _, recipient := range result.Recipients {
+			require.NotEqual(t, result.Message.FromUserID, recipient.UserID)
+			require.Positive(t, recipient.UnreadCount)
+		}
+	}
+
+	// Each member received the messages sent by the others, without losing any increment
+	chatMembers, err := testQueries.ListChatMembers(context.Background(), chat.Chat.ID)
+	require.NoError(t, err)
+	require.Len(t, chatMembers, len(members))
+	for _, member := range chatMembers {
+		require.Equal(t, int32(n*(len(members)-1)), member.UnreadCount)
+	}
+}
+
+func TestSendMessageTxDirectChat(t *testing.T) {
+	store := NewStore(testDB)
+
+	user1, _ := createRandomUser(t)
+	user2, _ := createRandomUser(t)
+
+	chat, err := store.CreateDirectChatTx(context.Background(), CreateChatParams{
+		FromUserID: sql.NullInt64{Int64: user1.ID, Valid: true},
+		ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// Sending concurrent messages from a single user
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.SendMessageTx(context.Background(), SendMessageTxParams{
+				ChatID:     chat.Chat.ID,
+				FromUserID: user1.ID,
+				ToUserID:   sql.NullInt64{Int64: user2.ID, Valid: true},
+				Body:       "Hello!",
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	// Only the recipient has unread messages
+	sender, err := testQueries.GetChatMember(context.Background(), GetChatMemberParams{
+		ChatID: chat.Chat.ID,
+		UserID: user1.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, sender.UnreadCount)
+
+	recipient, err := testQueries.GetChatMember(context.Background(), GetChatMemberParams{
+		ChatID: chat.Chat.ID,
+		UserID: user2.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(n), recipient.UnreadCount)
+}
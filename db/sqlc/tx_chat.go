@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Roles a user can have on a chat
+const (
+	ChatRoleOwner  = "owner"
+	ChatRoleAdmin  = "admin"
+	ChatRoleMember = "member"
+)
+
+// CreateChatTxResult is the result of the chat creation transactions
+type CreateChatTxResult struct {
+	Chat    Chat         `json:"chat"`
+	Members []ChatMember `json:"members"`
+}
+
+// CreateDirectChatTx creates a chat between two users, adding both as its members
+func (store *SQLStore) CreateDirectChatTx(ctx context.Context, arg CreateChatParams) (CreateChatTxResult, error) {
+	var result CreateChatTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Chat, err = q.CreateChat(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range []sql.NullInt64{arg.FromUserID, arg.ToUserID} {
+			member, err := q.AddChatMember(ctx, AddChatMemberParams{
+				ChatID: result.Chat.ID,
+				UserID: userID.Int64,
+				Role:   ChatRoleMember,
+			})
+			if err != nil {
+				return err
+			}
+			result.Members = append(result.Members, member)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// CreateGroupChatTxParams contains the input parameters of the group chat creation transaction
+type CreateGroupChatTxParams struct {
+	Title     string  `json:"title"`
+	OwnerID   int64   `json:"owner_id"`
+	MemberIDs []int64 `json:"member_ids"`
+}
+
+// CreateGroupChatTx creates a group chat owned by a user, adding the initial members to it
+func (store *SQLStore) CreateGroupChatTx(ctx context.Context, arg CreateGroupChatTxParams) (CreateChatTxResult, error) {
+	var result CreateChatTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Chat, err = q.CreateGroupChat(ctx, sql.NullString{String: arg.Title, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		owner, err := q.AddChatMember(ctx, AddChatMemberParams{
+			ChatID: result.Chat.ID,
+			UserID: arg.OwnerID,
+			Role:   ChatRoleOwner,
+		})
+		if err != nil {
+			return err
+		}
+		result.Members = append(result.Members, owner)
+
+		for _, userID := range arg.MemberIDs {
+			member, err := q.AddChatMember(ctx, AddChatMemberParams{
+				ChatID: result.Chat.ID,
+				UserID: userID,
+				Role:   ChatRoleMember,
+			})
+			if err != nil {
+				return err
+			}
+			result.Members = append(result.Members, member)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// TransferChatOwnershipTxParams contains the input parameters of the ownership transfer transaction
+type TransferChatOwnershipTxParams struct {
+	ChatID     int64 `json:"chat_id"`
+	FromUserID int64 `json:"from_user_id"`
+	ToUserID   int64 `json:"to_user_id"`
+}
+
+// TransferChatOwnershipTxResult is the result of the ownership transfer transaction
+type TransferChatOwnershipTxResult struct {
+	PreviousOwner ChatMember `json:"previous_owner"`
+	Owner         ChatMember `json:"owner"`
+}
+
+// TransferChatOwnershipTx makes another member the owner of the chat, while the previous owner becomes an admin
+func (store *SQLStore) TransferChatOwnershipTx(ctx context.Context, arg TransferChatOwnershipTxParams) (TransferChatOwnershipTxResult, error) {
+	var result TransferChatOwnershipTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PreviousOwner, err = q.UpdateChatMemberRole(ctx, UpdateChatMemberRoleParams{
+			ChatID: arg.ChatID,
+			UserID: arg.FromUserID,
+			Role:   ChatRoleAdmin,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Owner, err = q.UpdateChatMemberRole(ctx, UpdateChatMemberRoleParams{
+			ChatID: arg.ChatID,
+			UserID: arg.ToUserID,
+			Role:   ChatRoleOwner,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrInvalidAttachments is returned when some attachment can't be sent with the message
+var ErrInvalidAttachments = errors.New("attachments were not found or were already sent")
+
+// SendMessageTxParams contains the input parameters of the message sending transaction
+type SendMessageTxParams struct {
+	ChatID     int64         `json:"chat_id"`
+	FromUserID int64         `json:"from_user_id"`
+	ToUserID   sql.NullInt64 `json:"to_user_id"`
+	Body       string        `json:"body"`
+	// Attachments previously uploaded by the sender
+	AttachmentIDs []int64 `json:"attachment_ids"`
+}
+
+// SendMessageTxResult is the result of the message sending transaction
+type SendMessageTxResult struct {
+	Message Message `json:"message"`
+	Chat    Chat    `json:"chat"`
+	// Members of the chat which received the message, with their updated unread counters
+	Recipients  []ChatMember `json:"recipients"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// SendMessageTx creates a message with its attachments, updating the chat's last activity and the unread counters of its recipients
+func (store *SQLStore) SendMessageTx(ctx context.Context, arg SendMessageTxParams) (SendMessageTxResult, error) {
+	var result SendMessageTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Message, err = q.CreateMessage(ctx, CreateMessageParams{
+			ChatID:     arg.ChatID,
+			FromUserID: arg.FromUserID,
+			ToUserID:   arg.ToUserID,
+			Body:       arg.Body,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Only attachments uploaded by the sender and not sent yet can be used
+		result.Attachments = []Attachment{}
+		if len(arg.AttachmentIDs) > 0 {
+			result.Attachments, err = q.AttachToMessage(ctx, AttachToMessageParams{
+				MessageID:  result.Message.ID,
+				Ids:        arg.AttachmentIDs,
+				UploaderID: arg.FromUserID,
+			})
+			if err != nil {
+				return err
+			}
+			if len(result.Attachments) != len(arg.AttachmentIDs) {
+				return ErrInvalidAttachments
+			}
+		}
+
+		// Locking the chat row first makes concurrent sends on the same chat wait for each other
+		result.Chat, err = q.UpdateChat(ctx, arg.ChatID)
+		if err != nil {
+			return err
+		}
+
+		result.Recipients, err = q.IncrementUnreadCounts(ctx, IncrementUnreadCountsParams{
+			ChatID:   arg.ChatID,
+			SenderID: arg.FromUserID,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// EditMessageTxResult is the result of the message editing transaction
+type EditMessageTxResult struct {
+	Message  Message         `json:"message"`
+	Revision MessageRevision `json:"revision"`
+}
+
+// EditMessageTx updates the body of a message, keeping the previous one as a revision
+func (store *SQLStore) EditMessageTx(ctx context.Context, arg UpdateMessageParams) (EditMessageTxResult, error) {
+	var result EditMessageTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// Locking the message, so concurrent edits don't lose any revision
+		message, err := q.GetMessageForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Revision, err = q.CreateMessageRevision(ctx, CreateMessageRevisionParams{
+			MessageID: message.ID,
+			Body:      message.Body,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Message, err = q.UpdateMessage(ctx, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// DeleteMessageForEveryoneTx replaces a message by a tombstone, dropping its revisions so no previous body is kept
+func (store *SQLStore) DeleteMessageForEveryoneTx(ctx context.Context, id int64) (Message, error) {
+	var message Message
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		message, err = q.TombstoneMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteMessageRevisions(ctx, id)
+	})
+
+	return message, err
+}
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// CreateUserTxParams contains the input parameters of the user creation transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	// The email verification is only created for users with an email
+	VerifyCodeHash  string
+	VerifyExpiresAt time.Time
+}
+
+// CreateUserTxResult is the result of the user creation transaction
+type CreateUserTxResult struct {
+	User              User              `json:"user"`
+	EmailVerification EmailVerification `json:"email_verification"`
+}
+
+// CreateUserTx creates a user along with the verification of its email
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if result.User.Email.Valid {
+			result.EmailVerification, err = q.CreateEmailVerification(ctx, CreateEmailVerificationParams{
+				UserID:    result.User.ID,
+				Email:     result.User.Email.String,
+				CodeHash:  arg.VerifyCodeHash,
+				ExpiresAt: arg.VerifyExpiresAt,
+			})
+			return err
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// VerifyEmailTxResult is the result of the email verification transaction
+type VerifyEmailTxResult struct {
+	User              User              `json:"user"`
+	EmailVerification EmailVerification `json:"email_verification"`
+}
+
+// VerifyEmailTx uses up the email verification, marking the user's email as verified
+// It fails with sql.ErrNoRows if the code is wrong, expired or used, or if the user changed its email since then
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg UseEmailVerificationParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.EmailVerification, err = q.UseEmailVerification(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			ID:    result.EmailVerification.UserID,
+			Email: result.EmailVerification.Email,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// RecordLoginTxResult is the result of the login recording transaction
+type RecordLoginTxResult struct {
+	Event LoginEvent `json:"event"`
+	// The user with its last login updated, only filled on successful logins
+	User User `json:"user"`
+}
+
+// RecordLoginTx records a login attempt, updating the user's last login when it succeeded
+func (store *SQLStore) RecordLoginTx(ctx context.Context, arg CreateLoginEventParams) (RecordLoginTxResult, error) {
+	var result RecordLoginTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Event, err = q.CreateLoginEvent(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		if !arg.Succeeded {
+			return nil
+		}
+		result.User, err = q.UpdateUserLastLogin(ctx, arg.UserID)
+		return err
+	})
+
+	return result, err
+}
+
+// ResetPasswordTxParams contains the input parameters of the password reset transaction
+type ResetPasswordTxParams struct {
+	TokenHash         string    `json:"token_hash"`
+	HashPass          string    `json:"hash_pass"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+// ResetPasswordTxResult is the result of the password reset transaction
+type ResetPasswordTxResult struct {
+	User          User          `json:"user"`
+	PasswordReset PasswordReset `json:"password_reset"`
+}
+
+// ResetPasswordTx uses up the password reset, changing the user's password and expiring its other resets
+// It fails with sql.ErrNoRows if the token is wrong, expired or used
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UsePasswordReset(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.ChangeUserPassword(ctx, ChangeUserPasswordParams{
+			ID:                result.PasswordReset.UserID,
+			HashPass:          arg.HashPass,
+			PasswordChangedAt: arg.PasswordChangedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.ExpireUserPasswordResets(ctx, result.User.ID)
+	})
+
+	return result, err
+}
+
+// EnableTOTPTxParams contains the input parameters of the TOTP enabling transaction
+type EnableTOTPTxParams struct {
+	UserID             int64    `json:"user_id"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+// EnableTOTPTx enables the user's pending TOTP secret, replacing its recovery codes with new ones
+// It fails with sql.ErrNoRows if the user has no TOTP secret
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.EnableUserTOTP(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteUserRecoveryCodes(ctx, arg.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				UserID:   arg.UserID,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return user, err
+}
+
+// DisableTOTPTx removes the user's TOTP secret along with its recovery codes
+func (store *SQLStore) DisableTOTPTx(ctx context.Context, userID int64) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.DisableUserTOTP(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteUserRecoveryCodes(ctx, userID)
+	})
+
+	return user, err
+}
@@ -8,24 +8,26 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const changeUserPassword = `-- name: ChangeUserPassword :one
 UPDATE users
 SET
   hash_pass = $2,
-  password_changed_at = now()
+  password_changed_at = $3
 WHERE id = $1
-RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
 `
 
 type ChangeUserPasswordParams struct {
-	ID       int64  `json:"id"`
-	HashPass string `json:"hash_pass"`
+	ID                int64     `json:"id"`
+	HashPass          string    `json:"hash_pass"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
 }
 
 func (q *Queries) ChangeUserPassword(ctx context.Context, arg ChangeUserPasswordParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, changeUserPassword, arg.ID, arg.HashPass)
+	row := q.db.QueryRowContext(ctx, changeUserPassword, arg.ID, arg.HashPass, arg.PasswordChangedAt)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +39,13 @@ func (q *Queries) ChangeUserPassword(ctx context.Context, arg ChangeUserPassword
 		&i.LastLoginAt,
 		&i.HashPass,
 		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
@@ -49,7 +58,7 @@ INSERT INTO users (
   hash_pass
 ) VALUES (
   $1, $2, $3, $4
-) RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at
+) RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
 `
 
 type CreateUserParams struct {
@@ -77,6 +86,13 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.LastLoginAt,
 		&i.HashPass,
 		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
@@ -90,8 +106,108 @@ func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
 	return err
 }
 
+const disableUserTOTP = `-- name: DisableUserTOTP :one
+UPDATE users
+SET
+  totp_secret = NULL,
+  is_totp_enabled = false,
+  totp_last_counter = NULL
+WHERE id = $1
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+func (q *Queries) DisableUserTOTP(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, disableUserTOTP, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const enableUserTOTP = `-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true
+WHERE
+  id = $1 AND
+  totp_secret IS NOT NULL
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+func (q *Queries) EnableUserTOTP(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, enableUserTOTP, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getSystemStats = `-- name: GetSystemStats :one
+SELECT
+  (SELECT count(*) FROM users) AS users,
+  (SELECT count(*) FROM users WHERE suspended_at IS NOT NULL) AS suspended_users,
+  (SELECT count(*) FROM chats) AS chats,
+  (SELECT count(*) FROM messages) AS messages,
+  (SELECT count(*) FROM messages WHERE sent_at > $1::timestamptz) AS recent_messages,
+  (SELECT count(*) FROM sessions WHERE NOT is_blocked AND expires_at > now()) AS active_sessions
+`
+
+type GetSystemStatsRow struct {
+	Users          int64 `json:"users"`
+	SuspendedUsers int64 `json:"suspended_users"`
+	Chats          int64 `json:"chats"`
+	Messages       int64 `json:"messages"`
+	RecentMessages int64 `json:"recent_messages"`
+	ActiveSessions int64 `json:"active_sessions"`
+}
+
+func (q *Queries) GetSystemStats(ctx context.Context, since time.Time) (GetSystemStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getSystemStats, since)
+	var i GetSystemStatsRow
+	err := row.Scan(
+		&i.Users,
+		&i.SuspendedUsers,
+		&i.Chats,
+		&i.Messages,
+		&i.RecentMessages,
+		&i.ActiveSessions,
+	)
+	return i, err
+}
+
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at FROM users
+SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at FROM users
 WHERE id = $1 LIMIT 1
 `
 
@@ -108,12 +224,48 @@ func (q *Queries) GetUser(ctx context.Context, id int64) (User, error) {
 		&i.LastLoginAt,
 		&i.HashPass,
 		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at FROM users
+WHERE email = $1::varchar LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at FROM users
+SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -130,10 +282,123 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.LastLoginAt,
 		&i.HashPass,
 		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
 
+const listAllUsers = `-- name: ListAllUsers :many
+SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at FROM users
+WHERE
+  $1::bigint IS NULL OR
+  id > $1::bigint
+ORDER BY id
+LIMIT $2
+`
+
+type ListAllUsersParams struct {
+	CursorID sql.NullInt64 `json:"cursor_id"`
+	Limit    int32         `json:"limit"`
+}
+
+func (q *Queries) ListAllUsers(ctx context.Context, arg ListAllUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listAllUsers, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.FullName,
+			&i.Username,
+			&i.Email,
+			&i.AvatarUrl,
+			&i.LastLoginAt,
+			&i.HashPass,
+			&i.PasswordChangedAt,
+			&i.TokensRevokedAt,
+			&i.IsEmailVerified,
+			&i.TotpSecret,
+			&i.IsTotpEnabled,
+			&i.TotpLastCounter,
+			&i.Role,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllUsersBefore = `-- name: ListAllUsersBefore :many
+SELECT id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at FROM users
+WHERE id < $1::bigint
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListAllUsersBeforeParams struct {
+	CursorID int64 `json:"cursor_id"`
+	Limit    int32 `json:"limit"`
+}
+
+func (q *Queries) ListAllUsersBefore(ctx context.Context, arg ListAllUsersBeforeParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listAllUsersBefore, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.FullName,
+			&i.Username,
+			&i.Email,
+			&i.AvatarUrl,
+			&i.LastLoginAt,
+			&i.HashPass,
+			&i.PasswordChangedAt,
+			&i.TokensRevokedAt,
+			&i.IsEmailVerified,
+			&i.TotpSecret,
+			&i.IsTotpEnabled,
+			&i.TotpLastCounter,
+			&i.Role,
+			&i.SuspendedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
 SELECT
   id,
@@ -143,14 +408,24 @@ SELECT
   avatar_url,
   last_login_at
 FROM users
+WHERE
+  -- Users who blocked the one listing are hidden from it
+  NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE
+      user_blocks.blocker_id = users.id AND
+      user_blocks.blocked_id = $1
+  ) AND
+  ($2::bigint IS NULL OR
+  id > $2::bigint)
 ORDER BY id
-LIMIT $1
-OFFSET $2
+LIMIT $3
 `
 
 type ListUsersParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	UserID   int64         `json:"user_id"`
+	CursorID sql.NullInt64 `json:"cursor_id"`
+	Limit    int32         `json:"limit"`
 }
 
 type ListUsersRow struct {
@@ -163,7 +438,7 @@ type ListUsersRow struct {
 }
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error) {
-	rows, err := q.db.QueryContext(ctx, listUsers, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listUsers, arg.UserID, arg.CursorID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -192,15 +467,188 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUse
 	return items, nil
 }
 
+const listUsersBefore = `-- name: ListUsersBefore :many
+SELECT
+  id,
+  full_name,
+  username,
+  email,
+  avatar_url,
+  last_login_at
+FROM users
+WHERE
+  NOT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE
+      user_blocks.blocker_id = users.id AND
+      user_blocks.blocked_id = $1
+  ) AND
+  id < $2::bigint
+ORDER BY id DESC
+LIMIT $3
+`
+
+type ListUsersBeforeParams struct {
+	UserID   int64 `json:"user_id"`
+	CursorID int64 `json:"cursor_id"`
+	Limit    int32 `json:"limit"`
+}
+
+type ListUsersBeforeRow struct {
+	ID          int64          `json:"id"`
+	FullName    string         `json:"full_name"`
+	Username    string         `json:"username"`
+	Email       sql.NullString `json:"email"`
+	AvatarUrl   sql.NullString `json:"avatar_url"`
+	LastLoginAt sql.NullTime   `json:"last_login_at"`
+}
+
+func (q *Queries) ListUsersBefore(ctx context.Context, arg ListUsersBeforeParams) ([]ListUsersBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersBefore, arg.UserID, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUsersBeforeRow{}
+	for rows.Next() {
+		var i ListUsersBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FullName,
+			&i.Username,
+			&i.Email,
+			&i.AvatarUrl,
+			&i.LastLoginAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setUserTOTPSecret = `-- name: SetUserTOTPSecret :one
+UPDATE users
+SET
+  totp_secret = $1::varchar,
+  totp_last_counter = NULL
+WHERE
+  id = $2 AND
+  is_totp_enabled = false
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+type SetUserTOTPSecretParams struct {
+	TotpSecret string `json:"totp_secret"`
+	ID         int64  `json:"id"`
+}
+
+func (q *Queries) SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserTOTPSecret, arg.TotpSecret, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const suspendUser = `-- name: SuspendUser :one
+UPDATE users
+SET suspended_at = now()
+WHERE
+  id = $1 AND
+  suspended_at IS NULL
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+func (q *Queries) SuspendUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, suspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const unsuspendUser = `-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_at = NULL
+WHERE
+  id = $1 AND
+  suspended_at IS NOT NULL
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+func (q *Queries) UnsuspendUser(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, unsuspendUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
   full_name = $2,
   username = $3,
   email = $4,
-  avatar_url = $5
+  avatar_url = $5,
+  is_email_verified = is_email_verified AND email IS NOT DISTINCT FROM $4
 WHERE id = $1
-RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
 `
 
 type UpdateUserParams struct {
@@ -230,6 +678,121 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.LastLoginAt,
 		&i.HashPass,
 		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const updateUserLastLogin = `-- name: UpdateUserLastLogin :one
+UPDATE users
+SET last_login_at = now()
+WHERE id = $1
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+func (q *Queries) UpdateUserLastLogin(ctx context.Context, id int64) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserLastLogin, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const useUserTOTPCounter = `-- name: UseUserTOTPCounter :one
+UPDATE users
+SET totp_last_counter = $1::bigint
+WHERE
+  id = $2 AND
+  (totp_last_counter IS NULL OR
+  totp_last_counter < $1::bigint)
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+type UseUserTOTPCounterParams struct {
+	Counter int64 `json:"counter"`
+	ID      int64 `json:"id"`
+}
+
+func (q *Queries) UseUserTOTPCounter(ctx context.Context, arg UseUserTOTPCounterParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, useUserTOTPCounter, arg.Counter, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
+	)
+	return i, err
+}
+
+const verifyUserEmail = `-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true
+WHERE
+  id = $1 AND
+  email = $2::varchar
+RETURNING id, created_at, full_name, username, email, avatar_url, last_login_at, hash_pass, password_changed_at, tokens_revoked_at, is_email_verified, totp_secret, is_totp_enabled, totp_last_counter, role, suspended_at
+`
+
+type VerifyUserEmailParams struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.FullName,
+		&i.Username,
+		&i.Email,
+		&i.AvatarUrl,
+		&i.LastLoginAt,
+		&i.HashPass,
+		&i.PasswordChangedAt,
+		&i.TokensRevokedAt,
+		&i.IsEmailVerified,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastCounter,
+		&i.Role,
+		&i.SuspendedAt,
 	)
 	return i, err
 }
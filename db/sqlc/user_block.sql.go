@@ -0,0 +1,188 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.19.1
+// source: user_block.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const blockUser = `-- name: BlockUser :one
+INSERT INTO user_blocks (
+  blocker_id,
+  blocked_id
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (blocker_id, blocked_id) DO UPDATE
+SET created_at = user_blocks.created_at
+RETURNING blocker_id, blocked_id, created_at
+`
+
+type BlockUserParams struct {
+	BlockerID int64 `json:"blocker_id"`
+	BlockedID int64 `json:"blocked_id"`
+}
+
+func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) (UserBlock, error) {
+	row := q.db.QueryRowContext(ctx, blockUser, arg.BlockerID, arg.BlockedID)
+	var i UserBlock
+	err := row.Scan(&i.BlockerID, &i.BlockedID, &i.CreatedAt)
+	return i, err
+}
+
+const isBlockedBetween = `-- name: IsBlockedBetween :one
+SELECT EXISTS (
+  SELECT 1 FROM user_blocks
+  WHERE
+    (blocker_id = $1 AND blocked_id = $2) OR
+    (blocker_id = $2 AND blocked_id = $1)
+)::bool
+`
+
+type IsBlockedBetweenParams struct {
+	UserID      int64 `json:"user_id"`
+	OtherUserID int64 `json:"other_user_id"`
+}
+
+func (q *Queries) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isBlockedBetween, arg.UserID, arg.OtherUserID)
+	var column_1 bool
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listBlockedUsers = `-- name: ListBlockedUsers :many
+SELECT
+  users.id,
+  users.full_name,
+  users.username,
+  user_blocks.created_at AS blocked_at
+FROM user_blocks
+JOIN users ON users.id = user_blocks.blocked_id
+WHERE
+  user_blocks.blocker_id = $1 AND
+  ($2::bigint IS NULL OR
+  users.id > $2::bigint)
+ORDER BY users.id
+LIMIT $3
+`
+
+type ListBlockedUsersParams struct {
+	BlockerID int64         `json:"blocker_id"`
+	CursorID  sql.NullInt64 `json:"cursor_id"`
+	Limit     int32         `json:"limit"`
+}
+
+type ListBlockedUsersRow struct {
+	ID        int64     `json:"id"`
+	FullName  string    `json:"full_name"`
+	Username  string    `json:"username"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+func (q *Queries) ListBlockedUsers(ctx context.Context, arg ListBlockedUsersParams) ([]ListBlockedUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockedUsers, arg.BlockerID, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBlockedUsersRow{}
+	for rows.Next() {
+		var i ListBlockedUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FullName,
+			&i.Username,
+			&i.BlockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlockedUsersBefore = `-- name: ListBlockedUsersBefore :many
+SELECT
+  users.id,
+  users.full_name,
+  users.username,
+  user_blocks.created_at AS blocked_at
+FROM user_blocks
+JOIN users ON users.id = user_blocks.blocked_id
+WHERE
+  user_blocks.blocker_id = $1 AND
+  users.id < $2::bigint
+ORDER BY users.id DESC
+LIMIT $3
+`
+
+type ListBlockedUsersBeforeParams struct {
+	BlockerID int64 `json:"blocker_id"`
+	CursorID  int64 `json:"cursor_id"`
+	Limit     int32 `json:"limit"`
+}
+
+type ListBlockedUsersBeforeRow struct {
+	ID        int64     `json:"id"`
+	FullName  string    `json:"full_name"`
+	Username  string    `json:"username"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+func (q *Queries) ListBlockedUsersBefore(ctx context.Context, arg ListBlockedUsersBeforeParams) ([]ListBlockedUsersBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBlockedUsersBefore, arg.BlockerID, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBlockedUsersBeforeRow{}
+	for rows.Next() {
+		var i ListBlockedUsersBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FullName,
+			&i.Username,
+			&i.BlockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unblockUser = `-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1 AND blocked_id = $2
+`
+
+type UnblockUserParams struct {
+	BlockerID int64 `json:"blocker_id"`
+	BlockedID int64 `json:"blocked_id"`
+}
+
+func (q *Queries) UnblockUser(ctx context.Context, arg UnblockUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unblockUser, arg.BlockerID, arg.BlockedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockUser(t *testing.T) {
+	blocker, _ := createRandomUser(t)
+	blocked, _ := createRandomUser(t)
+
+	arg := BlockUserParams{
+		BlockerID: blocker.ID,
+		BlockedID: blocked.ID,
+	}
+	block, err := testQueries.BlockUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, blocker.ID, block.BlockerID)
+	require.Equal(t, blocked.ID, block.BlockedID)
+	require.WithinDuration(t, time.Now(), block.CreatedAt, time.Second)
+
+	// Blocking again keeps the original block
+	sameBlock, err := testQueries.BlockUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, block.CreatedAt, sameBlock.CreatedAt)
+
+	// The block is seen from both sides
+	for _, pair := range [][2]int64{{blocker.ID, blocked.ID}, {blocked.ID, blocker.ID}} {
+		isBlocked, err := testQueries.IsBlockedBetween(context.Background(), IsBlockedBetweenParams{
+			UserID:      pair[0],
+			OtherUserID: pair[1],
+		})
+		require.NoError(t, err)
+		require.True(t, isBlocked)
+	}
+
+	// A user can't block itself
+	_, err = testQueries.BlockUser(context.Background(), BlockUserParams{
+		BlockerID: blocker.ID,
+		BlockedID: blocker.ID,
+	})
+	require.Error(t, err)
+}
+
+func TestUnblockUser(t *testing.T) {
+	blocker, _ := createRandomUser(t)
+	blocked, _ := createRandomUser(t)
+	testQueries.BlockUser(context.Background(), BlockUserParams{
+		BlockerID: blocker.ID,
+		BlockedID: blocked.ID,
+	})
+
+	// Only the blocker can remove the block
+	removed, err := testQueries.UnblockUser(context.Background(), UnblockUserParams{
+		BlockerID: blocked.ID,
+		BlockedID: blocker.ID,
+	})
+	require.NoError(t, err)
+	require.Zero(t, removed)
+
+	removed, err = testQueries.UnblockUser(context.Background(), UnblockUserParams{
+		BlockerID: blocker.ID,
+		BlockedID: blocked.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), removed)
+
+	isBlocked, err := testQueries.IsBlockedBetween(context.Background(), IsBlockedBetweenParams{
+		UserID:      blocker.ID,
+		OtherUserID: blocked.ID,
+	})
+	require.NoError(t, err)
+	require.False(t, isBlocked)
+}
+
+func TestListBlockedUsers(t *testing.T) {
+	blocker, _ := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		blocked, _ := createRandomUser(t)
+		testQueries.BlockUser(context.Background(), BlockUserParams{
+			BlockerID: blocker.ID,
+			BlockedID: blocked.ID,
+		})
+	}
+
+	blockedUsers, err := testQueries.ListBlockedUsers(context.Background(), ListBlockedUsersParams{
+		BlockerID: blocker.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, blockedUsers, 3)
+
+	// The blocker is hidden from the listing of the blocked users
+	users, err := testQueries.ListUsersBefore(context.Background(), ListUsersBeforeParams{
+		UserID:   blockedUsers[0].ID,
+		CursorID: blocker.ID + 1,
+		Limit:    1,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.NotEqual(t, blocker.ID, users[0].ID)
+}
@@ -156,21 +156,32 @@ func TestDeleteUser(t *testing.T) {
 }
 
 func TestListUsers(t *testing.T) {
+	createdUsers := []User{}
 	for i := 0; i < 10; i++ {
-		createRandomUser(t)
-	}
-
-	args := ListUsersParams{
-		Limit:  5,
-		Offset: 5,
+		user, _ := createRandomUser(t)
+		createdUsers = append(createdUsers, user)
 	}
 
-	users, err := testQueries.ListUsers(context.Background(), args)
+	// Listing the users right after the fifth one
+	users, err := testQueries.ListUsers(context.Background(), ListUsersParams{
+		CursorID: sql.NullInt64{Int64: createdUsers[4].ID, Valid: true},
+		Limit:    5,
+	})
 	require.NoError(t, err)
 	require.Len(t, users, 5)
+	for i, user := range users {
+		require.Equal(t, createdUsers[i+5].ID, user.ID)
+	}
 
-	for _, user := range users {
-		require.NotEmpty(t, user)
+	// Listing them back from the sixth one, closest ones first
+	previousUsers, err := testQueries.ListUsersBefore(context.Background(), ListUsersBeforeParams{
+		CursorID: createdUsers[5].ID,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Len(t, previousUsers, 5)
+	for i, user := range previousUsers {
+		require.Equal(t, createdUsers[4-i].ID, user.ID)
 	}
 }
 
@@ -182,8 +193,9 @@ func TestChangeUserPassword(t *testing.T) {
 	hashpass, _ := util.HashPassword(util.RandomString(int(util.RandomInt(18, 24))))
 
 	args := ChangeUserPasswordParams{
-		ID:       createdUser.ID,
-		HashPass: hashpass,
+		ID:                createdUser.ID,
+		HashPass:          hashpass,
+		PasswordChangedAt: time.Now(),
 	}
 
 	// Changing the newly created user's password
@@ -194,5 +206,63 @@ func TestChangeUserPassword(t *testing.T) {
 
 	require.Equal(t, createdUser.ID, updatedUser.ID)
 	require.Equal(t, args.HashPass, updatedUser.HashPass)
-	require.WithinDuration(t, updatedUser.PasswordChangedAt, time.Now(), time.Second)
+	require.WithinDuration(t, args.PasswordChangedAt, updatedUser.PasswordChangedAt, time.Millisecond)
+}
+
+func TestSuspendUser(t *testing.T) {
+	user, _ := createRandomUser(t)
+	require.Equal(t, util.UserRole, user.Role)
+	require.False(t, user.SuspendedAt.Valid)
+
+	suspendedUser, err := testQueries.SuspendUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, suspendedUser.SuspendedAt.Valid)
+	require.WithinDuration(t, time.Now(), suspendedUser.SuspendedAt.Time, time.Second)
+
+	// Suspending again keeps the original suspension
+	_, err = testQueries.SuspendUser(context.Background(), user.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	unsuspendedUser, err := testQueries.UnsuspendUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.False(t, unsuspendedUser.SuspendedAt.Valid)
+
+	_, err = testQueries.UnsuspendUser(context.Background(), user.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListAllUsers(t *testing.T) {
+	first, _ := createRandomUser(t)
+	for i := 0; i < 2; i++ {
+		createRandomUser(t)
+	}
+
+	users, err := testQueries.ListAllUsers(context.Background(), ListAllUsersParams{
+		CursorID: sql.NullInt64{Int64: first.ID - 1, Valid: true},
+		Limit:    3,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	require.Equal(t, first.ID, users[0].ID)
+
+	users, err = testQueries.ListAllUsersBefore(context.Background(), ListAllUsersBeforeParams{
+		CursorID: users[2].ID,
+		Limit:    2,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, first.ID, users[1].ID)
+}
+
+func TestGetSystemStats(t *testing.T) {
+	user, _ := createRandomUser(t)
+	_, err := testQueries.SuspendUser(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	stats, err := testQueries.GetSystemStats(context.Background(), time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	require.Positive(t, stats.Users)
+	require.Positive(t, stats.SuspendedUsers)
+	require.LessOrEqual(t, stats.SuspendedUsers, stats.Users)
+	require.LessOrEqual(t, stats.RecentMessages, stats.Messages)
 }
@@ -0,0 +1,74 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Policy defines how attempts are backed off after too many failures
+type Policy struct {
+	// Failures allowed before backing off
+	MaxAttempts int
+	// Delay after the first failure over the limit, doubled on each further one
+	BaseDelay time.Duration
+	// Longest delay, which locks the key out for a while
+	MaxDelay time.Duration
+}
+
+// validate checks that the policy makes sense before using it
+func (policy Policy) validate() error {
+	if policy.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be at least 1")
+	}
+	if policy.BaseDelay <= 0 || policy.MaxDelay < policy.BaseDelay {
+		return fmt.Errorf("delays must be positive, with the max delay at least the base one")
+	}
+	return nil
+}
+
+// forgetAfter is how long failures are kept without new ones, so keys which keep failing stay locked out
+func (policy Policy) forgetAfter() time.Duration {
+	return 2 * policy.MaxDelay
+}
+
+// delay gets how long a key must wait after a number of consecutive failures
+func (policy Policy) delay(failures int) time.Duration {
+	if failures < policy.MaxAttempts {
+		return 0
+	}
+
+	delay := policy.BaseDelay
+	for i := policy.MaxAttempts; i < failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
+
+// retryAfter gets how long a key must still wait, given its failures and when the last one happened
+func (policy Policy) retryAfter(failures int, lastFailedAt time.Time, now time.Time) time.Duration {
+	if now.Sub(lastFailedAt) >= policy.forgetAfter() {
+		return 0
+	}
+
+	wait := lastFailedAt.Add(policy.delay(failures)).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Limiter is an interface for tracking failed attempts, such as logins, backing off the keys which keep failing
+type Limiter interface {
+	// RetryAfter returns how long to wait before attempting a key again, which is zero when it's allowed now
+	RetryAfter(ctx context.Context, key string) (time.Duration, error)
+
+	// Fail records a failed attempt for a key, returning how long it must wait before the next one
+	Fail(ctx context.Context, key string) (time.Duration, error)
+
+	// Reset forgets the failed attempts for a key
+	Reset(ctx context.Context, key string) error
+}
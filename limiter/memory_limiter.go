@@ -0,0 +1,92 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryEntry holds the consecutive failures for a single key
+type memoryEntry struct {
+	failures     int
+	lastFailedAt time.Time
+}
+
+// MemoryLimiter is a limiter which keeps the failures on the instance memory
+// It's meant for tests and single instance deployments, since each instance counts failures on its own
+type MemoryLimiter struct {
+	policy     Policy
+	now        func() time.Time
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	lastPruned time.Time
+}
+
+// NewMemoryLimiter creates a new MemoryLimiter backing off keys according to the policy
+func NewMemoryLimiter(policy Policy) (Limiter, error) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	limiter := &MemoryLimiter{
+		policy:     policy,
+		now:        time.Now,
+		entries:    make(map[string]memoryEntry),
+		lastPruned: time.Now(),
+	}
+	return limiter, nil
+}
+
+// RetryAfter returns how long to wait before attempting a key again, which is zero when it's allowed now
+func (limiter *MemoryLimiter) RetryAfter(ctx context.Context, key string) (time.Duration, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	entry, ok := limiter.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	return limiter.policy.retryAfter(entry.failures, entry.lastFailedAt, limiter.now()), nil
+}
+
+// Fail records a failed attempt for a key, returning how long it must wait before the next one
+func (limiter *MemoryLimiter) Fail(ctx context.Context, key string) (time.Duration, error) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	limiter.pruneLocked(now)
+
+	entry := limiter.entries[key]
+	if now.Sub(entry.lastFailedAt) >= limiter.policy.forgetAfter() {
+		entry.failures = 0
+	}
+	entry.failures++
+	entry.lastFailedAt = now
+	limiter.entries[key] = entry
+
+	return limiter.policy.delay(entry.failures), nil
+}
+
+// Reset forgets the failed attempts for a key
+func (limiter *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	limiter.mu.Lock()
+	delete(limiter.entries, key)
+	limiter.mu.Unlock()
+
+	return nil
+}
+
+// pruneLocked drops the keys whose failures were already forgotten, it must be called with the lock held
+func (limiter *MemoryLimiter) pruneLocked(now time.Time) {
+	if now.Sub(limiter.lastPruned) < limiter.policy.forgetAfter() {
+		return
+	}
+	limiter.lastPruned = now
+
+	for key, entry := range limiter.entries {
+		if now.Sub(entry.lastFailedAt) >= limiter.policy.forgetAfter() {
+			delete(limiter.entries, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	require.Zero(t, policy.delay(2))
+	require.Equal(t, time.Second, policy.delay(3))
+	require.Equal(t, 2*time.Second, policy.delay(4))
+	require.Equal(t, 8*time.Second, policy.delay(6))
+	require.Equal(t, 10*time.Second, policy.delay(7))
+	require.Equal(t, 10*time.Second, policy.delay(100))
+
+	_, err := NewMemoryLimiter(Policy{MaxAttempts: 0, BaseDelay: time.Second, MaxDelay: time.Second})
+	require.Error(t, err)
+	_, err = NewMemoryLimiter(Policy{MaxAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Second})
+	require.Error(t, err)
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	policy := Policy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	store, err := NewMemoryLimiter(policy)
+	require.NoError(t, err)
+
+	// The limiter clock is moved forward by hand
+	now := time.Now()
+	limiter := store.(*MemoryLimiter)
+	limiter.now = func() time.Time { return now }
+
+	key := util.RandomString(8)
+	ctx := context.Background()
+
+	wait, err := limiter.Fail(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	wait, err = limiter.Fail(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, wait)
+
+	wait, err = limiter.RetryAfter(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, wait)
+
+	// Other keys aren't affected
+	wait, err = limiter.RetryAfter(ctx, util.RandomString(8))
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	now = now.Add(time.Minute)
+	wait, err = limiter.RetryAfter(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	// Further failures double the delay
+	wait, err = limiter.Fail(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Minute, wait)
+
+	// Failures are forgotten a while after the last one
+	now = now.Add(policy.forgetAfter())
+	wait, err = limiter.Fail(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	limiter.Fail(ctx, key)
+	err = limiter.Reset(ctx, key)
+	require.NoError(t, err)
+	wait, err = limiter.RetryAfter(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+}
@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+)
+
+// PostgresLimiter is a limiter which keeps the failures on Postgres, so they're shared by every instance
+type PostgresLimiter struct {
+	policy Policy
+	store  db.Store
+}
+
+// NewPostgresLimiter creates a new PostgresLimiter backing off keys according to the policy
+func NewPostgresLimiter(store db.Store, policy Policy) (Limiter, error) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	limiter := &PostgresLimiter{
+		policy: policy,
+		store:  store,
+	}
+	return limiter, nil
+}
+
+// RetryAfter returns how long to wait before attempting a key again, which is zero when it's allowed now
+func (limiter *PostgresLimiter) RetryAfter(ctx context.Context, key string) (time.Duration, error) {
+	failure, err := limiter.store.GetLoginFailure(ctx, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return limiter.policy.retryAfter(int(failure.Failures), failure.LastFailedAt, time.Now()), nil
+}
+
+// Fail records a failed attempt for a key, returning how long it must wait before the next one
+func (limiter *PostgresLimiter) Fail(ctx context.Context, key string) (time.Duration, error) {
+	// The failures are counted on the database, so concurrent attempts on other instances aren't lost
+	forgetBefore := time.Now().Add(-limiter.policy.forgetAfter())
+	failure, err := limiter.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Key:          key,
+		ForgetBefore: forgetBefore,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Keys which stopped failing a while ago are no longer needed
+	err = limiter.store.DeleteStaleLoginFailures(ctx, forgetBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return limiter.policy.delay(int(failure.Failures)), nil
+}
+
+// Reset forgets the failed attempts for a key
+func (limiter *PostgresLimiter) Reset(ctx context.Context, key string) error {
+	return limiter.store.DeleteLoginFailure(ctx, key)
+}
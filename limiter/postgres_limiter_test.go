@@ -0,0 +1,65 @@
+package limiter
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/renatomh/api-simplechat/db/mock"
+	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresLimiter(t *testing.T) {
+	policy := Policy{MaxAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour}
+	key := util.RandomString(8)
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	limiter, err := NewPostgresLimiter(store, policy)
+	require.NoError(t, err)
+
+	// Keys which never failed have no rows
+	store.EXPECT().
+		GetLoginFailure(gomock.Any(), gomock.Eq(key)).
+		Times(1).
+		Return(db.LoginFailure{}, sql.ErrNoRows)
+	wait, err := limiter.RetryAfter(ctx, key)
+	require.NoError(t, err)
+	require.Zero(t, wait)
+
+	// The failures are counted by the database, forgetting the ones which are too old
+	store.EXPECT().
+		RecordLoginFailure(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RecordLoginFailureParams) (db.LoginFailure, error) {
+			require.Equal(t, key, arg.Key)
+			require.WithinDuration(t, time.Now().Add(-policy.forgetAfter()), arg.ForgetBefore, time.Second)
+			return db.LoginFailure{Key: key, Failures: 3, LastFailedAt: time.Now()}, nil
+		})
+	store.EXPECT().
+		DeleteStaleLoginFailures(gomock.Any(), gomock.Any()).
+		Times(1)
+	wait, err = limiter.Fail(ctx, key)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Minute, wait)
+
+	store.EXPECT().
+		GetLoginFailure(gomock.Any(), gomock.Eq(key)).
+		Times(1).
+		Return(db.LoginFailure{Key: key, Failures: 3, LastFailedAt: time.Now().Add(-time.Minute)}, nil)
+	wait, err = limiter.RetryAfter(ctx, key)
+	require.NoError(t, err)
+	require.InDelta(t, time.Minute, wait, float64(time.Second))
+
+	store.EXPECT().
+		DeleteLoginFailure(gomock.Any(), gomock.Eq(key)).
+		Times(1)
+	require.NoError(t, limiter.Reset(ctx, key))
+}
@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer is a mailer which writes each email to a file instead of sending it
+// It's meant for local development and tests, where the emails can be read from its directory
+type FileMailer struct {
+	dir    string
+	sender string
+}
+
+// NewFileMailer creates a new FileMailer, creating its output directory if needed
+func NewFileMailer(dir string, sender string) (Mailer, error) {
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("mailer output path must be provided")
+	}
+
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer output directory: %w", err)
+	}
+
+	mailer := &FileMailer{
+		dir:    dir,
+		sender: sender,
+	}
+	return mailer, nil
+}
+
+// Send writes the message to a new file on the output directory, logging where it was written
+func (mailer *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	path := filepath.Join(mailer.dir, fmt.Sprintf("%d-%s.eml", now.UnixNano(), uuid.New()))
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		mailer.sender,
+		msg.To,
+		msg.Subject,
+		now.Format(time.RFC1123Z),
+		msg.Body,
+	)
+	err := os.WriteFile(path, []byte(content), 0o640)
+	if err != nil {
+		return fmt.Errorf("cannot write email: %w", err)
+	}
+
+	log.Printf("email %q to %s written to %s", msg.Subject, msg.To, path)
+	return nil
+}
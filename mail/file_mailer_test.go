@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewFileMailer(dir, "Simple Chat <no-reply@example.com>")
+	require.NoError(t, err)
+
+	msg := Message{
+		To:      util.RandomUsername() + "@example.com",
+		Subject: "Welcome",
+		Body:    util.RandomString(32),
+	}
+	for i := 0; i < 2; i++ {
+		err = mailer.Send(context.Background(), msg)
+		require.NoError(t, err)
+	}
+
+	// Each email gets a file of its own
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(content), "From: Simple Chat <no-reply@example.com>")
+	require.Contains(t, string(content), "To: "+msg.To)
+	require.Contains(t, string(content), "Subject: "+msg.Subject)
+	require.Contains(t, string(content), msg.Body)
+
+	_, err = NewFileMailer("", "")
+	require.Error(t, err)
+}
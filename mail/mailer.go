@@ -0,0 +1,18 @@
+package mail
+
+import (
+	"context"
+)
+
+// Message is an email to be sent to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is an interface for sending emails, such as verification codes
+type Mailer interface {
+	// Send delivers the message to its recipient
+	Send(ctx context.Context, msg Message) error
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/renatomh/api-simplechat/api"
 	db "github.com/renatomh/api-simplechat/db/sqlc"
+	"github.com/renatomh/api-simplechat/token"
 	"github.com/renatomh/api-simplechat/util"
 )
 
@@ -23,8 +24,20 @@ func main() {
 		log.Fatal("cannot connect to database:", err)
 	}
 
+	// The token settings are checked before starting, failing on any missing or invalid key
+	tokenMaker, err := token.NewMaker(token.MakerConfig{
+		Type:           token.MakerType(config.TokenType),
+		SymmetricKey:   config.TokenSymmetricKey,
+		KeyringPath:    config.TokenKeyringPath,
+		PrivateKeyPath: config.TokenPrivateKeyPath,
+		PublicKeyPath:  config.TokenPublicKeyPath,
+	})
+	if err != nil {
+		log.Fatal("cannot create token maker:", err)
+	}
+
 	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+	server, err := api.NewServer(config, store, tokenMaker)
 	if err != nil {
 		log.Fatal("cannot create server:", err)
 	}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Different types of errors can be returned by the blob stores
+var (
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrInvalidBlobKey = errors.New("blob key is not valid")
+)
+
+// BlobStore is an interface for storing binary files, such as attachments
+type BlobStore interface {
+	// Put stores the content read from r under a specific key, returning its size
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+
+	// Open returns a reader for the content stored under a specific key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the content stored under a specific key
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore is a blob store which keeps the files on the local filesystem
+type LocalBlobStore struct {
+	root string
+}
+
+// NewLocalBlobStore creates a new LocalBlobStore, creating its root directory if needed
+func NewLocalBlobStore(root string) (BlobStore, error) {
+	if len(root) == 0 {
+		return nil, fmt.Errorf("blob storage path must be provided")
+	}
+
+	err := os.MkdirAll(root, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create blob storage directory: %w", err)
+	}
+
+	store := &LocalBlobStore{
+		root: root,
+	}
+	return store, nil
+}
+
+// path gets the file path for a key, which must not escape the root directory
+func (store *LocalBlobStore) path(key string) (string, error) {
+	if len(key) == 0 || strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", ErrInvalidBlobKey
+	}
+	return filepath.Join(store.root, key), nil
+}
+
+// Put stores the content read from r under a specific key, returning its size
+func (store *LocalBlobStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	// Writing to a temporary file first, so a failed upload never leaves a partial blob behind
+	file, err := os.CreateTemp(store.root, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	size, err := io.Copy(file, r)
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	if err = file.Close(); err != nil {
+		return 0, err
+	}
+
+	if err = os.Rename(file.Name(), path); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// Open returns a reader for the content stored under a specific key
+func (store *LocalBlobStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+// Delete removes the content stored under a specific key
+func (store *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrBlobNotFound
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/renatomh/api-simplechat/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	key := util.RandomString(16)
+	content := []byte(util.RandomString(64))
+
+	size, err := store.Put(context.Background(), key, bytes.NewReader(content))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(content)), size)
+
+	reader, err := store.Open(context.Background(), key)
+	require.NoError(t, err)
+	stored, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	require.Equal(t, content, stored)
+
+	err = store.Delete(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = store.Open(context.Background(), key)
+	require.EqualError(t, err, ErrBlobNotFound.Error())
+
+	err = store.Delete(context.Background(), key)
+	require.EqualError(t, err, ErrBlobNotFound.Error())
+}
+
+func TestLocalBlobStoreInvalidKey(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", ".", "..", "../secret", "dir/file", `dir\file`} {
+		_, err = store.Put(context.Background(), key, bytes.NewReader([]byte("content")))
+		require.EqualError(t, err, ErrInvalidBlobKey.Error())
+
+		_, err = store.Open(context.Background(), key)
+		require.EqualError(t, err, ErrInvalidBlobKey.Error())
+
+		err = store.Delete(context.Background(), key)
+		require.EqualError(t, err, ErrInvalidBlobKey.Error())
+	}
+}
+
+func TestNewLocalBlobStoreEmptyPath(t *testing.T) {
+	store, err := NewLocalBlobStore("")
+	require.Error(t, err)
+	require.Nil(t, store)
+}
@@ -0,0 +1,100 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// LoadEd25519PrivateKey reads an Ed25519 private key from a PKCS #8 PEM file
+// The public key used to verify tokens can be derived from it
+func LoadEd25519PrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMFile(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key %s: %w", path, err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is not an Ed25519 key", path)
+	}
+	return privateKey, nil
+}
+
+// LoadEd25519PublicKey reads an Ed25519 public key from a PKIX PEM file
+func LoadEd25519PublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMFile(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key %s: %w", path, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", path)
+	}
+	return publicKey, nil
+}
+
+func readPEMFile(path string, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s must contain a PEM encoded %s", path, blockType)
+	}
+	return block, nil
+}
+
+// SigningMethodEdDSA signs JWTs with Ed25519 keys, which jwt-go doesn't support out of the box
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (method *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+func (method *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+func (method *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePEMFile(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	require.NoError(t, err)
+	return path
+}
+
+func TestLoadEd25519Keys(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	loadedPrivateKey, err := LoadEd25519PrivateKey(writePEMFile(t, "PRIVATE KEY", privateDER))
+	require.NoError(t, err)
+	require.True(t, privateKey.Equal(loadedPrivateKey))
+
+	loadedPublicKey, err := LoadEd25519PublicKey(writePEMFile(t, "PUBLIC KEY", publicDER))
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(loadedPublicKey))
+
+	// Keys must be stored in the expected PEM block
+	_, err = LoadEd25519PrivateKey(writePEMFile(t, "PUBLIC KEY", publicDER))
+	require.Error(t, err)
+	_, err = LoadEd25519PublicKey(writePEMFile(t, "PRIVATE KEY", privateDER))
+	require.Error(t, err)
+
+	_, err = LoadEd25519PrivateKey(filepath.Join(t.TempDir(), "missing.pem"))
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidConfig is returned by NewMaker when the token settings can't be used
+var ErrInvalidConfig = errors.New("invalid token config")
+
+// MakerType selects the token format and signing algorithm
+type MakerType string
+
+// Different types of makers can be created from the config
+const (
+	MakerTypePasetoLocal  MakerType = "paseto-local"
+	MakerTypeJWTHS256     MakerType = "jwt-hs256"
+	MakerTypePasetoPublic MakerType = "paseto-v4-public"
+	MakerTypeJWTEdDSA     MakerType = "jwt-eddsa"
+)
+
+// MakerConfig holds the settings used to create a token maker
+// Symmetric makers use the keys from the keyring file when given, or else the symmetric key
+// Asymmetric makers only verify tokens when given just the public key file
+type MakerConfig struct {
+	Type           MakerType
+	SymmetricKey   string
+	KeyringPath    string
+	PrivateKeyPath string
+	PublicKeyPath  string
+}
+
+// NewMaker creates the token maker selected by the config, checking its key material
+// An empty type defaults to PASETO v2.local, which was the only one supported before
+func NewMaker(config MakerConfig) (Maker, error) {
+	var maker Maker
+	var err error
+
+	switch config.Type {
+	case "", MakerTypePasetoLocal:
+		maker, err = newSymmetricMaker(config, NewPasetoMaker, NewPasetoKeyringMaker)
+	case MakerTypeJWTHS256:
+		maker, err = newSymmetricMaker(config, NewJWTMaker, NewJWTKeyringMaker)
+	case MakerTypePasetoPublic:
+		maker, err = newAsymmetricMaker(config, NewPasetoPublicMaker, NewPasetoPublicVerifier)
+	case MakerTypeJWTEdDSA:
+		maker, err = newAsymmetricMaker(config, NewJWTEdDSAMaker, NewJWTEdDSAVerifier)
+	default:
+		err = fmt.Errorf("unknown type %q, must be one of %q, %q, %q or %q", config.Type,
+			MakerTypePasetoLocal, MakerTypeJWTHS256, MakerTypePasetoPublic, MakerTypeJWTEdDSA)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	return maker, nil
+}
+
+func newSymmetricMaker(
+	config MakerConfig,
+	newMaker func(symmetricKey string) (Maker, error),
+	newKeyringMaker func(keyring *Keyring) (Maker, error),
+) (Maker, error) {
+	if config.KeyringPath == "" {
+		return newMaker(config.SymmetricKey)
+	}
+
+	keyring, err := LoadKeyring(config.KeyringPath)
+	if err != nil {
+		return nil, err
+	}
+	// Catching keyrings without an active key on startup, instead of failing on the first login
+	_, err = keyring.signingKey(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newKeyringMaker(keyring)
+}
+
+func newAsymmetricMaker(
+	config MakerConfig,
+	newMaker func(privateKey ed25519.PrivateKey) (Maker, error),
+	newVerifier func(publicKey ed25519.PublicKey) (Maker, error),
+) (Maker, error) {
+	var privateKey ed25519.PrivateKey
+	var publicKey ed25519.PublicKey
+	var err error
+
+	if config.PrivateKeyPath == "" && config.PublicKeyPath == "" {
+		return nil, fmt.Errorf("a private or public key file is required")
+	}
+	if config.PublicKeyPath != "" {
+		publicKey, err = LoadEd25519PublicKey(config.PublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.PrivateKeyPath == "" {
+		return newVerifier(publicKey)
+	}
+
+	privateKey, err = LoadEd25519PrivateKey(config.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	// Catching mismatched files on startup, instead of issuing tokens no other service accepts
+	if publicKey != nil && !publicKey.Equal(privateKey.Public()) {
+		return nil, fmt.Errorf("public key %s doesn't match private key %s", config.PublicKeyPath, config.PrivateKeyPath)
+	}
+	return newMaker(privateKey)
+}
@@ -0,0 +1,138 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMaker(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	otherPublicDER, err := x509.MarshalPKIXPublicKey(otherPublicKey)
+	require.NoError(t, err)
+
+	privateKeyPath := writePEMFile(t, "PRIVATE KEY", privateDER)
+	publicKeyPath := writePEMFile(t, "PUBLIC KEY", publicDER)
+	otherPublicKeyPath := writePEMFile(t, "PUBLIC KEY", otherPublicDER)
+
+	writeKeyringFile := func(keys ...SigningKey) string {
+		path := filepath.Join(t.TempDir(), "keyring.json")
+		keyring, err := json.Marshal(keys)
+		require.NoError(t, err)
+		err = os.WriteFile(path, keyring, 0600)
+		require.NoError(t, err)
+		return path
+	}
+	keyringPath := writeKeyringFile(randomSigningKey(time.Now().Add(-time.Hour), time.Time{}))
+	pendingKeyringPath := writeKeyringFile(randomSigningKey(time.Now().Add(time.Hour), time.Time{}))
+	retiredKeyringPath := writeKeyringFile(randomSigningKey(time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)))
+
+	testCases := []struct {
+		name         string
+		config       MakerConfig
+		expectedType Maker
+		verifierOnly bool
+	}{
+		{
+			name:         "Default",
+			config:       MakerConfig{SymmetricKey: util.RandomString(32)},
+			expectedType: &PasetoMaker{},
+		},
+		{
+			name:         "PasetoLocal",
+			config:       MakerConfig{Type: MakerTypePasetoLocal, SymmetricKey: util.RandomString(32)},
+			expectedType: &PasetoMaker{},
+		},
+		{
+			name:         "PasetoLocalKeyring",
+			config:       MakerConfig{Type: MakerTypePasetoLocal, KeyringPath: keyringPath},
+			expectedType: &PasetoKeyringMaker{},
+		},
+		{
+			name:         "JWTHS256",
+			config:       MakerConfig{Type: MakerTypeJWTHS256, SymmetricKey: util.RandomString(32)},
+			expectedType: &JWTMaker{},
+		},
+		{
+			name:         "JWTHS256Keyring",
+			config:       MakerConfig{Type: MakerTypeJWTHS256, KeyringPath: keyringPath},
+			expectedType: &JWTKeyringMaker{},
+		},
+		{
+			name:         "PasetoPublic",
+			config:       MakerConfig{Type: MakerTypePasetoPublic, PrivateKeyPath: privateKeyPath, PublicKeyPath: publicKeyPath},
+			expectedType: &PasetoPublicMaker{},
+		},
+		{
+			name:         "PasetoPublicVerifier",
+			config:       MakerConfig{Type: MakerTypePasetoPublic, PublicKeyPath: publicKeyPath},
+			expectedType: &PasetoPublicMaker{},
+			verifierOnly: true,
+		},
+		{
+			name:         "JWTEdDSA",
+			config:       MakerConfig{Type: MakerTypeJWTEdDSA, PrivateKeyPath: privateKeyPath},
+			expectedType: &JWTEdDSAMaker{},
+		},
+		{
+			name:         "JWTEdDSAVerifier",
+			config:       MakerConfig{Type: MakerTypeJWTEdDSA, PublicKeyPath: publicKeyPath},
+			expectedType: &JWTEdDSAMaker{},
+			verifierOnly: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(tc.config)
+			require.NoError(t, err)
+			require.IsType(t, tc.expectedType, maker)
+
+			_, _, err = maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+			if tc.verifierOnly {
+				require.ErrorIs(t, err, ErrVerifierOnly)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	invalidConfigs := map[string]MakerConfig{
+		"UnknownType":        {Type: "paseto-v3", SymmetricKey: util.RandomString(32)},
+		"ShortSymmetricKey":  {Type: MakerTypeJWTHS256, SymmetricKey: util.RandomString(16)},
+		"MissingKeyring":     {Type: MakerTypePasetoLocal, KeyringPath: filepath.Join(t.TempDir(), "missing.json")},
+		"PendingKeyring":     {Type: MakerTypePasetoLocal, KeyringPath: pendingKeyringPath},
+		"RetiredKeyring":     {Type: MakerTypeJWTHS256, KeyringPath: retiredKeyringPath},
+		"MissingKeyFiles":    {Type: MakerTypePasetoPublic},
+		"MissingPrivateKey":  {Type: MakerTypeJWTEdDSA, PrivateKeyPath: filepath.Join(t.TempDir(), "missing.pem")},
+		"PublicAsPrivateKey": {Type: MakerTypeJWTEdDSA, PrivateKeyPath: publicKeyPath},
+		"MismatchedKeys":     {Type: MakerTypePasetoPublic, PrivateKeyPath: privateKeyPath, PublicKeyPath: otherPublicKeyPath},
+	}
+
+	for name, config := range invalidConfigs {
+		t.Run(name, func(t *testing.T) {
+			maker, err := NewMaker(config)
+			require.ErrorIs(t, err, ErrInvalidConfig)
+			require.Nil(t, maker)
+		})
+	}
+}
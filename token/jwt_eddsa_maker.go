@@ -0,0 +1,81 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWTEdDSAMaker is a JSON Web Token maker signing with Ed25519 keys
+// Tokens are signed with the private key, so they can be verified by anyone holding the public key
+type JWTEdDSAMaker struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewJWTEdDSAMaker creates a new JWTEdDSAMaker which signs and verifies tokens
+func NewJWTEdDSAMaker(privateKey ed25519.PrivateKey) (Maker, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PrivateKeySize)
+	}
+
+	maker := &JWTEdDSAMaker{
+		privateKey: privateKey,
+		publicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	return maker, nil
+}
+
+// NewJWTEdDSAVerifier creates a new JWTEdDSAMaker which can only verify tokens
+func NewJWTEdDSAVerifier(publicKey ed25519.PublicKey) (Maker, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PublicKeySize)
+	}
+	return &JWTEdDSAMaker{publicKey: publicKey}, nil
+}
+
+// CreateToken creates a new token for a specific username, role, type and duration
+func (maker *JWTEdDSAMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", nil, ErrVerifierOnly
+	}
+
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(SigningMethodEdDSA, payload)
+	token, err := jwtToken.SignedString(maker.privateKey)
+	return token, payload, err
+}
+
+// VerifyToken checks if a token is valid
+func (maker *JWTEdDSAMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		// Only accepting the expected algorithm stops tokens from being signed with another one
+		if token.Method != SigningMethodEdDSA {
+			return nil, ErrInvalidToken
+		}
+		return maker.publicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,107 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTEdDSAMaker(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewJWTEdDSAMaker(privateKey)
+	require.NoError(t, err)
+
+	username := util.RandomUsername()
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, util.UserRole, TokenTypeAccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, TokenTypeAccessToken, payload.Type)
+	require.Equal(t, username, payload.Username)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestJWTEdDSAVerifier(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewJWTEdDSAMaker(privateKey)
+	require.NoError(t, err)
+	verifier, err := NewJWTEdDSAVerifier(publicKey)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	// Verifiers can't create tokens
+	token, payload, err = verifier.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.ErrorIs(t, err, ErrVerifierOnly)
+	require.Empty(t, token)
+	require.Nil(t, payload)
+}
+
+func TestExpiredJWTEdDSAToken(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewJWTEdDSAMaker(privateKey)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTEdDSATokenAlg(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewJWTEdDSAMaker(privateKey)
+	require.NoError(t, err)
+
+	payload, err := NewPayload(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// Neither unsigned nor HMAC tokens are accepted, even when signed with the public key
+	noneToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, payload).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString([]byte(privateKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	for _, token := range []string{noneToken, hmacToken} {
+		payload, err = maker.VerifyToken(token)
+		require.Error(t, err)
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	}
+}
@@ -0,0 +1,100 @@
+package token
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrNoSigningKey is returned when creating tokens while none of the keys is active
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// SigningKey is a key of a keyring, identified by its ID on the tokens it signs
+// The key signs new tokens once it activates, and verifies tokens until it retires, with a zero time meaning it never retires
+type SigningKey struct {
+	ID          string    `json:"id"`
+	Secret      string    `json:"secret"`
+	ActivatesAt time.Time `json:"activates_at"`
+	RetiresAt   time.Time `json:"retires_at"`
+}
+
+// Keyring holds the keys used to sign and verify tokens, allowing them to be rotated
+// Tokens are signed with the most recently activated key, while the previous keys still verify them until retired
+// The grace period between a new key's activation and the old key's retirement should outlast the refresh tokens
+type Keyring struct {
+	keys []SigningKey
+}
+
+// NewKeyring creates a new Keyring from its keys
+func NewKeyring(keys []SigningKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyring must have at least one key")
+	}
+
+	ids := make(map[string]bool)
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("keyring keys must have an ID")
+		}
+		if ids[key.ID] {
+			return nil, fmt.Errorf("duplicate keyring key ID %q", key.ID)
+		}
+		if !key.RetiresAt.IsZero() && !key.RetiresAt.After(key.ActivatesAt) {
+			return nil, fmt.Errorf("keyring key %q must retire after it activates", key.ID)
+		}
+		ids[key.ID] = true
+	}
+
+	return &Keyring{keys: keys}, nil
+}
+
+// LoadKeyring reads a keyring from a JSON file holding a list of keys, such as:
+// [{"id": "2024-01", "secret": "...", "activates_at": "2024-01-01T00:00:00Z", "retires_at": "2024-02-01T00:00:00Z"}]
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []SigningKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("cannot parse keyring %s: %w", path, err)
+	}
+	return NewKeyring(keys)
+}
+
+// signingKey returns the key to sign new tokens with, which is the most recently activated one
+func (keyring *Keyring) signingKey(now time.Time) (SigningKey, error) {
+	var current *SigningKey
+	for i := range keyring.keys {
+		key := &keyring.keys[i]
+		if key.ActivatesAt.After(now) || key.retired(now) {
+			continue
+		}
+		if current == nil || key.ActivatesAt.After(current.ActivatesAt) {
+			current = key
+		}
+	}
+
+	if current == nil {
+		return SigningKey{}, ErrNoSigningKey
+	}
+	return *current, nil
+}
+
+// verifyingKey returns the key with the given ID, as long as it isn't retired
+// Keys verify tokens even before activating, so they can be rolled out to every service ahead of time
+func (keyring *Keyring) verifyingKey(id string, now time.Time) (SigningKey, bool) {
+	for _, key := range keyring.keys {
+		if key.ID == id && !key.retired(now) {
+			return key, true
+		}
+	}
+	return SigningKey{}, false
+}
+
+func (key SigningKey) retired(now time.Time) bool {
+	return !key.RetiresAt.IsZero() && !now.Before(key.RetiresAt)
+}
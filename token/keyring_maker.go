@@ -0,0 +1,145 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aead/chacha20poly1305"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/o1egl/paseto"
+)
+
+// PasetoKeyringMaker is a PASETO token maker which rotates its keys, keeping their IDs on the token footer
+type PasetoKeyringMaker struct {
+	paseto  *paseto.V2
+	keyring *Keyring
+}
+
+// keyFooter identifies the key which encrypted a PASETO token
+type keyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// NewPasetoKeyringMaker creates a new PasetoKeyringMaker
+func NewPasetoKeyringMaker(keyring *Keyring) (Maker, error) {
+	for _, key := range keyring.keys {
+		if len(key.Secret) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("invalid size for key %q: must be exactly %d characters", key.ID, chacha20poly1305.KeySize)
+		}
+	}
+
+	maker := &PasetoKeyringMaker{
+		paseto:  paseto.NewV2(),
+		keyring: keyring,
+	}
+	return maker, nil
+}
+
+// CreateToken creates a new token for a specific username, role, type and duration
+func (maker *PasetoKeyringMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	key, err := maker.keyring.signingKey(time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	token, err := maker.paseto.Encrypt([]byte(key.Secret), payload, keyFooter{KeyID: key.ID})
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoKeyringMaker) VerifyToken(token string) (*Payload, error) {
+	// The footer isn't encrypted, but tampering with it makes the decryption fail
+	var footer keyFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, ok := maker.keyring.verifyingKey(footer.KeyID, time.Now())
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	err := maker.paseto.Decrypt(token, []byte(key.Secret), payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// JWTKeyringMaker is a JSON Web Token maker which rotates its keys, keeping their IDs on the kid header
+type JWTKeyringMaker struct {
+	keyring *Keyring
+}
+
+// NewJWTKeyringMaker creates a new JWTKeyringMaker
+func NewJWTKeyringMaker(keyring *Keyring) (Maker, error) {
+	for _, key := range keyring.keys {
+		if len(key.Secret) < minSecretKeySize {
+			return nil, fmt.Errorf("invalid size for key %q: must be at least %d characters", key.ID, minSecretKeySize)
+		}
+	}
+	return &JWTKeyringMaker{keyring}, nil
+}
+
+// CreateToken creates a new token for a specific username, role, type and duration
+func (maker *JWTKeyringMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	key, err := maker.keyring.signingKey(time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken.Header["kid"] = key.ID
+	token, err := jwtToken.SignedString([]byte(key.Secret))
+	return token, payload, err
+}
+
+// VerifyToken checks if a token is valid
+func (maker *JWTKeyringMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		_, ok := token.Method.(*jwt.SigningMethodHMAC)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		keyID, _ := token.Header["kid"].(string)
+		key, ok := maker.keyring.verifyingKey(keyID, time.Now())
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return []byte(key.Secret), nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
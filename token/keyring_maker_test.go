@@ -0,0 +1,180 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+var keyringMakers = []struct {
+	name     string
+	newMaker func(keyring *Keyring) (Maker, error)
+}{
+	{name: "PASETO", newMaker: NewPasetoKeyringMaker},
+	{name: "JWT", newMaker: NewJWTKeyringMaker},
+}
+
+func TestKeyringMaker(t *testing.T) {
+	for _, km := range keyringMakers {
+		t.Run(km.name, func(t *testing.T) {
+			keyring, err := NewKeyring([]SigningKey{randomSigningKey(time.Now().Add(-time.Hour), time.Time{})})
+			require.NoError(t, err)
+
+			maker, err := km.newMaker(keyring)
+			require.NoError(t, err)
+
+			username := util.RandomUsername()
+			duration := time.Minute
+
+			issuedAt := time.Now()
+			expiredAt := issuedAt.Add(duration)
+
+			token, payload, err := maker.CreateToken(username, util.UserRole, TokenTypeAccessToken, duration)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+			require.NotEmpty(t, payload)
+
+			payload, err = maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			require.NotZero(t, payload.ID)
+			require.Equal(t, TokenTypeAccessToken, payload.Type)
+			require.Equal(t, username, payload.Username)
+			require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+			require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+			token, payload, err = maker.CreateToken(username, util.UserRole, TokenTypeAccessToken, -time.Minute)
+			require.NoError(t, err)
+
+			payload, err = maker.VerifyToken(token)
+			require.EqualError(t, err, ErrExpiredToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestKeyringMakerRotation(t *testing.T) {
+	for _, km := range keyringMakers {
+		t.Run(km.name, func(t *testing.T) {
+			now := time.Now()
+			oldKey := randomSigningKey(now.Add(-24*time.Hour), time.Time{})
+
+			keyring, err := NewKeyring([]SigningKey{oldKey})
+			require.NoError(t, err)
+			maker, err := km.newMaker(keyring)
+			require.NoError(t, err)
+
+			oldToken, _, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeRefreshToken, time.Hour)
+			require.NoError(t, err)
+
+			// Rotating the keys signs new tokens with the new key, while the old one is in its grace period
+			newKey := randomSigningKey(now.Add(-time.Minute), time.Time{})
+			oldKey.RetiresAt = now.Add(time.Hour)
+
+			keyring, err = NewKeyring([]SigningKey{oldKey, newKey})
+			require.NoError(t, err)
+			rotatedMaker, err := km.newMaker(keyring)
+			require.NoError(t, err)
+
+			payload, err := rotatedMaker.VerifyToken(oldToken)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			newToken, _, err := rotatedMaker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Hour)
+			require.NoError(t, err)
+
+			// Only the new key signs the new tokens
+			_, err = maker.VerifyToken(newToken)
+			require.ErrorIs(t, err, ErrInvalidToken)
+
+			newOnlyKeyring, err := NewKeyring([]SigningKey{newKey})
+			require.NoError(t, err)
+			newOnlyMaker, err := km.newMaker(newOnlyKeyring)
+			require.NoError(t, err)
+
+			_, err = newOnlyMaker.VerifyToken(newToken)
+			require.NoError(t, err)
+
+			// Tokens signed with the old key are rejected once it retires
+			oldKey.RetiresAt = now.Add(-time.Second)
+			keyring, err = NewKeyring([]SigningKey{oldKey, newKey})
+			require.NoError(t, err)
+			retiredMaker, err := km.newMaker(keyring)
+			require.NoError(t, err)
+
+			payload, err = retiredMaker.VerifyToken(oldToken)
+			require.ErrorIs(t, err, ErrInvalidToken)
+			require.Nil(t, payload)
+
+			_, err = retiredMaker.VerifyToken(newToken)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyringMakerInvalidToken(t *testing.T) {
+	for _, km := range keyringMakers {
+		t.Run(km.name, func(t *testing.T) {
+			key := randomSigningKey(time.Now().Add(-time.Hour), time.Time{})
+
+			keyring, err := NewKeyring([]SigningKey{key})
+			require.NoError(t, err)
+			maker, err := km.newMaker(keyring)
+			require.NoError(t, err)
+
+			// A key with the same ID but another secret can't forge tokens
+			forgedKey := key
+			forgedKey.Secret = util.RandomString(32)
+			forgedKeyring, err := NewKeyring([]SigningKey{forgedKey})
+			require.NoError(t, err)
+			forgedMaker, err := km.newMaker(forgedKeyring)
+			require.NoError(t, err)
+
+			forgedToken, _, err := forgedMaker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+			require.NoError(t, err)
+
+			// Tokens without a key ID aren't accepted either
+			unkeyedMaker, err := NewPasetoMaker(key.Secret)
+			require.NoError(t, err)
+			unkeyedToken, _, err := unkeyedMaker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+			require.NoError(t, err)
+
+			for _, token := range []string{forgedToken, unkeyedToken, "invalid"} {
+				payload, err := maker.VerifyToken(token)
+				require.ErrorIs(t, err, ErrInvalidToken)
+				require.Nil(t, payload)
+			}
+		})
+	}
+}
+
+func TestKeyringMakerNoSigningKey(t *testing.T) {
+	for _, km := range keyringMakers {
+		t.Run(km.name, func(t *testing.T) {
+			keyring, err := NewKeyring([]SigningKey{randomSigningKey(time.Now().Add(time.Hour), time.Time{})})
+			require.NoError(t, err)
+			maker, err := km.newMaker(keyring)
+			require.NoError(t, err)
+
+			token, payload, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+			require.ErrorIs(t, err, ErrNoSigningKey)
+			require.Empty(t, token)
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestKeyringMakerInvalidKeySize(t *testing.T) {
+	keyring, err := NewKeyring([]SigningKey{{ID: "short", Secret: util.RandomString(16)}})
+	require.NoError(t, err)
+
+	for _, km := range keyringMakers {
+		maker, err := km.newMaker(keyring)
+		require.Error(t, err)
+		require.Nil(t, maker)
+	}
+}
@@ -0,0 +1,97 @@
+package token
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomSigningKey(activatesAt, retiresAt time.Time) SigningKey {
+	return SigningKey{
+		ID:          util.RandomString(8),
+		Secret:      util.RandomString(32),
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+	}
+}
+
+func TestKeyringSigningKey(t *testing.T) {
+	now := time.Now()
+	retired := randomSigningKey(now.Add(-3*time.Hour), now.Add(-time.Hour))
+	previous := randomSigningKey(now.Add(-2*time.Hour), now.Add(time.Hour))
+	current := randomSigningKey(now.Add(-time.Minute), time.Time{})
+	upcoming := randomSigningKey(now.Add(time.Hour), time.Time{})
+
+	keyring, err := NewKeyring([]SigningKey{retired, current, upcoming, previous})
+	require.NoError(t, err)
+
+	// New tokens are signed with the most recently activated key
+	key, err := keyring.signingKey(now)
+	require.NoError(t, err)
+	require.Equal(t, current.ID, key.ID)
+
+	key, err = keyring.signingKey(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, upcoming.ID, key.ID)
+
+	_, err = keyring.signingKey(now.Add(-4 * time.Hour))
+	require.ErrorIs(t, err, ErrNoSigningKey)
+
+	// Every key verifies tokens until it retires
+	for _, key := range []SigningKey{previous, current, upcoming} {
+		_, ok := keyring.verifyingKey(key.ID, now)
+		require.True(t, ok)
+	}
+	_, ok := keyring.verifyingKey(retired.ID, now)
+	require.False(t, ok)
+	_, ok = keyring.verifyingKey(previous.ID, now.Add(time.Hour))
+	require.False(t, ok)
+	_, ok = keyring.verifyingKey("unknown", now)
+	require.False(t, ok)
+}
+
+func TestNewKeyringInvalidKeys(t *testing.T) {
+	now := time.Now()
+	key := randomSigningKey(now, time.Time{})
+	unnamedKey := randomSigningKey(now, time.Time{})
+	unnamedKey.ID = ""
+
+	for _, keys := range [][]SigningKey{
+		{},
+		{unnamedKey},
+		{key, key},
+		{randomSigningKey(now, now.Add(-time.Minute))},
+	} {
+		keyring, err := NewKeyring(keys)
+		require.Error(t, err)
+		require.Nil(t, keyring)
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	err := os.WriteFile(path, []byte(`[
+		{"id": "old", "secret": "12345678901234567890123456789012", "activates_at": "2024-01-01T00:00:00Z", "retires_at": "2024-02-01T00:00:00Z"},
+		{"id": "new", "secret": "abcdefghijklmnopqrstuvwxyzabcdef", "activates_at": "2024-01-25T00:00:00Z"}
+	]`), 0600)
+	require.NoError(t, err)
+
+	keyring, err := LoadKeyring(path)
+	require.NoError(t, err)
+	require.Len(t, keyring.keys, 2)
+
+	key, err := keyring.signingKey(time.Date(2024, 1, 26, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "new", key.ID)
+	require.True(t, key.RetiresAt.IsZero())
+
+	err = os.WriteFile(path, []byte(`{"id": "old"}`), 0600)
+	require.NoError(t, err)
+	_, err = LoadKeyring(path)
+	require.Error(t, err)
+}
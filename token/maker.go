@@ -4,8 +4,8 @@ import "time"
 
 // Maker is an interface for managing tokens
 type Maker interface {
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateToken creates a new token for a specific username, role, type and duration
+	CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
 
 	// VerifyToken checks if a token is valid
 	VerifyToken(token string) (*Payload, error)
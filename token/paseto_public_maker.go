@@ -0,0 +1,108 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const pasetoPublicHeader = "v4.public."
+
+// PasetoPublicMaker is a PASETO v4.public token maker
+// Tokens are signed with an Ed25519 private key, so they can be verified by anyone holding the public key
+type PasetoPublicMaker struct {
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewPasetoPublicMaker creates a new PasetoPublicMaker which signs and verifies tokens
+func NewPasetoPublicMaker(privateKey ed25519.PrivateKey) (Maker, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PrivateKeySize)
+	}
+
+	maker := &PasetoPublicMaker{
+		privateKey: privateKey,
+		publicKey:  privateKey.Public().(ed25519.PublicKey),
+	}
+	return maker, nil
+}
+
+// NewPasetoPublicVerifier creates a new PasetoPublicMaker which can only verify tokens
+func NewPasetoPublicVerifier(publicKey ed25519.PublicKey) (Maker, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PublicKeySize)
+	}
+	return &PasetoPublicMaker{publicKey: publicKey}, nil
+}
+
+// CreateToken creates a new token for a specific username, role, type and duration
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", nil, ErrVerifierOnly
+	}
+
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	signature := ed25519.Sign(maker.privateKey, preAuthEncode([]byte(pasetoPublicHeader), message, nil, nil))
+	token := pasetoPublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	return token, payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	if !strings.HasPrefix(token, pasetoPublicHeader) {
+		return nil, ErrInvalidToken
+	}
+
+	// The footer is optional, but it's signed along with the message when present
+	body, encodedFooter, _ := strings.Cut(strings.TrimPrefix(token, pasetoPublicHeader), ".")
+	signed, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(signed) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+	footer, err := base64.RawURLEncoding.DecodeString(encodedFooter)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	message := signed[:len(signed)-ed25519.SignatureSize]
+	signature := signed[len(signed)-ed25519.SignatureSize:]
+	if !ed25519.Verify(maker.publicKey, preAuthEncode([]byte(pasetoPublicHeader), message, footer, nil), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	if err := json.Unmarshal(message, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// preAuthEncode packs the pieces of a token which are signed, as defined by the PASETO spec
+func preAuthEncode(pieces ...[]byte) []byte {
+	encoded := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces)))
+	for _, piece := range pieces {
+		encoded = binary.LittleEndian.AppendUint64(encoded, uint64(len(piece)))
+		encoded = append(encoded, piece...)
+	}
+	return encoded
+}
@@ -0,0 +1,122 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoPublicMaker(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(privateKey)
+	require.NoError(t, err)
+
+	username := util.RandomUsername()
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, util.UserRole, TokenTypeAccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, TokenTypeAccessToken, payload.Type)
+	require.Equal(t, username, payload.Username)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestPasetoPublicVerifier(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(privateKey)
+	require.NoError(t, err)
+	verifier, err := NewPasetoPublicVerifier(publicKey)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	// Verifiers can't create tokens
+	token, payload, err = verifier.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.ErrorIs(t, err, ErrVerifierOnly)
+	require.Empty(t, token)
+	require.Nil(t, payload)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(privateKey)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.Error(t, err)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidPasetoPublicToken(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(privateKey)
+	require.NoError(t, err)
+	otherMaker, err := NewPasetoPublicMaker(otherPrivateKey)
+	require.NoError(t, err)
+	symmetricMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	otherToken, _, err := otherMaker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+	localToken, _, err := symmetricMaker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+	token, _, err := maker.CreateToken(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+
+	for _, invalidToken := range []string{otherToken, localToken, token + "x", token + ".Zm9vdGVy", "v4.public.", "invalid"} {
+		payload, err := maker.VerifyToken(invalidToken)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.Nil(t, payload)
+	}
+}
+
+func TestPasetoPublicSpecVector(t *testing.T) {
+	// Test vector 4-S-1 from the PASETO spec
+	privateKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	expected := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	signature := ed25519.Sign(privateKey, preAuthEncode([]byte(pasetoPublicHeader), message, nil, nil))
+	require.Equal(t, expected, pasetoPublicHeader+base64.RawURLEncoding.EncodeToString(append(message, signature...)))
+}
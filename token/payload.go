@@ -11,18 +11,35 @@ import (
 var (
 	ErrExpiredToken = errors.New("token has expired")
 	ErrInvalidToken = errors.New("token is not valid")
+	// Makers holding only a public key can verify tokens, but not create them
+	ErrVerifierOnly = errors.New("token maker can only verify tokens")
+)
+
+// TokenType defines what a token can be used for
+type TokenType string
+
+// Different types of tokens can be created by the makers
+const (
+	TokenTypeAccessToken  TokenType = "access"
+	TokenTypeRefreshToken TokenType = "refresh"
+	// Issued after the password on two-factor logins, only to be exchanged along with a code
+	TokenTypeMFAPendingToken TokenType = "mfa_pending"
 )
 
 // Payload contains the payload data of the token
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
+	Type      TokenType `json:"token_type"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Scopes    []string  `json:"scopes,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload with a specific username and duration
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// NewPayload creates a new token payload with a specific username, role, type and duration
+// The token is granted the scopes of the role it's created with
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -30,7 +47,10 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 
 	payload := &Payload{
 		ID:        tokenID,
+		Type:      tokenType,
 		Username:  username,
+		Role:      role,
+		Scopes:    scopesForRole(role),
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
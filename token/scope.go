@@ -0,0 +1,37 @@
+package token
+
+import "github.com/renatomh/api-simplechat/util"
+
+// Scopes grant access to the groups of routes which require them
+const (
+	ScopeAdminUsers    = "admin:users"
+	ScopeAdminMessages = "admin:messages"
+	ScopeAdminStats    = "admin:stats"
+)
+
+// roleScopes lists the scopes granted to each role, with regular users not needing any
+var roleScopes = map[string][]string{
+	util.AdminRole: {ScopeAdminUsers, ScopeAdminMessages, ScopeAdminStats},
+}
+
+// scopesForRole returns a copy of the scopes granted to the role, so payloads can't change them
+func scopesForRole(role string) []string {
+	return append([]string(nil), roleScopes[role]...)
+}
+
+// HasScopes checks if the token was granted every one of the scopes
+func (payload *Payload) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		granted := false
+		for _, payloadScope := range payload.Scopes {
+			if payloadScope == scope {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/renatomh/api-simplechat/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadScopes(t *testing.T) {
+	payload, err := NewPayload(util.RandomUsername(), util.AdminRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, util.AdminRole, payload.Role)
+	require.True(t, payload.HasScopes(ScopeAdminUsers, ScopeAdminStats))
+	require.True(t, payload.HasScopes())
+
+	// Changing the payload's scopes doesn't change the ones granted to the role
+	payload.Scopes[0] = "changed"
+	payload, err = NewPayload(util.RandomUsername(), util.AdminRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+	require.True(t, payload.HasScopes(ScopeAdminUsers))
+
+	payload, err = NewPayload(util.RandomUsername(), util.UserRole, TokenTypeAccessToken, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, util.UserRole, payload.Role)
+	require.Empty(t, payload.Scopes)
+	require.False(t, payload.HasScopes(ScopeAdminUsers))
+	require.True(t, payload.HasScopes())
+}
+
+func TestMakersKeepScopes(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pasetoMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	jwtMaker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+	pasetoPublicMaker, err := NewPasetoPublicMaker(privateKey)
+	require.NoError(t, err)
+	jwtEdDSAMaker, err := NewJWTEdDSAMaker(privateKey)
+	require.NoError(t, err)
+
+	for _, maker := range []Maker{pasetoMaker, jwtMaker, pasetoPublicMaker, jwtEdDSAMaker} {
+		token, _, err := maker.CreateToken(util.RandomUsername(), util.AdminRole, TokenTypeAccessToken, time.Minute)
+		require.NoError(t, err)
+
+		payload, err := maker.VerifyToken(token)
+		require.NoError(t, err)
+		require.Equal(t, util.AdminRole, payload.Role)
+		require.ElementsMatch(t, []string{ScopeAdminUsers, ScopeAdminMessages, ScopeAdminStats}, payload.Scopes)
+	}
+}
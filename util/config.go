@@ -9,11 +9,56 @@ import (
 // Config stores all configuration of the application
 // The values are read by viper from a config file or environment variables
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	MessageEditWindow    time.Duration `mapstructure:"MESSAGE_EDIT_WINDOW"`
+	MessageDeleteWindow  time.Duration `mapstructure:"MESSAGE_DELETE_WINDOW"`
+	// Tokens are created by the maker of the given type: paseto-local, jwt-hs256, paseto-v4-public or jwt-eddsa
+	TokenType string `mapstructure:"TOKEN_TYPE"`
+	// Asymmetric tokens are signed with an Ed25519 private key, and verifying them only needs the public key
+	// Both keys are read from PEM files, so services given just the public key can verify tokens but not create them
+	TokenPrivateKeyPath string `mapstructure:"TOKEN_PRIVATE_KEY_PATH"`
+	TokenPublicKeyPath  string `mapstructure:"TOKEN_PUBLIC_KEY_PATH"`
+	// Tokens are signed with the keys from the keyring file when given, so they can be rotated, instead of the symmetric key
+	TokenKeyringPath string `mapstructure:"TOKEN_KEYRING_PATH"`
+	// Time a rejected user must wait before requesting the same contact again
+	ContactRequestCooldown time.Duration `mapstructure:"CONTACT_REQUEST_COOLDOWN"`
+	// Lists are paginated with the default page size, unless clients ask for another one up to the max
+	DefaultPageSize int32 `mapstructure:"DEFAULT_PAGE_SIZE"`
+	MaxPageSize     int32 `mapstructure:"MAX_PAGE_SIZE"`
+	// Failed logins are backed off by account and by client IP, keeping the failures on the "memory" or "postgres" limiter
+	LoginLimiter          string        `mapstructure:"LOGIN_LIMITER"`
+	LoginMaxAttempts      int           `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginMaxAttemptsPerIP int           `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+	LoginBackoffDelay     time.Duration `mapstructure:"LOGIN_BACKOFF_DELAY"`
+	LoginLockoutDuration  time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	// Two-factor logins must be completed with a code before the MFA token expires
+	TOTPIssuer       string        `mapstructure:"TOTP_ISSUER"`
+	MFATokenDuration time.Duration `mapstructure:"MFA_TOKEN_DURATION"`
+	// Requests are rate limited by user, or by client IP on public routes, with 0 disabling a limit
+	// Each limit is the amount of requests allowed per period, which can also be made at once
+	RateLimitPeriod   time.Duration `mapstructure:"RATE_LIMIT_PERIOD"`
+	RateLimitDefault  int           `mapstructure:"RATE_LIMIT_DEFAULT"`
+	RateLimitPublic   int           `mapstructure:"RATE_LIMIT_PUBLIC"`
+	RateLimitMessages int           `mapstructure:"RATE_LIMIT_MESSAGES"`
+	RateLimitContacts int           `mapstructure:"RATE_LIMIT_CONTACTS"`
+	// Client IPs are only read from the X-Forwarded-For header when sent by these proxies, with none trusted by default
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	// Links sent by email point to the app's public URL
+	AppBaseURL string `mapstructure:"APP_BASE_URL"`
+	// Emails are written to files on the mailer output path, from the sender address
+	MailerOutputPath          string        `mapstructure:"MAILER_OUTPUT_PATH"`
+	MailSender                string        `mapstructure:"MAIL_SENDER"`
+	EmailVerificationDuration time.Duration `mapstructure:"EMAIL_VERIFICATION_DURATION"`
+	PasswordResetDuration     time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	// Attachments are kept on the local filesystem, limited by size and content type
+	BlobStoragePath        string   `mapstructure:"BLOB_STORAGE_PATH"`
+	AttachmentMaxSize      int64    `mapstructure:"ATTACHMENT_MAX_SIZE"`
+	AttachmentAllowedTypes []string `mapstructure:"ATTACHMENT_ALLOWED_TYPES"`
 }
 
 // LoadConfig reads configuration from file or environment variables
@@ -0,0 +1,7 @@
+package util
+
+// Roles a user can have, each one granting its own scopes to the user's tokens
+const (
+	UserRole  = "user"
+	AdminRole = "admin"
+)
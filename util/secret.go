@@ -0,0 +1,25 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// RandomSecret generates a URL safe secret from n cryptographically random bytes, for codes sent to users
+func RandomSecret(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// HashSecret returns the SHA-256 hash of a secret, which can be stored and looked up in its place
+// Unlike passwords, secrets are random enough not to need a slow hash
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package util
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// TOTP codes use the RFC 6238 defaults, which every authenticator app supports
+const totpPeriod = 30
+
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// GenerateTOTP generates a new TOTP secret for an account, returning it along with its otpauth URI
+func GenerateTOTP(issuer string, accountName string) (string, string, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateTOTP checks a TOTP code, allowing the time steps right before and after the current one for clock drift
+// The time step matched is returned, so callers can reject codes which were already used
+func ValidateTOTP(code string, secret string, now time.Time) (int64, bool) {
+	counter := now.Unix() / totpPeriod
+	for _, step := range []int64{counter, counter - 1, counter + 1} {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totpOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
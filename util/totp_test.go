@@ -0,0 +1,44 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTOTP(t *testing.T) {
+	secret, uri, err := GenerateTOTP("Simple Chat", "jack.doe")
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+
+	key, err := otp.NewKeyFromURL(uri)
+	require.NoError(t, err)
+	require.Equal(t, "totp", key.Type())
+	require.Equal(t, secret, key.Secret())
+	require.Equal(t, "Simple Chat", key.Issuer())
+	require.Equal(t, "jack.doe", key.AccountName())
+
+	now := time.Now()
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	counter, ok := ValidateTOTP(code, secret, now)
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30, counter)
+
+	// Codes from the neighbouring time steps are still accepted
+	counter, ok = ValidateTOTP(code, secret, now.Add(30*time.Second))
+	require.True(t, ok)
+	require.Equal(t, now.Unix()/30, counter)
+
+	_, ok = ValidateTOTP(code, secret, now.Add(2*time.Minute))
+	require.False(t, ok)
+
+	otherSecret, _, err := GenerateTOTP("Simple Chat", "jack.doe")
+	require.NoError(t, err)
+	_, ok = ValidateTOTP(code, otherSecret, now)
+	require.False(t, ok)
+}